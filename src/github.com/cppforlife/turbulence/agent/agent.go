@@ -1,14 +1,17 @@
 package main
 
 import (
+	"os"
 	"time"
 
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
 
+	"github.com/cppforlife/turbulence/fleet"
 	"github.com/cppforlife/turbulence/tasks"
 	"github.com/cppforlife/turbulence/tasks/monit"
+	"github.com/cppforlife/turbulence/tasks/netctl"
 )
 
 type Agent struct {
@@ -18,15 +21,22 @@ type Agent struct {
 	client        Client
 	monitProvider monit.ClientProvider
 	cmdRunner     boshsys.CmdRunner
+	netCtl        netctl.Controller
+
+	startedAt    time.Time
+	running      *runningTasks
+	results      *taskResults
+	lease        *lease
+	journal      *journal
+	capabilities []string
+
+	// Progress reports are frequent hence only one is sent at a time
+	progressPolls *coalescer
 
 	logTag string
 	logger boshlog.Logger
 }
 
-type agentTask interface {
-	Execute(stopCh chan struct{}) error
-}
-
 type AgentConfig struct {
 	APIHost string
 	APIPort int
@@ -48,6 +58,10 @@ func newAgent(
 	client Client,
 	monitProvider monit.ClientProvider,
 	cmdRunner boshsys.CmdRunner,
+	netCtl netctl.Controller,
+	capabilities []string,
+	leaseGracePeriod time.Duration,
+	journal *journal,
 	logger boshlog.Logger,
 ) Agent {
 	return Agent{
@@ -57,122 +71,263 @@ func newAgent(
 		client:        client,
 		monitProvider: monitProvider,
 		cmdRunner:     cmdRunner,
+		netCtl:        netCtl,
+
+		startedAt:    time.Now(),
+		running:      newRunningTasks(),
+		results:      newTaskResults(),
+		lease:        newLease(leaseGracePeriod),
+		journal:      journal,
+		capabilities: capabilities,
+
+		progressPolls: newCoalescer(),
 
 		logTag: "Agent",
 		logger: logger,
 	}
 }
 
-func (a Agent) ContiniouslyExecuteTasks() error {
-	a.logger.Info(a.logTag, "Started continiously executing tasks")
+// Recover undoes changes left behind by tasks that were running when
+// agent stopped, most recent first, and reports them to the API server
+// in the background since it may not be reachable yet.
+func (a Agent) Recover() {
+	entries := a.journal.Leftovers()
+	if len(entries) == 0 {
+		return
+	}
 
-	ticker := time.NewTicker(1 * time.Second)
+	var changes []fleet.RecoveredChange
+
+	for i := len(entries) - 1; i >= 0; i-- {
+		entry := entries[i]
+		change := fleet.RecoveredChange{TaskID: entry.TaskID, Description: entry.Description}
+
+		a.logger.Info(a.logTag, "Undoing leftover change of task '%s': %s", entry.TaskID, entry.Description)
+
+		var err error
+
+		if len(entry.UndoCmd) == 0 {
+			err = bosherr.Error("Missing undo command")
+		} else if netctl.IsUndoCmd(entry.UndoCmd) {
+			err = netctl.RunUndoCmd(a.netCtl, entry.UndoCmd)
+		} else {
+			_, _, _, err = a.cmdRunner.RunCommand(entry.UndoCmd[0], entry.UndoCmd[1:]...)
+		}
+
+		if err != nil {
+			a.logger.Error(a.logTag, "Failed undoing leftover change: %s", err.Error())
+			change.Error = err.Error()
+		}
+
+		changes = append(changes, change)
+	}
+
+	// Changes are not retried since undo commands may not be repeatable
+	a.journal.ForgetAll()
+
+	go a.reportRecovery(changes)
+}
+
+func (a Agent) reportRecovery(changes []fleet.RecoveredChange) {
+	retries := newBackoff(1*time.Second, 30*time.Second)
+
+	for {
+		err := a.client.ReportRecovery(a.agentID, fleet.RecoveryRequest{Changes: changes})
+		if err == nil {
+			return
+		}
+
+		delay := retries.Next()
+		a.logger.Error(a.logTag, "Failed reporting recovery (retrying in %s): %s", delay, err.Error())
+		time.Sleep(delay)
+	}
+}
+
+// Register lets API server know that agent (re)started; failing
+// to register is not fatal since heartbeats register agent as well.
+func (a Agent) Register() {
+	err := a.client.Register(a.agentID, a.heartbeatRequest())
+	if err != nil {
+		a.logger.Error(a.logTag, "Failed registering agent: %s", err.Error())
+		return
+	}
+
+	a.lease.Renew()
+}
+
+func (a Agent) ContiniouslySendHeartbeats() {
+	ticker := time.NewTicker(fleet.HeartbeatInterval)
 
 	for {
 		select {
 		case <-ticker.C:
-			tasks, err := a.client.FetchTasks(a.agentID)
+			err := a.client.Heartbeat(a.agentID, a.heartbeatRequest())
 			if err != nil {
-				a.logger.Error(a.logTag, "Failed fetching tasks: %s", err.Error())
+				a.logger.Error(a.logTag, "Failed sending heartbeat: %s", err.Error())
 			} else {
-				// Execute tasks in parallel
-				for _, task := range tasks {
-					go a.executeTask(task)
-				}
+				a.lease.Renew()
 			}
 		}
 	}
 }
 
-func (a Agent) executeTask(task tasks.Task) {
-	a.logger.Debug(a.logTag, "Received agent task options '%#v'", task)
+// ContiniouslyEnforceLease stops all active tasks once API server
+// has not been reached within lease grace period; results of stopped
+// tasks are reported once API server is reachable again.
+func (a Agent) ContiniouslyEnforceLease() {
+	ticker := time.NewTicker(1 * time.Second)
 
-	task1, err := a.buildAgentTask(task)
+	for {
+		select {
+		case now := <-ticker.C:
+			if !a.lease.Expired(now) {
+				continue
+			}
 
-	if task1 != nil && err == nil {
-		stopCh := make(chan struct{}, 1) // allow one stop
-		endPollCh := make(chan struct{}, 1)
-
-		go func() {
-			for {
-				select {
-				case <-endPollCh:
-					return
-				default:
-				}
-
-				resp, err := a.client.FetchTaskState(task.ID)
-				if err != nil {
-					a.logger.Error(a.logTag, "Failed fetching agent task control: %s", err.Error())
-				}
-
-				if resp.Stop {
-					close(stopCh)
-					return
-				}
-
-				time.Sleep(1 * time.Second)
+			taskIDs := a.running.ExpireLeases()
+			if len(taskIDs) > 0 {
+				a.logger.Error(a.logTag, "Stopping tasks %v since API server was not reached within %s",
+					taskIDs, a.lease.gracePeriod)
 			}
-		}()
+		}
+	}
+}
 
-		err = task1.Execute(stopCh)
+func (a Agent) heartbeatRequest() fleet.HeartbeatRequest {
+	hostname, err := os.Hostname()
+	if err != nil {
+		a.logger.Error(a.logTag, "Failed getting hostname: %s", err.Error())
+	}
+
+	return fleet.HeartbeatRequest{
+		Hostname: hostname,
+		Version:  agentVersion,
+		Uptime:   int64(time.Since(a.startedAt).Seconds()),
+
+		RunningTaskIDs: a.running.IDs(),
+		Capabilities:   a.capabilities,
+	}
+}
+
+func (a Agent) ContiniouslyExecuteTasks() error {
+	a.logger.Info(a.logTag, "Started continiously executing tasks")
+
+	retries := newBackoff(1*time.Second, 30*time.Second)
+
+	for {
+		err := a.poll(true)
 		if err != nil {
-			err = bosherr.WrapError(err, "Task execution")
-			a.logger.Error(a.logTag, "Failed executing agent task: %s", err.Error())
+			delay := retries.Next()
+			a.logger.Error(a.logTag, "Failed polling tasks (retrying in %s): %s", delay, err.Error())
+			time.Sleep(delay)
+			continue
 		}
 
-		close(endPollCh)
+		retries.Reset()
 	}
+}
+
+// poll delivers results of finished tasks, starts new tasks
+// and stops running tasks according to API server's response.
+func (a Agent) poll(wait bool) error {
+	results := a.results.Take()
 
-	err = a.client.RecordTaskResult(task.ID, err)
+	req := tasks.PollRequest{
+		RunningTaskIDs: a.running.UnstoppedIDs(),
+		Progress:       a.running.Progress(),
+		Results:        results,
+		Wait:           wait,
+	}
+
+	resp, err := a.client.Poll(a.agentID, req)
 	if err != nil {
-		a.logger.Error(a.logTag, "Failed updating agent task: %s", err.Error())
+		// Results will be delivered with one of the following polls
+		a.results.Return(results)
+		return err
+	}
+
+	a.lease.Renew()
+
+	for _, taskID := range resp.StopTaskIDs {
+		a.logger.Debug(a.logTag, "Stopping agent task '%s'", taskID)
+		a.running.Stop(taskID)
+	}
+
+	// Execute tasks in parallel
+	for _, task := range resp.Tasks {
+		recorder := tasks.NewResultRecorder(a.reportProgress)
+		stopCh := a.running.Add(task.ID, recorder)
+		go a.executeTask(task, stopCh, recorder)
 	}
+
+	return nil
 }
 
-func (a Agent) buildAgentTask(task tasks.Task) (agentTask, error) {
-	var t agentTask
-	var err error
+func (a Agent) executeTask(task tasks.Task, stopCh chan struct{}, recorder *tasks.ResultRecorder) {
+	a.logger.Debug(a.logTag, "Received agent task options '%#v'", task)
 
-	switch opts := task.Options().(type) {
-	case tasks.NoopOptions:
-		t = tasks.NewNoopTask(opts)
+	task1, err := a.buildAgentTask(task, recorder)
 
-	case tasks.KillProcessOptions:
-		monitClient, err := a.monitProvider.Get()
+	if task1 != nil && err == nil {
+		err = task1.Execute(stopCh)
 		if err != nil {
-			err = bosherr.WrapError(err, "Failed to retrieve monit client")
-		} else {
-			t = tasks.NewKillProcessTask(monitClient, a.cmdRunner, opts, a.logger)
+			err = bosherr.WrapError(err, "Task execution")
+			a.logger.Error(a.logTag, "Failed executing agent task: %s", err.Error())
 		}
 
-	case tasks.PauseProcessOptions:
-		t = tasks.NewPauseProcessTask(a.cmdRunner, opts, a.logger)
+		// Task is responsible for undoing its changes before returning
+		a.journal.Forget(task.ID)
+	}
 
-	case tasks.StressOptions:
-		t = tasks.NewStressTask(a.cmdRunner, opts, a.logger)
+	if leaseExpired := a.running.Remove(task.ID); leaseExpired {
+		msg := "Reverted since API server was not reached within %s"
 
-	case tasks.ControlNetOptions:
-		t = tasks.NewControlNetTask(a.cmdRunner, opts, a.logger)
+		if err != nil {
+			err = bosherr.WrapErrorf(err, msg, a.lease.gracePeriod)
+		} else {
+			err = bosherr.Errorf(msg, a.lease.gracePeriod)
+		}
+	}
 
-	case tasks.FirewallOptions:
-		t = tasks.NewFirewallTask(a.cmdRunner, opts, a.agentConfig.AllowedOutputDests(), a.logger)
+	a.results.Add(task.ID, recorder.Result(), err)
 
-	case tasks.TargetedBlockerOptions:
-		t = tasks.NewTargetedBlockerTask(a.cmdRunner, opts, a.logger)
+	// Report result right away instead of waiting for the pending long poll
+	err = a.poll(false)
+	if err != nil {
+		a.logger.Error(a.logTag, "Failed reporting agent task result: %s", err.Error())
+	}
+}
 
-	case tasks.BlockDNSOptions:
-		t = tasks.NewBlockDNSTask(a.cmdRunner, opts, a.logger)
+// reportProgress delivers progress of running tasks right away
+// instead of waiting for the pending long poll to return; progress
+// reported while previous report is in flight is delivered right after it
+func (a Agent) reportProgress() {
+	a.progressPolls.Run(func() {
+		err := a.poll(false)
+		if err != nil {
+			a.logger.Error(a.logTag, "Failed reporting agent task progress: %s", err.Error())
+		}
+	})
+}
+
+func (a Agent) buildAgentTask(task tasks.Task, recorder tasks.Recorder) (tasks.AgentTask, error) {
+	deps := tasks.AgentDeps{
+		CmdRunner:     a.cmdRunner,
+		MonitProvider: a.monitProvider,
+		NetCtl:        a.netCtl,
 
-	case tasks.FillDiskOptions:
-		t = tasks.NewFillDiskTask(a.cmdRunner, opts, a.logger)
+		AllowedOutputDests: a.agentConfig.AllowedOutputDests(),
 
-	case tasks.ShutdownOptions:
-		t = tasks.NewShutdownTask(a.cmdRunner, opts, a.logger)
+		TaskID:   task.ID,
+		Journal:  a.journal.ForTask(task.ID),
+		Recorder: recorder,
 
-	default:
-		err = bosherr.Errorf("Unknown agent task '%T'", task.Optionss[0])
-		a.logger.Error(a.logTag, "Ignoring unknown agent task '%T'", task.Optionss[0])
+		Logger: a.logger,
+	}
+
+	t, err := tasks.BuildAgentTask(task.Options(), deps)
+	if err != nil {
+		a.logger.Error(a.logTag, "Ignoring agent task '%T': %s", task.Options(), err.Error())
 	}
 
 	return t, err
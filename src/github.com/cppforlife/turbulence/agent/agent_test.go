@@ -0,0 +1,118 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	boshhttp "github.com/cloudfoundry/bosh-utils/httpclient"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cppforlife/turbulence/fleet"
+	"github.com/cppforlife/turbulence/tasks/monit"
+)
+
+type fakePost struct {
+	Endpoint string
+	Payload  []byte
+}
+
+// fakeHTTPClient responds successfully to all requests
+type fakeHTTPClient struct {
+	boshhttp.HTTPClient
+
+	postsCh chan fakePost
+}
+
+func (c fakeHTTPClient) Post(endpoint string, payload []byte) (*http.Response, error) {
+	c.postsCh <- fakePost{Endpoint: endpoint, Payload: payload}
+
+	resp := &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       ioutil.NopCloser(strings.NewReader("{}")),
+	}
+
+	return resp, nil
+}
+
+var _ = Describe("Agent", func() {
+	Describe("Recover", func() {
+		var (
+			fs         *fakesys.FakeFileSystem
+			cmdRunner  *fakesys.FakeCmdRunner
+			httpClient fakeHTTPClient
+			logger     boshlog.Logger
+		)
+
+		BeforeEach(func() {
+			fs = fakesys.NewFakeFileSystem()
+			cmdRunner = fakesys.NewFakeCmdRunner()
+			httpClient = fakeHTTPClient{postsCh: make(chan fakePost, 10)}
+			logger = boshlog.NewLogger(boshlog.LevelNone)
+		})
+
+		newAgentWithJournal := func(j *journal) Agent {
+			client := NewClient("https://api", httpClient, logger)
+			monitProvider := monit.NewClientProvider(fs, logger)
+
+			return newAgent("agent-1", AgentConfig{}, client, monitProvider, cmdRunner, nil, nil, time.Minute, j, logger)
+		}
+
+		It("undoes leftover changes most recent first and reports them", func() {
+			j, err := newJournal("/journal.json", fs, logger)
+			Expect(err).ToNot(HaveOccurred())
+
+			j.ForTask("task-1").Record("change 1", "undo", "1")
+			j.ForTask("task-2").Record("change 2", "undo", "2")
+			j.ForTask("task-1").Record("change 3")
+			j.ForTask("task-1").Record("change 4", "undo", "4")
+
+			cmdRunner.AddCmdResult("undo 2", fakesys.FakeCmdResult{Error: errors.New("fake-err")})
+
+			// Journal is loaded again as it would be by restarted agent
+			j, err = newJournal("/journal.json", fs, logger)
+			Expect(err).ToNot(HaveOccurred())
+
+			newAgentWithJournal(j).Recover()
+
+			Expect(cmdRunner.RunCommands).To(Equal([][]string{
+				{"undo", "4"},
+				{"undo", "2"},
+				{"undo", "1"},
+			}))
+
+			Expect(j.Leftovers()).To(BeEmpty())
+
+			var post fakePost
+			Eventually(httpClient.postsCh).Should(Receive(&post))
+			Expect(post.Endpoint).To(Equal("https://api/api/v1/agents/agent-1/recovery"))
+
+			var req fleet.RecoveryRequest
+
+			err = json.Unmarshal(post.Payload, &req)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(req.Changes).To(Equal([]fleet.RecoveredChange{
+				{TaskID: "task-1", Description: "change 4"},
+				{TaskID: "task-1", Description: "change 3", Error: "Missing undo command"},
+				{TaskID: "task-2", Description: "change 2", Error: "fake-err"},
+				{TaskID: "task-1", Description: "change 1"},
+			}))
+		})
+
+		It("does nothing when there are no leftover changes", func() {
+			j, err := newJournal("/journal.json", fs, logger)
+			Expect(err).ToNot(HaveOccurred())
+
+			newAgentWithJournal(j).Recover()
+
+			Expect(cmdRunner.RunCommands).To(BeEmpty())
+			Consistently(httpClient.postsCh).ShouldNot(Receive())
+		})
+	})
+})
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"math/rand"
+	"time"
+)
+
+// backoff spaces out retries of failing requests to the API server
+// so that restarted API server is not overwhelmed by all agents at once
+type backoff struct {
+	min     time.Duration
+	max     time.Duration
+	current time.Duration
+}
+
+func newBackoff(min, max time.Duration) *backoff {
+	return &backoff{min: min, max: max}
+}
+
+// Next doubles delay up to max and returns it with jitter of up to a half
+func (b *backoff) Next() time.Duration {
+	if b.current == 0 {
+		b.current = b.min
+	} else {
+		b.current *= 2
+	}
+
+	if b.current > b.max {
+		b.current = b.max
+	}
+
+	half := b.current / 2
+
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+func (b *backoff) Reset() {
+	b.current = 0
+}
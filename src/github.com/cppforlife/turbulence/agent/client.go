@@ -8,6 +8,7 @@ import (
 	boshhttp "github.com/cloudfoundry/bosh-utils/httpclient"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 
+	"github.com/cppforlife/turbulence/fleet"
 	"github.com/cppforlife/turbulence/tasks"
 )
 
@@ -25,52 +26,75 @@ func NewClient(endpoint string, httpClient boshhttp.HTTPClient, logger boshlog.L
 	return Client{clientRequest: clientRequest}
 }
 
-func (c Client) FetchTasks(agentID string) ([]tasks.Task, error) {
-	var resp []tasks.Task
+func (c Client) Register(agentID string, req fleet.HeartbeatRequest) error {
+	var resp interface{}
 
-	// todo use query string
-	// todo rename to agent_tasks
-	path := fmt.Sprintf("/api/v1/agents/%s/tasks", agentID)
+	path := fmt.Sprintf("/api/v1/agents/%s/register", agentID)
 
-	err := c.clientRequest.Post(path, nil, &resp)
+	bytes, err := json.Marshal(req)
 	if err != nil {
-		return resp, bosherr.WrapErrorf(err, "Fetching tasks '%s'", agentID)
+		return bosherr.WrapErrorf(err, "Marshalling registration")
 	}
 
-	return resp, nil
+	err = c.clientRequest.Post(path, bytes, &resp)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Registering agent '%s'", agentID)
+	}
+
+	return nil
 }
 
-func (c Client) FetchTaskState(taskID string) (tasks.StateResponse, error) {
-	var resp tasks.StateResponse
+func (c Client) Heartbeat(agentID string, req fleet.HeartbeatRequest) error {
+	var resp interface{}
 
-	path := fmt.Sprintf("/api/v1/agent_tasks/%s/state", taskID)
+	path := fmt.Sprintf("/api/v1/agents/%s/heartbeat", agentID)
 
-	err := c.clientRequest.Get(path, &resp)
+	bytes, err := json.Marshal(req)
 	if err != nil {
-		return resp, bosherr.WrapErrorf(err, "Fetching task '%s' state", taskID)
+		return bosherr.WrapErrorf(err, "Marshalling heartbeat")
 	}
 
-	return resp, nil
+	err = c.clientRequest.Post(path, bytes, &resp)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Sending heartbeat for agent '%s'", agentID)
+	}
+
+	return nil
 }
 
-func (c Client) RecordTaskResult(taskID string, err error) error {
-	var resp interface{}
+// Poll blocks until API server has new tasks or stop signals for the agent
+// when request asks to wait; otherwise it returns right away.
+func (c Client) Poll(agentID string, req tasks.PollRequest) (tasks.PollResponse, error) {
+	var resp tasks.PollResponse
+
+	path := fmt.Sprintf("/api/v1/agents/%s/poll", agentID)
 
-	path := fmt.Sprintf("/api/v1/agent_tasks/%s", taskID)
-	req := tasks.ResultRequest{}
+	bytes, err := json.Marshal(req)
+	if err != nil {
+		return resp, bosherr.WrapErrorf(err, "Marshalling poll")
+	}
 
+	err = c.clientRequest.Post(path, bytes, &resp)
 	if err != nil {
-		req.Error = err.Error()
+		return resp, bosherr.WrapErrorf(err, "Polling tasks for agent '%s'", agentID)
 	}
 
+	return resp, nil
+}
+
+func (c Client) ReportRecovery(agentID string, req fleet.RecoveryRequest) error {
+	var resp interface{}
+
+	path := fmt.Sprintf("/api/v1/agents/%s/recovery", agentID)
+
 	bytes, err := json.Marshal(req)
 	if err != nil {
-		return bosherr.WrapErrorf(err, "Marshalling task")
+		return bosherr.WrapErrorf(err, "Marshalling recovery")
 	}
 
 	err = c.clientRequest.Post(path, bytes, &resp)
 	if err != nil {
-		return bosherr.WrapErrorf(err, "Updating task '%s'", taskID)
+		return bosherr.WrapErrorf(err, "Reporting recovery of agent '%s'", agentID)
 	}
 
 	return nil
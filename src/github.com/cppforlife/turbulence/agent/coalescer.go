@@ -0,0 +1,48 @@
+package main
+
+import (
+	"sync"
+)
+
+// coalescer runs functions in the background one at a time;
+// functions given while one is running are coalesced into a single run
+// once it finishes, hence they are expected to do the same thing
+// (e.g. deliver latest state to the API server).
+type coalescer struct {
+	running bool
+	pending bool
+	lock    sync.Mutex
+}
+
+func newCoalescer() *coalescer {
+	return &coalescer{}
+}
+
+func (c *coalescer) Run(f func()) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.running {
+		c.pending = true
+		return
+	}
+
+	c.running = true
+
+	go func() {
+		for {
+			f()
+
+			c.lock.Lock()
+
+			if !c.pending {
+				c.running = false
+				c.lock.Unlock()
+				return
+			}
+
+			c.pending = false
+			c.lock.Unlock()
+		}
+	}()
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"sync"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("coalescer", func() {
+	It("runs requests made while one is running once it finishes", func() {
+		c := newCoalescer()
+
+		var runs int
+		var runsLock sync.Mutex
+
+		startedCh := make(chan struct{}, 10)
+		continueCh := make(chan struct{})
+
+		f := func() {
+			runsLock.Lock()
+			runs++
+			runsLock.Unlock()
+
+			startedCh <- struct{}{}
+			<-continueCh
+		}
+
+		c.Run(f)
+		Eventually(startedCh).Should(Receive())
+
+		for i := 0; i < 5; i++ {
+			c.Run(f)
+		}
+
+		close(continueCh)
+
+		Eventually(startedCh).Should(Receive())
+		Consistently(startedCh).ShouldNot(Receive())
+
+		runsLock.Lock()
+		Expect(runs).To(Equal(2))
+		runsLock.Unlock()
+
+		c.Run(f)
+		Eventually(startedCh).Should(Receive())
+	})
+})
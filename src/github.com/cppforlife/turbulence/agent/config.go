@@ -3,17 +3,33 @@ package main
 import (
 	"crypto/x509"
 	"encoding/json"
+	"time"
 
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
+
+	"github.com/cppforlife/turbulence/tasks"
 )
 
 type Config struct {
 	AgentID string
 
 	API APIConfig
+
+	// Active tasks are stopped when API server is not reached for this long
+	// (e.g. 2m); defaults to DefaultLeaseGracePeriod
+	LeaseGracePeriod string
+
+	// Path to the journal of changes applied by running tasks;
+	// defaults to DefaultJournalPath
+	JournalPath string
 }
 
+const (
+	DefaultLeaseGracePeriod = 2 * time.Minute
+	DefaultJournalPath      = "/var/vcap/data/turbulence_agent/journal.json"
+)
+
 type APIConfig struct {
 	Host string
 	Port int
@@ -52,9 +68,40 @@ func (c Config) Validate() error {
 		return bosherr.WrapError(err, "Validating 'API' config")
 	}
 
+	if len(c.LeaseGracePeriod) > 0 {
+		period, err := time.ParseDuration(c.LeaseGracePeriod)
+		if err != nil {
+			return bosherr.WrapError(err, "Parsing 'LeaseGracePeriod'")
+		}
+
+		// Healthy long polls must not let lease expire
+		if period <= tasks.PollTimeout {
+			return bosherr.Errorf("Expected 'LeaseGracePeriod' to be longer than %s", tasks.PollTimeout)
+		}
+	}
+
 	return nil
 }
 
+func (c Config) LeaseGracePeriodDuration() time.Duration {
+	if len(c.LeaseGracePeriod) == 0 {
+		return DefaultLeaseGracePeriod
+	}
+
+	// Already validated
+	period, _ := time.ParseDuration(c.LeaseGracePeriod)
+
+	return period
+}
+
+func (c Config) JournalPathOrDefault() string {
+	if len(c.JournalPath) == 0 {
+		return DefaultJournalPath
+	}
+
+	return c.JournalPath
+}
+
 func (c APIConfig) CACertPool() (*x509.CertPool, error) {
 	if len(c.CACert) == 0 {
 		return nil, nil
@@ -12,12 +12,13 @@ import (
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
 
+	"github.com/cppforlife/turbulence/tasks"
 	"github.com/cppforlife/turbulence/tasks/monit"
+	"github.com/cppforlife/turbulence/tasks/netctl"
 )
 
 type Factory struct {
-	agentID string
-	config  APIConfig
+	config Config
 
 	fs        boshsys.FileSystem
 	cmdRunner boshsys.CmdRunner
@@ -27,15 +28,13 @@ type Factory struct {
 }
 
 func NewFactory(
-	agentID string,
-	config APIConfig,
+	config Config,
 	fs boshsys.FileSystem,
 	cmdRunner boshsys.CmdRunner,
 	logger boshlog.Logger,
 ) Factory {
 	return Factory{
-		agentID: agentID,
-		config:  config,
+		config: config,
 
 		fs:        fs,
 		cmdRunner: cmdRunner,
@@ -58,7 +57,27 @@ func (f Factory) New() (Agent, error) {
 
 	monitProvider := monit.NewClientProvider(f.fs, f.logger)
 
-	return newAgent(f.agentID, agentConfig, client, monitProvider, f.cmdRunner, f.logger), nil
+	capabilities := tasks.ProbeCapabilities(f.cmdRunner, f.fs)
+
+	f.logger.Info(f.logTag, "Found capabilities: %v", capabilities)
+
+	journal, err := newJournal(f.config.JournalPathOrDefault(), f.fs, f.logger)
+	if err != nil {
+		return Agent{}, err
+	}
+
+	return newAgent(
+		f.config.AgentID,
+		agentConfig,
+		client,
+		monitProvider,
+		f.cmdRunner,
+		netctl.NewController(),
+		capabilities,
+		f.config.LeaseGracePeriodDuration(),
+		journal,
+		f.logger,
+	), nil
 }
 
 func (f Factory) agentConfig() (AgentConfig, error) {
@@ -73,8 +92,8 @@ func (f Factory) agentConfig() (AgentConfig, error) {
 	}
 
 	agentConfig := AgentConfig{
-		APIHost: f.config.Host,
-		APIPort: f.config.Port,
+		APIHost: f.config.API.Host,
+		APIPort: f.config.API.Port,
 
 		BOSHMbusHost: mbusHost,
 		BOSHMbusPort: mbusPort,
@@ -84,7 +103,7 @@ func (f Factory) agentConfig() (AgentConfig, error) {
 }
 
 func (f Factory) httpClient() (Client, error) {
-	certPool, err := f.config.CACertPool()
+	certPool, err := f.config.API.CACertPool()
 	if err != nil {
 		return Client{}, err
 	}
@@ -105,11 +124,18 @@ func (f Factory) httpClient() (Client, error) {
 
 	endpoint := url.URL{
 		Scheme: "https",
-		Host:   fmt.Sprintf("%s:%d", f.config.Host, f.config.Port),
-		User:   url.UserPassword(f.config.Username, f.config.Password),
+		Host:   fmt.Sprintf("%s:%d", f.config.API.Host, f.config.API.Port),
+		User:   url.UserPassword(f.config.API.Username, f.config.API.Password),
+	}
+
+	// Allow long polls to be held by the API server while
+	// making sure that requests over dead connections end
+	rawClient := &http.Client{
+		Transport: httpTransport,
+		Timeout:   tasks.PollTimeout + 30*time.Second,
 	}
 
-	httpClient := boshhttp.NewHTTPClient(&http.Client{Transport: httpTransport}, f.logger)
+	httpClient := boshhttp.NewHTTPClient(rawClient, f.logger)
 
 	return NewClient(endpoint.String(), httpClient, f.logger), nil
 }
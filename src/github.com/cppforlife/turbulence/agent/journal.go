@@ -0,0 +1,155 @@
+package main
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"sync"
+	"time"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+
+	"github.com/cppforlife/turbulence/tasks"
+)
+
+// journal is kept on disk so that changes applied by tasks
+// can be undone after agent crashes or is restarted by monit
+type journal struct {
+	path string
+	fs   boshsys.FileSystem
+
+	entries     []journalEntry
+	entriesLock sync.Mutex
+
+	logTag string
+	logger boshlog.Logger
+}
+
+type journalEntry struct {
+	TaskID      string
+	Description string
+	UndoCmd     []string
+	RecordedAt  time.Time
+}
+
+func newJournal(path string, fs boshsys.FileSystem, logger boshlog.Logger) (*journal, error) {
+	j := &journal{
+		path: path,
+		fs:   fs,
+
+		logTag: "agent.journal",
+		logger: logger,
+	}
+
+	err := fs.MkdirAll(filepath.Dir(path), 0700)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Creating journal directory")
+	}
+
+	if fs.FileExists(path) {
+		bytes, err := fs.ReadFile(path)
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Reading journal '%s'", path)
+		}
+
+		err = json.Unmarshal(bytes, &j.entries)
+		if err != nil {
+			// Keep going since corrupted journal must not prevent agent from starting
+			logger.Error(j.logTag, "Ignoring unreadable journal '%s': %s", path, err.Error())
+		}
+	}
+
+	return j, nil
+}
+
+// ForTask returns journal that records changes applied by given task
+func (j *journal) ForTask(taskID string) tasks.Journal {
+	return taskJournal{journal: j, taskID: taskID}
+}
+
+// Leftovers returns changes recorded by tasks that did not finish
+// in order in which they were recorded
+func (j *journal) Leftovers() []journalEntry {
+	j.entriesLock.Lock()
+	defer j.entriesLock.Unlock()
+
+	return append([]journalEntry{}, j.entries...)
+}
+
+func (j *journal) record(entry journalEntry) {
+	j.entriesLock.Lock()
+	defer j.entriesLock.Unlock()
+
+	j.entries = append(j.entries, entry)
+	j.save()
+}
+
+// Forget removes changes of the task once task has undone them
+func (j *journal) Forget(taskID string) {
+	j.entriesLock.Lock()
+	defer j.entriesLock.Unlock()
+
+	var entries []journalEntry
+
+	for _, entry := range j.entries {
+		if entry.TaskID != taskID {
+			entries = append(entries, entry)
+		}
+	}
+
+	if len(entries) != len(j.entries) {
+		j.entries = entries
+		j.save()
+	}
+}
+
+func (j *journal) ForgetAll() {
+	j.entriesLock.Lock()
+	defer j.entriesLock.Unlock()
+
+	j.entries = nil
+	j.save()
+}
+
+// save errors are only logged since tasks should proceed regardless
+func (j *journal) save() {
+	entries := j.entries
+	if entries == nil {
+		entries = []journalEntry{}
+	}
+
+	bytes, err := json.Marshal(entries)
+	if err != nil {
+		j.logger.Error(j.logTag, "Failed marshalling journal: %s", err.Error())
+		return
+	}
+
+	// Write atomically so that journal is not corrupted by a crash
+	tmpPath := j.path + ".tmp"
+
+	err = j.fs.WriteFile(tmpPath, bytes)
+	if err != nil {
+		j.logger.Error(j.logTag, "Failed writing journal: %s", err.Error())
+		return
+	}
+
+	err = j.fs.Rename(tmpPath, j.path)
+	if err != nil {
+		j.logger.Error(j.logTag, "Failed renaming journal: %s", err.Error())
+	}
+}
+
+type taskJournal struct {
+	journal *journal
+	taskID  string
+}
+
+func (j taskJournal) Record(description string, undoCmd ...string) {
+	j.journal.record(journalEntry{
+		TaskID:      j.taskID,
+		Description: description,
+		UndoCmd:     undoCmd,
+		RecordedAt:  time.Now().UTC(),
+	})
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("journal", func() {
+	var (
+		fs     *fakesys.FakeFileSystem
+		logger boshlog.Logger
+	)
+
+	BeforeEach(func() {
+		fs = fakesys.NewFakeFileSystem()
+		logger = boshlog.NewLogger(boshlog.LevelNone)
+	})
+
+	savedEntries := func() []journalEntry {
+		bytes, err := fs.ReadFile("/var/vcap/data/turbulence/journal.json")
+		Expect(err).ToNot(HaveOccurred())
+
+		var entries []journalEntry
+
+		err = json.Unmarshal(bytes, &entries)
+		Expect(err).ToNot(HaveOccurred())
+
+		return entries
+	}
+
+	It("saves recorded changes until their tasks forget them", func() {
+		j, err := newJournal("/var/vcap/data/turbulence/journal.json", fs, logger)
+		Expect(err).ToNot(HaveOccurred())
+
+		j.ForTask("task-1").Record("change 1", "undo", "1")
+		j.ForTask("task-2").Record("change 2", "undo", "2")
+		j.ForTask("task-1").Record("change 3", "undo", "3")
+
+		entries := savedEntries()
+		Expect(entries).To(HaveLen(3))
+		Expect(entries[0].TaskID).To(Equal("task-1"))
+		Expect(entries[0].Description).To(Equal("change 1"))
+		Expect(entries[0].UndoCmd).To(Equal([]string{"undo", "1"}))
+		Expect(entries[0].RecordedAt.IsZero()).To(BeFalse())
+
+		j.Forget("task-1")
+
+		entries = savedEntries()
+		Expect(entries).To(HaveLen(1))
+		Expect(entries[0].Description).To(Equal("change 2"))
+
+		j.ForgetAll()
+
+		Expect(savedEntries()).To(BeEmpty())
+		Expect(j.Leftovers()).To(BeEmpty())
+	})
+
+	It("replaces saved journal via rename so that it's never partially written", func() {
+		j, err := newJournal("/var/vcap/data/turbulence/journal.json", fs, logger)
+		Expect(err).ToNot(HaveOccurred())
+
+		j.ForTask("task-1").Record("change 1", "undo", "1")
+
+		Expect(fs.RenameOldPaths).To(Equal([]string{"/var/vcap/data/turbulence/journal.json.tmp"}))
+		Expect(fs.RenameNewPaths).To(Equal([]string{"/var/vcap/data/turbulence/journal.json"}))
+
+		fs.RenameError = errors.New("fake-err")
+
+		j.ForTask("task-2").Record("change 2", "undo", "2")
+
+		Expect(savedEntries()).To(HaveLen(1))
+	})
+
+	It("loads changes left behind by previous agent", func() {
+		j, err := newJournal("/var/vcap/data/turbulence/journal.json", fs, logger)
+		Expect(err).ToNot(HaveOccurred())
+
+		j.ForTask("task-1").Record("change 1", "undo", "1")
+		j.ForTask("task-2").Record("change 2", "undo", "2")
+
+		j, err = newJournal("/var/vcap/data/turbulence/journal.json", fs, logger)
+		Expect(err).ToNot(HaveOccurred())
+
+		leftovers := j.Leftovers()
+		Expect(leftovers).To(HaveLen(2))
+		Expect(leftovers[0].Description).To(Equal("change 1"))
+		Expect(leftovers[1].Description).To(Equal("change 2"))
+	})
+
+	It("starts with empty journal when saved journal is corrupted", func() {
+		err := fs.WriteFileString("/var/vcap/data/turbulence/journal.json", "[{")
+		Expect(err).ToNot(HaveOccurred())
+
+		j, err := newJournal("/var/vcap/data/turbulence/journal.json", fs, logger)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(j.Leftovers()).To(BeEmpty())
+
+		j.ForTask("task-1").Record("change 1", "undo", "1")
+
+		Expect(savedEntries()).To(HaveLen(1))
+	})
+
+	It("returns error when saved journal cannot be read", func() {
+		err := fs.WriteFileString("/var/vcap/data/turbulence/journal.json", "[]")
+		Expect(err).ToNot(HaveOccurred())
+
+		fs.ReadFileError = errors.New("fake-err")
+
+		_, err = newJournal("/var/vcap/data/turbulence/journal.json", fs, logger)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("fake-err"))
+	})
+})
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// lease is renewed whenever agent reaches the API server; active tasks
+// are reverted once it's not renewed within grace period since agent
+// would not otherwise learn that it should stop them.
+type lease struct {
+	gracePeriod time.Duration
+
+	renewedAt     time.Time
+	renewedAtLock sync.Mutex
+}
+
+func newLease(gracePeriod time.Duration) *lease {
+	return &lease{gracePeriod: gracePeriod, renewedAt: time.Now()}
+}
+
+func (l *lease) Renew() {
+	l.renewedAtLock.Lock()
+	l.renewedAt = time.Now()
+	l.renewedAtLock.Unlock()
+}
+
+func (l *lease) Expired(now time.Time) bool {
+	l.renewedAtLock.Lock()
+	defer l.renewedAtLock.Unlock()
+
+	return now.Sub(l.renewedAt) > l.gracePeriod
+}
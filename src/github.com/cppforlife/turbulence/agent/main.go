@@ -11,6 +11,9 @@ import (
 	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
 )
 
+// Overridden at build time with -ldflags "-X main.agentVersion=..."
+var agentVersion = "dev"
+
 var (
 	debugOpt      = flag.Bool("debug", false, "Output debug logs")
 	configPathOpt = flag.String("configPath", "", "Path to configuration file")
@@ -28,11 +31,19 @@ func main() {
 	config, err := NewConfigFromPath(*configPathOpt, fs)
 	ensureNoErr(logger, "Loading config", err)
 
-	factory := NewFactory(config.AgentID, config.API, fs, cmdRunner, logger)
+	factory := NewFactory(config, fs, cmdRunner, logger)
 
 	agent, err := factory.New()
 	ensureNoErr(logger, "Building agent", err)
 
+	// Undo changes left behind by tasks that were running when agent stopped
+	agent.Recover()
+
+	agent.Register()
+
+	go agent.ContiniouslySendHeartbeats()
+	go agent.ContiniouslyEnforceLease()
+
 	err = agent.ContiniouslyExecuteTasks()
 	ensureNoErr(logger, "Executing tasks", err)
 }
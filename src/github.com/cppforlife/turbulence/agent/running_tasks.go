@@ -0,0 +1,127 @@
+package main
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/cppforlife/turbulence/tasks"
+)
+
+// runningTasks is shared between copies of the agent
+type runningTasks struct {
+	tasks     map[string]*runningTask
+	tasksLock sync.Mutex
+}
+
+type runningTask struct {
+	stopCh   chan struct{}
+	stopping bool
+
+	recorder *tasks.ResultRecorder
+
+	// Set when task was stopped since agent's lease expired
+	leaseExpired bool
+}
+
+func newRunningTasks() *runningTasks {
+	return &runningTasks{tasks: map[string]*runningTask{}}
+}
+
+// Add returns channel that is closed when task is asked to stop
+func (t *runningTasks) Add(id string, recorder *tasks.ResultRecorder) chan struct{} {
+	t.tasksLock.Lock()
+	defer t.tasksLock.Unlock()
+
+	task := &runningTask{stopCh: make(chan struct{}), recorder: recorder}
+	t.tasks[id] = task
+
+	return task.stopCh
+}
+
+// Remove returns true if task was stopped because lease expired
+func (t *runningTasks) Remove(id string) bool {
+	t.tasksLock.Lock()
+	defer t.tasksLock.Unlock()
+
+	task, found := t.tasks[id]
+	if !found {
+		return false
+	}
+
+	delete(t.tasks, id)
+
+	return task.leaseExpired
+}
+
+// Stop asks task to stop; it's a noop if task already ended or was asked to stop
+func (t *runningTasks) Stop(id string) {
+	t.tasksLock.Lock()
+	defer t.tasksLock.Unlock()
+
+	if task, found := t.tasks[id]; found && !task.stopping {
+		task.stopping = true
+		close(task.stopCh)
+	}
+}
+
+// ExpireLeases asks all tasks that were not yet asked to stop
+// to stop and returns their IDs
+func (t *runningTasks) ExpireLeases() []string {
+	t.tasksLock.Lock()
+	defer t.tasksLock.Unlock()
+
+	ids := []string{}
+
+	for id, task := range t.tasks {
+		if !task.stopping {
+			task.stopping = true
+			task.leaseExpired = true
+			close(task.stopCh)
+			ids = append(ids, id)
+		}
+	}
+
+	sort.Strings(ids)
+
+	return ids
+}
+
+// Progress returns progress of all running tasks keyed by task ID
+func (t *runningTasks) Progress() map[string][]tasks.Progress {
+	t.tasksLock.Lock()
+	defer t.tasksLock.Unlock()
+
+	progress := map[string][]tasks.Progress{}
+
+	for id, task := range t.tasks {
+		progress[id] = task.recorder.Progress()
+	}
+
+	return progress
+}
+
+func (t *runningTasks) IDs() []string {
+	return t.ids(true)
+}
+
+// UnstoppedIDs returns tasks that were not yet asked to stop
+func (t *runningTasks) UnstoppedIDs() []string {
+	return t.ids(false)
+}
+
+func (t *runningTasks) ids(includeStopping bool) []string {
+	t.tasksLock.Lock()
+	defer t.tasksLock.Unlock()
+
+	ids := []string{}
+
+	for id, task := range t.tasks {
+		if includeStopping || !task.stopping {
+			ids = append(ids, id)
+		}
+	}
+
+	sort.Strings(ids)
+
+	return ids
+}
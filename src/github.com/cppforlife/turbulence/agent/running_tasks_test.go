@@ -0,0 +1,58 @@
+package main
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cppforlife/turbulence/tasks"
+)
+
+var _ = Describe("runningTasks", func() {
+	Describe("ExpireLeases", func() {
+		It("stops tasks that were not asked to stop yet and marks them as expired", func() {
+			running := newRunningTasks()
+
+			stopCh1 := running.Add("task-1", tasks.NewResultRecorder(nil))
+			stopCh2 := running.Add("task-2", tasks.NewResultRecorder(nil))
+			stopCh3 := running.Add("task-3", tasks.NewResultRecorder(nil))
+
+			running.Stop("task-2")
+			Expect(stopCh2).To(BeClosed())
+
+			Expect(running.ExpireLeases()).To(Equal([]string{"task-1", "task-3"}))
+			Expect(stopCh1).To(BeClosed())
+			Expect(stopCh3).To(BeClosed())
+
+			Expect(running.UnstoppedIDs()).To(BeEmpty())
+			Expect(running.IDs()).To(Equal([]string{"task-1", "task-2", "task-3"}))
+
+			Expect(running.Remove("task-1")).To(BeTrue())
+			Expect(running.Remove("task-2")).To(BeFalse())
+		})
+
+		It("does not stop tasks again", func() {
+			running := newRunningTasks()
+			running.Add("task-1", tasks.NewResultRecorder(nil))
+
+			Expect(running.ExpireLeases()).To(Equal([]string{"task-1"}))
+			Expect(running.ExpireLeases()).To(BeEmpty())
+
+			running.Stop("task-1") // does not panic on closed channel
+		})
+	})
+})
+
+var _ = Describe("lease", func() {
+	It("expires once it's not renewed within grace period", func() {
+		l := newLease(time.Minute)
+
+		Expect(l.Expired(time.Now())).To(BeFalse())
+		Expect(l.Expired(time.Now().Add(2 * time.Minute))).To(BeTrue())
+
+		l.Renew()
+
+		Expect(l.Expired(time.Now().Add(30 * time.Second))).To(BeFalse())
+	})
+})
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"sync"
+
+	"github.com/cppforlife/turbulence/tasks"
+)
+
+// taskResults keeps results of finished tasks until
+// they are delivered to the API server with the next poll
+type taskResults struct {
+	results     map[string]tasks.ResultRequest
+	resultsLock sync.Mutex
+}
+
+func newTaskResults() *taskResults {
+	return &taskResults{results: map[string]tasks.ResultRequest{}}
+}
+
+func (r *taskResults) Add(taskID string, result tasks.Result, err error) {
+	req := tasks.ResultRequest{Result: &result}
+
+	if err != nil {
+		req.Error = err.Error()
+	}
+
+	r.resultsLock.Lock()
+	r.results[taskID] = req
+	r.resultsLock.Unlock()
+}
+
+// Take removes and returns all results
+func (r *taskResults) Take() map[string]tasks.ResultRequest {
+	r.resultsLock.Lock()
+	defer r.resultsLock.Unlock()
+
+	results := r.results
+	r.results = map[string]tasks.ResultRequest{}
+
+	return results
+}
+
+// Return puts back results that failed to be delivered
+func (r *taskResults) Return(results map[string]tasks.ResultRequest) {
+	r.resultsLock.Lock()
+	defer r.resultsLock.Unlock()
+
+	for taskID, req := range results {
+		r.results[taskID] = req
+	}
+}
@@ -0,0 +1,206 @@
+package blackout
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+const (
+	clockLayout = "15:04"
+	dateLayout  = "2006-01-02T15:04"
+)
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+type ActiveError struct {
+	Window Window
+}
+
+func (e ActiveError) Error() string {
+	return fmt.Sprintf("Incidents are not allowed during blackout window %s", e.Window.Description())
+}
+
+// Calendar is safe to use concurrently since it's never modified
+type Calendar struct {
+	windows []Window
+}
+
+type Window struct {
+	config WindowConfig
+
+	loc *time.Location
+
+	days       map[time.Weekday]struct{}
+	start, end time.Duration // since midnight
+
+	from, to time.Time
+}
+
+func NewCalendar(config Config) (Calendar, error) {
+	var cal Calendar
+
+	for i, winConfig := range config.Windows {
+		win, err := newWindow(winConfig)
+		if err != nil {
+			return Calendar{}, bosherr.WrapErrorf(err, "Validating blackout window %d", i+1)
+		}
+
+		cal.windows = append(cal.windows, win)
+	}
+
+	return cal, nil
+}
+
+// Active returns first window that includes given time
+func (c Calendar) Active(t time.Time) (Window, bool) {
+	for _, win := range c.windows {
+		if win.Includes(t) {
+			return win, true
+		}
+	}
+
+	return Window{}, false
+}
+
+// Check returns ActiveError if given time falls inside a blackout window
+func (c Calendar) Check(t time.Time) error {
+	if win, found := c.Active(t); found {
+		return ActiveError{Window: win}
+	}
+	return nil
+}
+
+func newWindow(config WindowConfig) (Window, error) {
+	err := config.validate()
+	if err != nil {
+		return Window{}, err
+	}
+
+	win := Window{config: config, days: map[time.Weekday]struct{}{}}
+
+	win.loc, err = time.LoadLocation(config.TimeZone)
+	if err != nil {
+		return Window{}, bosherr.WrapErrorf(err, "Loading time zone '%s'", config.TimeZone)
+	}
+
+	if config.isAbsolute() {
+		win.from, err = time.ParseInLocation(dateLayout, config.From, win.loc)
+		if err != nil {
+			return Window{}, bosherr.WrapError(err, "Parsing 'From'")
+		}
+
+		win.to, err = time.ParseInLocation(dateLayout, config.To, win.loc)
+		if err != nil {
+			return Window{}, bosherr.WrapError(err, "Parsing 'To'")
+		}
+
+		if !win.to.After(win.from) {
+			return Window{}, bosherr.Error("Expected 'To' to be after 'From'")
+		}
+
+		return win, nil
+	}
+
+	for _, day := range config.Days {
+		weekday, found := weekdays[strings.ToLower(day)]
+		if !found {
+			return Window{}, bosherr.Errorf("Expected day '%s' to be one of Mon, Tue, Wed, Thu, Fri, Sat, Sun", day)
+		}
+		win.days[weekday] = struct{}{}
+	}
+
+	if len(config.Days) == 0 {
+		for _, weekday := range weekdays {
+			win.days[weekday] = struct{}{}
+		}
+	}
+
+	if len(config.Start) > 0 {
+		win.start, err = parseClock(config.Start)
+		if err != nil {
+			return Window{}, bosherr.WrapError(err, "Parsing 'Start'")
+		}
+
+		win.end, err = parseClock(config.End)
+		if err != nil {
+			return Window{}, bosherr.WrapError(err, "Parsing 'End'")
+		}
+	}
+
+	return win, nil
+}
+
+func (w Window) Name() string { return w.config.Name }
+
+func (w Window) Description() string {
+	tz := w.loc.String()
+
+	if w.config.isAbsolute() {
+		return fmt.Sprintf("'%s' (%s to %s %s)", w.config.Name, w.config.From, w.config.To, tz)
+	}
+
+	days := "every day"
+	if len(w.config.Days) > 0 {
+		days = strings.Join(w.config.Days, ", ")
+	}
+
+	hours := "all day"
+	if len(w.config.Start) > 0 {
+		hours = fmt.Sprintf("%s-%s", w.config.Start, w.config.End)
+	}
+
+	return fmt.Sprintf("'%s' (%s %s %s)", w.config.Name, days, hours, tz)
+}
+
+func (w Window) Includes(t time.Time) bool {
+	t = t.In(w.loc)
+
+	if w.config.isAbsolute() {
+		return !t.Before(w.from) && t.Before(w.to)
+	}
+
+	// Based on wall clock so that windows are not shifted on DST changes
+	sinceMidnight := time.Duration(t.Hour())*time.Hour +
+		time.Duration(t.Minute())*time.Minute + time.Duration(t.Second())*time.Second
+
+	// Whole day
+	if w.start == w.end && len(w.config.Start) == 0 {
+		return w.includesDay(t.Weekday())
+	}
+
+	if w.start < w.end {
+		return w.includesDay(t.Weekday()) && sinceMidnight >= w.start && sinceMidnight < w.end
+	}
+
+	// Window started on this day or continues from the previous day
+	if w.includesDay(t.Weekday()) && sinceMidnight >= w.start {
+		return true
+	}
+
+	return w.includesDay(t.AddDate(0, 0, -1).Weekday()) && sinceMidnight < w.end
+}
+
+func (w Window) includesDay(day time.Weekday) bool {
+	_, found := w.days[day]
+	return found
+}
+
+func parseClock(str string) (time.Duration, error) {
+	t, err := time.Parse(clockLayout, str)
+	if err != nil {
+		return 0, err
+	}
+
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
@@ -0,0 +1,136 @@
+package blackout_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cppforlife/turbulence/blackout"
+)
+
+var _ = Describe("Calendar", func() {
+	newCalendar := func(windows ...WindowConfig) Calendar {
+		cal, err := NewCalendar(Config{Windows: windows})
+		Expect(err).ToNot(HaveOccurred())
+		return cal
+	}
+
+	utc := func(str string) time.Time {
+		t, err := time.Parse(time.RFC3339, str)
+		Expect(err).ToNot(HaveOccurred())
+		return t
+	}
+
+	It("allows everything without windows", func() {
+		Expect(newCalendar().Check(time.Now())).ToNot(HaveOccurred())
+	})
+
+	Describe("weekly windows", func() {
+		It("includes time between start and end on specified days", func() {
+			cal := newCalendar(WindowConfig{Name: "lunch", Days: []string{"Mon"}, Start: "12:00", End: "13:00"})
+
+			// 2017-05-01 is Monday
+			_, active := cal.Active(utc("2017-05-01T12:30:00Z"))
+			Expect(active).To(BeTrue())
+
+			_, active = cal.Active(utc("2017-05-01T13:00:00Z"))
+			Expect(active).To(BeFalse())
+
+			_, active = cal.Active(utc("2017-05-02T12:30:00Z"))
+			Expect(active).To(BeFalse())
+		})
+
+		It("continues into the next day when end is not after start", func() {
+			cal := newCalendar(WindowConfig{Name: "night", Days: []string{"Fri"}, Start: "18:00", End: "09:00"})
+
+			// Friday evening and Saturday morning
+			_, active := cal.Active(utc("2017-05-05T20:00:00Z"))
+			Expect(active).To(BeTrue())
+
+			_, active = cal.Active(utc("2017-05-06T08:59:00Z"))
+			Expect(active).To(BeTrue())
+
+			_, active = cal.Active(utc("2017-05-06T09:00:00Z"))
+			Expect(active).To(BeFalse())
+
+			// Friday morning belongs to Thursday night
+			_, active = cal.Active(utc("2017-05-05T08:00:00Z"))
+			Expect(active).To(BeFalse())
+		})
+
+		It("includes whole day when start and end are not specified", func() {
+			cal := newCalendar(WindowConfig{Name: "weekends", Days: []string{"Sat", "Sun"}})
+
+			_, active := cal.Active(utc("2017-05-06T00:00:00Z"))
+			Expect(active).To(BeTrue())
+
+			_, active = cal.Active(utc("2017-05-07T23:59:00Z"))
+			Expect(active).To(BeTrue())
+
+			_, active = cal.Active(utc("2017-05-08T00:00:00Z"))
+			Expect(active).To(BeFalse())
+		})
+
+		It("uses time zone", func() {
+			cal := newCalendar(WindowConfig{
+				Name:     "off-hours",
+				TimeZone: "America/Los_Angeles",
+				Start:    "18:00",
+				End:      "09:00",
+			})
+
+			// 10:00 in Los Angeles
+			_, active := cal.Active(utc("2017-05-01T17:00:00Z"))
+			Expect(active).To(BeFalse())
+
+			// 19:00 in Los Angeles
+			win, active := cal.Active(utc("2017-05-02T02:00:00Z"))
+			Expect(active).To(BeTrue())
+			Expect(win.Name()).To(Equal("off-hours"))
+		})
+	})
+
+	Describe("absolute windows", func() {
+		It("includes time between from and to", func() {
+			cal := newCalendar(WindowConfig{Name: "freeze", From: "2017-12-20T00:00", To: "2018-01-03T00:00"})
+
+			_, active := cal.Active(utc("2017-12-25T12:00:00Z"))
+			Expect(active).To(BeTrue())
+
+			_, active = cal.Active(utc("2018-01-03T00:00:00Z"))
+			Expect(active).To(BeFalse())
+		})
+
+		It("returns error naming the window", func() {
+			cal := newCalendar(WindowConfig{Name: "freeze", From: "2017-12-20T00:00", To: "2018-01-03T00:00"})
+
+			err := cal.Check(utc("2017-12-25T12:00:00Z"))
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Incidents are not allowed during blackout window 'freeze' (2017-12-20T00:00 to 2018-01-03T00:00 UTC)"))
+		})
+	})
+
+	Describe("validation", func() {
+		It("requires either weekly or absolute window", func() {
+			_, err := NewCalendar(Config{Windows: []WindowConfig{{Name: "win"}}})
+			Expect(err).To(HaveOccurred())
+
+			_, err = NewCalendar(Config{Windows: []WindowConfig{
+				{Name: "win", Days: []string{"Mon"}, From: "2017-12-20T00:00", To: "2018-01-03T00:00"},
+			}})
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("rejects unknown days, time zones and times", func() {
+			_, err := NewCalendar(Config{Windows: []WindowConfig{{Name: "win", Days: []string{"Funday"}}}})
+			Expect(err).To(HaveOccurred())
+
+			_, err = NewCalendar(Config{Windows: []WindowConfig{{Name: "win", Days: []string{"Mon"}, TimeZone: "Mars/Base"}}})
+			Expect(err).To(HaveOccurred())
+
+			_, err = NewCalendar(Config{Windows: []WindowConfig{{Name: "win", Start: "25:00", End: "09:00"}}})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,64 @@
+package blackout
+
+import (
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+// Config specifies time windows during which incidents are not allowed
+type Config struct {
+	Windows []WindowConfig
+}
+
+// WindowConfig is either a weekly recurring window (Days, Start, End)
+// or an absolute date range (From, To)
+type WindowConfig struct {
+	Name string
+
+	// IANA time zone name (e.g. America/Los_Angeles); defaults to UTC
+	TimeZone string
+
+	// Mon, Tue, Wed, Thu, Fri, Sat, Sun; every day if empty
+	Days []string
+
+	// 15:04 format; window ends on the next day if End is not after Start.
+	// Whole day if both are empty.
+	Start string
+	End   string
+
+	// 2006-01-02T15:04 format in the time zone
+	From string
+	To   string
+}
+
+func (c Config) Validate() error {
+	_, err := NewCalendar(c)
+	return err
+}
+
+func (c WindowConfig) isAbsolute() bool {
+	return len(c.From) > 0 || len(c.To) > 0
+}
+
+func (c WindowConfig) isWeekly() bool {
+	return len(c.Days) > 0 || len(c.Start) > 0 || len(c.End) > 0
+}
+
+func (c WindowConfig) validate() error {
+	if len(c.Name) == 0 {
+		return bosherr.Error("Missing 'Name'")
+	}
+
+	if c.isAbsolute() == c.isWeekly() {
+		return bosherr.Error("Expected either 'Days', 'Start' and 'End' or 'From' and 'To' to be specified")
+	}
+
+	if c.isAbsolute() && (len(c.From) == 0 || len(c.To) == 0) {
+		return bosherr.Error("Expected both 'From' and 'To' to be specified")
+	}
+
+	if (len(c.Start) == 0) != (len(c.End) == 0) {
+		return bosherr.Error("Expected both 'Start' and 'End' to be specified")
+	}
+
+	return nil
+}
@@ -21,6 +21,11 @@ func (i IncidentImpl) Wait() {
 	}
 }
 
+func (i IncidentImpl) Abort() {
+	_, err := i.client.AbortIncident(i.id)
+	panicIfErr(err, "abort incident")
+}
+
 func (i IncidentImpl) TasksOfType(example tasks.Options) []Task {
 	var ts []Task
 
@@ -74,3 +79,14 @@ func (c Client) GetIncident(id string) (incident.Response, error) {
 
 	return resp, nil
 }
+
+func (c Client) AbortIncident(id string) (incident.Response, error) {
+	var resp incident.Response
+
+	err := c.clientRequest.Post(fmt.Sprintf("/api/v1/incidents/%s/abort", id), incident.AbortRequest{}, &resp)
+	if err != nil {
+		return resp, bosherr.WrapErrorf(err, "Aborting incident")
+	}
+
+	return resp, nil
+}
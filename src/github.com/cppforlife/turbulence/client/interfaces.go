@@ -15,6 +15,7 @@ type Turbulence interface {
 
 type Incident interface {
 	Wait() // todo add timeout?
+	Abort()
 
 	TasksOfType(tasks.Options) []Task
 	HasTaskErrors() bool
@@ -34,6 +35,10 @@ type Task interface {
 	Instance() Instance
 	Error() string
 
+	// Result returns nil if task was not executed by an agent
+	// or agent did not report details of what it did
+	Result() *TaskResult
+
 	ExecutionStartedAt() time.Time
 	ExecutionCompletedAt() *time.Time
 }
@@ -45,4 +50,20 @@ type Instance struct {
 	Group      string
 	Deployment string
 	AZ         string
+	IPs        []string
+}
+
+type TaskResult struct {
+	Actions     []string
+	ResolvedIPs map[string][]string
+	PIDs        []int
+
+	StartedAt time.Time
+
+	// Nil when changes were not applied or reverted
+	AppliedAt  *time.Time
+	RevertedAt *time.Time
+
+	Reverted    bool
+	RevertError string
 }
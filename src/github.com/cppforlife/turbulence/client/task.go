@@ -29,6 +29,7 @@ func (t TaskImpl) Instance() Instance {
 		Group:      resp.Instance.Group,
 		Deployment: resp.Instance.Deployment,
 		AZ:         resp.Instance.AZ,
+		IPs:        resp.Instance.IPs,
 	}
 }
 
@@ -36,6 +37,29 @@ func (t TaskImpl) Error() string {
 	return t.fetch().Error
 }
 
+func (t TaskImpl) Result() *TaskResult {
+	resp := t.fetch().Result
+	if resp == nil {
+		return nil
+	}
+
+	startedAt, err := time.Parse(time.RFC3339, resp.StartedAt)
+	panicIfErr(err, "parse task's start time")
+
+	return &TaskResult{
+		Actions:     resp.Actions,
+		ResolvedIPs: resp.ResolvedIPs,
+		PIDs:        resp.PIDs,
+
+		StartedAt:  startedAt,
+		AppliedAt:  parseOptionalTime(resp.AppliedAt, "parse task's apply time"),
+		RevertedAt: parseOptionalTime(resp.RevertedAt, "parse task's revert time"),
+
+		Reverted:    resp.Reverted,
+		RevertError: resp.RevertError,
+	}
+}
+
 func (t TaskImpl) ExecutionStartedAt() time.Time {
 	t1, err := time.Parse(time.RFC3339, t.fetch().ExecutionStartedAt)
 	panicIfErr(err, "parse incident's execution start time")
@@ -56,6 +80,17 @@ func (t TaskImpl) ExecutionCompletedAt() *time.Time {
 	return &t1
 }
 
+func parseOptionalTime(str, desc string) *time.Time {
+	if len(str) == 0 {
+		return nil
+	}
+
+	t1, err := time.Parse(time.RFC3339, str)
+	panicIfErr(err, desc)
+
+	return &t1
+}
+
 func (t TaskImpl) fetch() reporter.EventResponse {
 	incidentResp, err := t.client.GetIncident(t.incidentID)
 	panicIfErr(err, "fetch incident response")
@@ -3,7 +3,9 @@ package client
 import (
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 
+	"github.com/cppforlife/turbulence/fleet"
 	"github.com/cppforlife/turbulence/incident"
+	"github.com/cppforlife/turbulence/tasks"
 )
 
 type TurbulenceImpl struct {
@@ -32,3 +34,36 @@ func (c Client) CreateIncident(req incident.Request) (incident.Response, error)
 
 	return resp, nil
 }
+
+func (c Client) PreviewIncident(req incident.Request) (incident.Preview, error) {
+	var resp incident.Preview
+
+	err := c.clientRequest.Post("/api/v1/incidents/preview", req, &resp)
+	if err != nil {
+		return resp, bosherr.WrapErrorf(err, "Previewing incident")
+	}
+
+	return resp, nil
+}
+
+func (c Client) TaskTypes() ([]tasks.TypeResponse, error) {
+	var resp []tasks.TypeResponse
+
+	err := c.clientRequest.Get("/api/v1/task_types", &resp)
+	if err != nil {
+		return resp, bosherr.WrapErrorf(err, "Listing task types")
+	}
+
+	return resp, nil
+}
+
+func (c Client) Agents() ([]fleet.Response, error) {
+	var resp []fleet.Response
+
+	err := c.clientRequest.Get("/api/v1/agents", &resp)
+	if err != nil {
+		return resp, bosherr.WrapErrorf(err, "Listing agents")
+	}
+
+	return resp, nil
+}
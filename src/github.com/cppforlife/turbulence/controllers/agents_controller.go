@@ -0,0 +1,112 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	mart "github.com/go-martini/martini"
+	martrend "github.com/martini-contrib/render"
+
+	"github.com/cppforlife/turbulence/fleet"
+)
+
+type AgentsController struct {
+	repo fleet.Repo
+
+	indexTmpl string
+	errorTmpl string
+
+	logTag string
+	logger boshlog.Logger
+}
+
+func NewAgentsController(repo fleet.Repo, logger boshlog.Logger) AgentsController {
+	return AgentsController{
+		repo: repo,
+
+		indexTmpl: "agents/index",
+		errorTmpl: "error",
+
+		logTag: "AgentsController",
+		logger: logger,
+	}
+}
+
+type AgentsPage struct {
+	Agents []fleet.Response
+}
+
+func (c AgentsController) Index(r martrend.Render) {
+	items, err := c.repo.Inventory()
+	if err != nil {
+		r.HTML(500, c.errorTmpl, err)
+		return
+	}
+
+	r.HTML(200, c.indexTmpl, AgentsPage{fleet.NewResponses(items)})
+}
+
+func (c AgentsController) APIIndex(r martrend.Render) {
+	items, err := c.repo.Inventory()
+	if err != nil {
+		r.JSON(500, map[string]string{"error": err.Error()})
+		return
+	}
+
+	r.JSON(200, fleet.NewResponses(items))
+}
+
+func (c AgentsController) APIRegister(req *http.Request, r martrend.Render, params mart.Params) {
+	var hbReq fleet.HeartbeatRequest
+
+	err := json.NewDecoder(req.Body).Decode(&hbReq)
+	if err != nil {
+		r.JSON(400, map[string]string{"error": err.Error()})
+		return
+	}
+
+	err = c.repo.Register(params["id"], hbReq)
+	if err != nil {
+		r.JSON(500, map[string]string{"error": err.Error()})
+		return
+	}
+
+	r.JSON(200, map[string]string{})
+}
+
+func (c AgentsController) APIHeartbeat(req *http.Request, r martrend.Render, params mart.Params) {
+	var hbReq fleet.HeartbeatRequest
+
+	err := json.NewDecoder(req.Body).Decode(&hbReq)
+	if err != nil {
+		r.JSON(400, map[string]string{"error": err.Error()})
+		return
+	}
+
+	err = c.repo.Heartbeat(params["id"], hbReq)
+	if err != nil {
+		r.JSON(500, map[string]string{"error": err.Error()})
+		return
+	}
+
+	r.JSON(200, map[string]string{})
+}
+
+func (c AgentsController) APIRecovery(req *http.Request, r martrend.Render, params mart.Params) {
+	var recReq fleet.RecoveryRequest
+
+	err := json.NewDecoder(req.Body).Decode(&recReq)
+	if err != nil {
+		r.JSON(400, map[string]string{"error": err.Error()})
+		return
+	}
+
+	err = c.repo.RecordRecovery(params["id"], recReq)
+	if err != nil {
+		r.JSON(500, map[string]string{"error": err.Error()})
+		return
+	}
+
+	r.JSON(200, map[string]string{})
+}
@@ -0,0 +1,113 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	martauth "github.com/martini-contrib/auth"
+	martrend "github.com/martini-contrib/render"
+
+	"github.com/cppforlife/turbulence/emergency"
+)
+
+type EmergencyStopController struct {
+	emergencySwitch emergency.Switch
+
+	errorTmpl string
+
+	logTag string
+	logger boshlog.Logger
+}
+
+func NewEmergencyStopController(
+	emergencySwitch emergency.Switch,
+	logger boshlog.Logger,
+) EmergencyStopController {
+	return EmergencyStopController{
+		emergencySwitch: emergencySwitch,
+
+		errorTmpl: "error",
+
+		logTag: "EmergencyStopController",
+		logger: logger,
+	}
+}
+
+func (c EmergencyStopController) Activate(req *http.Request, r martrend.Render, user martauth.User) {
+	_, err := c.emergencySwitch.Activate(c.formRequest(req, user))
+	if err != nil {
+		r.HTML(500, c.errorTmpl, err)
+		return
+	}
+
+	r.Redirect("/")
+}
+
+func (c EmergencyStopController) Clear(req *http.Request, r martrend.Render, user martauth.User) {
+	_, err := c.emergencySwitch.Clear(c.formRequest(req, user))
+	if err != nil {
+		r.HTML(500, c.errorTmpl, err)
+		return
+	}
+
+	r.Redirect("/")
+}
+
+func (c EmergencyStopController) APIRead(r martrend.Render) {
+	r.JSON(200, emergency.NewResponse(c.emergencySwitch.State()))
+}
+
+func (c EmergencyStopController) APIActivate(req *http.Request, r martrend.Render, user martauth.User) {
+	stopReq, err := c.apiRequest(req, user)
+	if err != nil {
+		r.JSON(400, map[string]string{"error": err.Error()})
+		return
+	}
+
+	state, err := c.emergencySwitch.Activate(stopReq)
+	if err != nil {
+		r.JSON(500, map[string]string{"error": err.Error()})
+		return
+	}
+
+	r.JSON(200, emergency.NewResponse(state))
+}
+
+func (c EmergencyStopController) APIClear(req *http.Request, r martrend.Render, user martauth.User) {
+	stopReq, err := c.apiRequest(req, user)
+	if err != nil {
+		r.JSON(400, map[string]string{"error": err.Error()})
+		return
+	}
+
+	state, err := c.emergencySwitch.Clear(stopReq)
+	if err != nil {
+		r.JSON(500, map[string]string{"error": err.Error()})
+		return
+	}
+
+	r.JSON(200, emergency.NewResponse(state))
+}
+
+func (c EmergencyStopController) formRequest(req *http.Request, user martauth.User) emergency.Request {
+	return emergency.Request{By: string(user), Reason: req.FormValue("reason")}
+}
+
+func (c EmergencyStopController) apiRequest(req *http.Request, user martauth.User) (emergency.Request, error) {
+	var stopReq emergency.Request
+
+	// Request body is optional
+	if req.ContentLength != 0 {
+		err := json.NewDecoder(req.Body).Decode(&stopReq)
+		if err != nil {
+			return stopReq, err
+		}
+	}
+
+	if len(stopReq.By) == 0 {
+		stopReq.By = string(user)
+	}
+
+	return stopReq, nil
+}
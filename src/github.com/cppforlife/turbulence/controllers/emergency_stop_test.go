@@ -0,0 +1,116 @@
+package controllers_test
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	martrend "github.com/martini-contrib/render"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cppforlife/turbulence/controllers"
+	"github.com/cppforlife/turbulence/emergency"
+	"github.com/cppforlife/turbulence/incident"
+	"github.com/cppforlife/turbulence/scenario"
+)
+
+type fakeRender struct {
+	martrend.Render
+
+	status int
+	json   interface{}
+}
+
+func (r *fakeRender) JSON(status int, v interface{}) {
+	r.status = status
+	r.json = v
+}
+
+type fakeSwitch struct {
+	emergency.Switch
+
+	state emergency.State
+}
+
+func (s fakeSwitch) State() emergency.State { return s.state }
+
+type fakeIncidentsRepo struct {
+	incident.Repo
+
+	created bool
+}
+
+func (r *fakeIncidentsRepo) Create(incident.Request) (incident.Incident, error) {
+	r.created = true
+	return incident.Incident{}, errors.New("fake-err")
+}
+
+type fakeScenariosRepo struct {
+	scenario.Repo
+
+	created bool
+}
+
+func (r *fakeScenariosRepo) Create(scenario.Request) (scenario.Scenario, error) {
+	r.created = true
+	return scenario.Scenario{}, errors.New("fake-err")
+}
+
+var _ = Describe("creating while emergency stop is active", func() {
+	var (
+		render       *fakeRender
+		activeSwitch fakeSwitch
+		logger       boshlog.Logger
+	)
+
+	BeforeEach(func() {
+		render = &fakeRender{}
+		logger = boshlog.NewLogger(boshlog.LevelNone)
+
+		activeSwitch = fakeSwitch{state: emergency.State{Active: true, ActivatedBy: "fake-user", Reason: "fake-reason"}}
+	})
+
+	newRequest := func(body string) *http.Request {
+		req, err := http.NewRequest("POST", "/api/v1/fake", strings.NewReader(body))
+		Expect(err).ToNot(HaveOccurred())
+		return req
+	}
+
+	It("rejects incidents with 409", func() {
+		repo := &fakeIncidentsRepo{}
+
+		ctrl := NewIncidentsController(repo, activeSwitch, logger)
+		ctrl.APICreate(newRequest(`{"Tasks":[{"Type":"Kill"}]}`), render)
+
+		Expect(render.status).To(Equal(409))
+		Expect(render.json).To(Equal(map[string]string{
+			"error": emergency.ActiveError{State: activeSwitch.state}.Error(),
+		}))
+		Expect(repo.created).To(BeFalse())
+	})
+
+	It("rejects scenarios with 409", func() {
+		repo := &fakeScenariosRepo{}
+
+		ctrl := NewScenariosController(repo, activeSwitch, logger)
+		ctrl.APICreate(newRequest(`{"Steps":[{"Wait":"1m"}]}`), render)
+
+		Expect(render.status).To(Equal(409))
+		Expect(render.json).To(Equal(map[string]string{
+			"error": emergency.ActiveError{State: activeSwitch.state}.Error(),
+		}))
+		Expect(repo.created).To(BeFalse())
+	})
+
+	It("creates incidents once emergency stop is cleared", func() {
+		repo := &fakeIncidentsRepo{}
+
+		ctrl := NewIncidentsController(repo, fakeSwitch{}, logger)
+		ctrl.APICreate(newRequest(`{"Tasks":[{"Type":"Kill"}]}`), render)
+
+		Expect(render.status).To(Equal(500))
+		Expect(repo.created).To(BeTrue())
+	})
+})
@@ -3,7 +3,10 @@ package controllers
 import (
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 
+	"github.com/cppforlife/turbulence/emergency"
+	"github.com/cppforlife/turbulence/fleet"
 	"github.com/cppforlife/turbulence/incident"
+	"github.com/cppforlife/turbulence/scenario"
 	"github.com/cppforlife/turbulence/scheduledinc"
 	"github.com/cppforlife/turbulence/tasks"
 )
@@ -11,26 +14,40 @@ import (
 type FactoryRepos interface {
 	IncidentsRepo() incident.Repo
 	ScheduledIncidentsRepo() scheduledinc.Repo
+	ScenariosRepo() scenario.Repo
 	TasksRepo() tasks.Repo
+	FleetRepo() fleet.Repo
+	EmergencySwitch() emergency.Switch
 }
 
 type Factory struct {
 	HomeController               HomeController
 	IncidentsController          IncidentsController
 	ScheduledIncidentsController ScheduledIncidentsController
+	ScenariosController          ScenariosController
 	TasksController              TasksController
+	TaskTypesController          TaskTypesController
+	AgentsController             AgentsController
+	EmergencyStopController      EmergencyStopController
 }
 
 func NewFactory(r FactoryRepos, logger boshlog.Logger) (Factory, error) {
 	isRepo := r.IncidentsRepo()
 	sisRepo := r.ScheduledIncidentsRepo()
+	scRepo := r.ScenariosRepo()
 	arRepo := r.TasksRepo()
+	esSwitch := r.EmergencySwitch()
+	flRepo := r.FleetRepo()
 
 	factory := Factory{
-		HomeController:               NewHomeController(isRepo, sisRepo, logger),
-		IncidentsController:          NewIncidentsController(isRepo, logger),
+		HomeController:               NewHomeController(isRepo, sisRepo, scRepo, esSwitch, logger),
+		IncidentsController:          NewIncidentsController(isRepo, esSwitch, logger),
 		ScheduledIncidentsController: NewScheduledIncidentsController(sisRepo, logger),
+		ScenariosController:          NewScenariosController(scRepo, esSwitch, logger),
 		TasksController:              NewTasksController(arRepo, logger),
+		TaskTypesController:          NewTaskTypesController(logger),
+		AgentsController:             NewAgentsController(flRepo, logger),
+		EmergencyStopController:      NewEmergencyStopController(esSwitch, logger),
 	}
 
 	return factory, nil
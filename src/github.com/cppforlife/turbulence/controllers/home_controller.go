@@ -4,13 +4,17 @@ import (
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	martrend "github.com/martini-contrib/render"
 
+	"github.com/cppforlife/turbulence/emergency"
 	"github.com/cppforlife/turbulence/incident"
+	"github.com/cppforlife/turbulence/scenario"
 	"github.com/cppforlife/turbulence/scheduledinc"
 )
 
 type HomeController struct {
 	incidentsRepo          incident.Repo
 	scheduledIncidentsRepo scheduledinc.Repo
+	scenariosRepo          scenario.Repo
+	emergencySwitch        emergency.Switch
 
 	homeTmpl  string
 	errorTmpl string
@@ -22,11 +26,15 @@ type HomeController struct {
 func NewHomeController(
 	incidentsRepo incident.Repo,
 	scheduledIncidentsRepo scheduledinc.Repo,
+	scenariosRepo scenario.Repo,
+	emergencySwitch emergency.Switch,
 	logger boshlog.Logger,
 ) HomeController {
 	return HomeController{
 		incidentsRepo:          incidentsRepo,
 		scheduledIncidentsRepo: scheduledIncidentsRepo,
+		scenariosRepo:          scenariosRepo,
+		emergencySwitch:        emergencySwitch,
 
 		homeTmpl:  "home/home",
 		errorTmpl: "error",
@@ -37,8 +45,11 @@ func NewHomeController(
 }
 
 type HomePage struct {
+	EmergencyStop emergency.Response
+
 	Incidents          incident.IncidentsResp
 	ScheduledIncidents scheduledinc.Responses
+	Scenarios          scenario.Responses
 }
 
 func (c HomeController) Home(r martrend.Render) {
@@ -54,9 +65,18 @@ func (c HomeController) Home(r martrend.Render) {
 		return
 	}
 
+	scs, err := c.scenariosRepo.ListAll()
+	if err != nil {
+		r.HTML(500, c.errorTmpl, err)
+		return
+	}
+
 	page := HomePage{
+		EmergencyStop: emergency.NewResponse(c.emergencySwitch.State()),
+
 		Incidents:          incident.NewResponses(is),
 		ScheduledIncidents: scheduledinc.NewResponses(sis),
+		Scenarios:          scenario.NewResponses(scs),
 	}
 
 	r.HTML(200, c.homeTmpl, page)
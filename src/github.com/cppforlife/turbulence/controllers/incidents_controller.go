@@ -6,13 +6,19 @@ import (
 
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	mart "github.com/go-martini/martini"
+	martauth "github.com/martini-contrib/auth"
 	martrend "github.com/martini-contrib/render"
 
+	"github.com/cppforlife/turbulence/blackout"
+	"github.com/cppforlife/turbulence/emergency"
 	"github.com/cppforlife/turbulence/incident"
+	"github.com/cppforlife/turbulence/policy"
+	"github.com/cppforlife/turbulence/tasks"
 )
 
 type IncidentsController struct {
-	incidentsRepo incident.Repo
+	incidentsRepo   incident.Repo
+	emergencySwitch emergency.Switch
 
 	indexTmpl string
 	showTmpl  string
@@ -24,10 +30,12 @@ type IncidentsController struct {
 
 func NewIncidentsController(
 	incidentsRepo incident.Repo,
+	emergencySwitch emergency.Switch,
 	logger boshlog.Logger,
 ) IncidentsController {
 	return IncidentsController{
-		incidentsRepo: incidentsRepo,
+		incidentsRepo:   incidentsRepo,
+		emergencySwitch: emergencySwitch,
 
 		indexTmpl: "incidents/index",
 		showTmpl:  "incidents/show",
@@ -67,23 +75,68 @@ func (c IncidentsController) APIIndex(req *http.Request, r martrend.Render) {
 }
 
 func (c IncidentsController) APICreate(req *http.Request, r martrend.Render) {
+	if state := c.emergencySwitch.State(); state.Active {
+		r.JSON(409, map[string]string{"error": emergency.ActiveError{State: state}.Error()})
+		return
+	}
+
 	var incidentReq incident.Request
 
 	err := json.NewDecoder(req.Body).Decode(&incidentReq)
 	if err != nil {
-		r.JSON(500, map[string]string{"error": err.Error()})
+		r.JSON(400, map[string]string{"error": err.Error()})
+		return
+	}
+
+	err = incidentReq.Validate()
+	if err != nil {
+		r.JSON(400, newErrorResponse(err))
 		return
 	}
 
 	incid, err := c.incidentsRepo.Create(incidentReq)
 	if err != nil {
-		r.JSON(500, map[string]string{"error": err.Error()})
+		code := 500
+		switch err.(type) {
+		case tasks.ValidationError:
+			code = 400
+		case policy.ViolationError:
+			code = 403
+		case blackout.ActiveError, incident.MissingCapabilitiesError:
+			code = 409
+		}
+
+		r.JSON(code, newErrorResponse(err))
 		return
 	}
 
 	r.JSON(200, incident.NewResponse(incid))
 }
 
+// APIPreview returns instances that would be affected without executing any tasks
+func (c IncidentsController) APIPreview(req *http.Request, r martrend.Render) {
+	var incidentReq incident.Request
+
+	err := json.NewDecoder(req.Body).Decode(&incidentReq)
+	if err != nil {
+		r.JSON(400, map[string]string{"error": err.Error()})
+		return
+	}
+
+	preview, err := c.incidentsRepo.Preview(incidentReq)
+	if err != nil {
+		code := 500
+		if _, ok := err.(tasks.ValidationError); ok {
+			code = 400
+		}
+
+		r.JSON(code, newErrorResponse(err))
+		return
+	}
+
+	r.JSON(200, preview)
+}
+
 func (c IncidentsController) Read(req *http.Request, r martrend.Render, params mart.Params) {
 	incid, err := c.incidentsRepo.Read(params["id"])
 	if err != nil {
@@ -113,3 +166,47 @@ func (c IncidentsController) APIRead(req *http.Request, r martrend.Render, param
 
 	r.JSON(200, incident.NewResponse(incid))
 }
+
+func (c IncidentsController) APIAbort(req *http.Request, r martrend.Render, params mart.Params, user martauth.User) {
+	var abortReq incident.AbortRequest
+
+	// Request body is optional
+	if req.ContentLength != 0 {
+		err := json.NewDecoder(req.Body).Decode(&abortReq)
+		if err != nil {
+			r.JSON(400, map[string]string{"error": err.Error()})
+			return
+		}
+	}
+
+	if len(abortReq.By) == 0 {
+		abortReq.By = string(user)
+	}
+
+	incid, err := c.incidentsRepo.Abort(params["id"], abortReq)
+	if err != nil {
+		code := 500
+		switch err.(type) {
+		case incident.IncidentNotFoundError:
+			code = 404
+		case incident.IncidentCompletedError:
+			code = 409
+		}
+
+		r.JSON(code, map[string]string{"error": err.Error()})
+		return
+	}
+
+	r.JSON(200, incident.NewResponse(incid))
+}
+
+// newErrorResponse includes per-field errors for invalid requests
+func newErrorResponse(err error) map[string]interface{} {
+	resp := map[string]interface{}{"error": err.Error()}
+
+	if valErr, ok := err.(tasks.ValidationError); ok {
+		resp["fields"] = valErr.Fields()
+	}
+
+	return resp
+}
@@ -0,0 +1,164 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	mart "github.com/go-martini/martini"
+	martauth "github.com/martini-contrib/auth"
+	martrend "github.com/martini-contrib/render"
+
+	"github.com/cppforlife/turbulence/emergency"
+	"github.com/cppforlife/turbulence/scenario"
+)
+
+type ScenariosController struct {
+	repo            scenario.Repo
+	emergencySwitch emergency.Switch
+
+	indexTmpl string
+	showTmpl  string
+	errorTmpl string
+
+	logTag string
+	logger boshlog.Logger
+}
+
+func NewScenariosController(
+	repo scenario.Repo,
+	emergencySwitch emergency.Switch,
+	logger boshlog.Logger,
+) ScenariosController {
+	return ScenariosController{
+		repo:            repo,
+		emergencySwitch: emergencySwitch,
+
+		indexTmpl: "scenarios/index",
+		showTmpl:  "scenarios/show",
+		errorTmpl: "error",
+
+		logTag: "ScenariosController",
+		logger: logger,
+	}
+}
+
+type ScenariosPage struct {
+	Scenarios []scenario.Response
+}
+
+type ScenarioPage struct {
+	Scenario scenario.Response
+}
+
+func (c ScenariosController) Index(r martrend.Render) {
+	scenarios, err := c.repo.ListAll()
+	if err != nil {
+		r.HTML(500, c.errorTmpl, err)
+		return
+	}
+
+	r.HTML(200, c.indexTmpl, ScenariosPage{scenario.NewResponses(scenarios)})
+}
+
+func (c ScenariosController) APIIndex(r martrend.Render) {
+	scenarios, err := c.repo.ListAll()
+	if err != nil {
+		r.JSON(500, map[string]string{"error": err.Error()})
+		return
+	}
+
+	r.JSON(200, scenario.NewResponses(scenarios))
+}
+
+func (c ScenariosController) APICreate(req *http.Request, r martrend.Render) {
+	if state := c.emergencySwitch.State(); state.Active {
+		r.JSON(409, map[string]string{"error": emergency.ActiveError{State: state}.Error()})
+		return
+	}
+
+	var scenarioReq scenario.Request
+
+	err := json.NewDecoder(req.Body).Decode(&scenarioReq)
+	if err != nil {
+		r.JSON(400, map[string]string{"error": err.Error()})
+		return
+	}
+
+	err = scenarioReq.Validate()
+	if err != nil {
+		r.JSON(400, map[string]string{"error": err.Error()})
+		return
+	}
+
+	sc, err := c.repo.Create(scenarioReq)
+	if err != nil {
+		r.JSON(500, map[string]string{"error": err.Error()})
+		return
+	}
+
+	r.JSON(200, scenario.NewResponse(sc))
+}
+
+func (c ScenariosController) Read(r martrend.Render, params mart.Params) {
+	sc, err := c.repo.Read(params["id"])
+	if err != nil {
+		code := 500
+		if _, ok := err.(scenario.NotFoundError); ok {
+			code = 404
+		}
+
+		r.HTML(code, c.errorTmpl, err)
+		return
+	}
+
+	r.HTML(200, c.showTmpl, ScenarioPage{scenario.NewResponse(sc)})
+}
+
+func (c ScenariosController) APIRead(r martrend.Render, params mart.Params) {
+	sc, err := c.repo.Read(params["id"])
+	if err != nil {
+		code := 500
+		if _, ok := err.(scenario.NotFoundError); ok {
+			code = 404
+		}
+
+		r.JSON(code, map[string]string{"error": err.Error()})
+		return
+	}
+
+	r.JSON(200, scenario.NewResponse(sc))
+}
+
+func (c ScenariosController) APIAbort(req *http.Request, r martrend.Render, params mart.Params, user martauth.User) {
+	var abortReq scenario.AbortRequest
+
+	// Request body is optional
+	if req.ContentLength != 0 {
+		err := json.NewDecoder(req.Body).Decode(&abortReq)
+		if err != nil {
+			r.JSON(400, map[string]string{"error": err.Error()})
+			return
+		}
+	}
+
+	if len(abortReq.By) == 0 {
+		abortReq.By = string(user)
+	}
+
+	sc, err := c.repo.Abort(params["id"], abortReq)
+	if err != nil {
+		code := 500
+		switch err.(type) {
+		case scenario.NotFoundError:
+			code = 404
+		case scenario.ScenarioCompletedError:
+			code = 409
+		}
+
+		r.JSON(code, map[string]string{"error": err.Error()})
+		return
+	}
+
+	r.JSON(200, scenario.NewResponse(sc))
+}
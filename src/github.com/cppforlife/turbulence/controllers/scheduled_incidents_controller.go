@@ -9,6 +9,7 @@ import (
 	martrend "github.com/martini-contrib/render"
 
 	"github.com/cppforlife/turbulence/scheduledinc"
+	"github.com/cppforlife/turbulence/tasks"
 )
 
 type ScheduledIncidentsController struct {
@@ -71,13 +72,24 @@ func (c ScheduledIncidentsController) APICreate(req *http.Request, r martrend.Re
 
 	err := json.NewDecoder(req.Body).Decode(&siReq)
 	if err != nil {
-		r.JSON(500, map[string]string{"error": err.Error()})
+		r.JSON(400, map[string]string{"error": err.Error()})
+		return
+	}
+
+	err = siReq.Validate()
+	if err != nil {
+		r.JSON(400, newErrorResponse(err))
 		return
 	}
 
 	si, err := c.repo.Create(siReq)
 	if err != nil {
-		r.JSON(500, map[string]string{"error": err.Error()})
+		code := 500
+		if _, ok := err.(tasks.ValidationError); ok {
+			code = 400
+		}
+
+		r.JSON(code, newErrorResponse(err))
 		return
 	}
 
@@ -0,0 +1,25 @@
+package controllers
+
+import (
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	martrend "github.com/martini-contrib/render"
+
+	"github.com/cppforlife/turbulence/tasks"
+)
+
+type TaskTypesController struct {
+	logTag string
+	logger boshlog.Logger
+}
+
+func NewTaskTypesController(logger boshlog.Logger) TaskTypesController {
+	return TaskTypesController{
+		logTag: "TaskTypesController",
+		logger: logger,
+	}
+}
+
+// APIIndex lists registered task types with their option schemas
+func (c TaskTypesController) APIIndex(r martrend.Render) {
+	r.JSON(200, tasks.NewTypeResponses(tasks.Types()))
+}
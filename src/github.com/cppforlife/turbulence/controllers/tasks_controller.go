@@ -3,6 +3,7 @@ package controllers
 import (
 	"encoding/json"
 	"net/http"
+	"time"
 
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	mart "github.com/go-martini/martini"
@@ -42,6 +43,59 @@ func (c TasksController) APIConsume(req *http.Request, r martrend.Render, params
 	r.JSON(200, tasks)
 }
 
+// APIPoll records progress of running tasks and results of finished tasks and then holds request
+// until there are new tasks for the agent or its tasks should stop.
+func (c TasksController) APIPoll(req *http.Request, r martrend.Render, params mart.Params) {
+	var pollReq tasks.PollRequest
+
+	err := json.NewDecoder(req.Body).Decode(&pollReq)
+	if err != nil {
+		r.JSON(400, map[string]string{"error": err.Error()})
+		return
+	}
+
+	for taskID, progress := range pollReq.Progress {
+		err = c.tasksRepo.UpdateProgress(taskID, progress)
+		if err != nil {
+			r.JSON(500, map[string]string{"error": err.Error()})
+			return
+		}
+	}
+
+	for taskID, resultReq := range pollReq.Results {
+		err = c.tasksRepo.Update(taskID, resultReq)
+		if err != nil {
+			r.JSON(500, map[string]string{"error": err.Error()})
+			return
+		}
+	}
+
+	var timeout time.Duration
+
+	if pollReq.Wait {
+		timeout = tasks.PollTimeout
+	}
+
+	// Stop waiting once agent disconnects
+	cancelCh := req.Context().Done()
+
+	ts, stopTaskIDs, err := c.tasksRepo.Poll(params["id"], pollReq.RunningTaskIDs, timeout, cancelCh)
+	if err != nil {
+		r.JSON(500, map[string]string{"error": err.Error()})
+		return
+	}
+
+	if ts == nil {
+		ts = []tasks.Task{}
+	}
+
+	if stopTaskIDs == nil {
+		stopTaskIDs = []string{}
+	}
+
+	r.JSON(200, tasks.PollResponse{Tasks: ts, StopTaskIDs: stopTaskIDs})
+}
+
 func (c TasksController) APIReadState(req *http.Request, r martrend.Render, params mart.Params) {
 	state, err := c.tasksRepo.FetchState(params["id"])
 	if err != nil {
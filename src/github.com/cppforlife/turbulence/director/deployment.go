@@ -0,0 +1,72 @@
+package director
+
+import (
+	"sync"
+
+	boshdir "github.com/cloudfoundry/bosh-cli/director"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+// deploymentDetails lazily loads parts of a deployment that are expensive
+// to fetch (VM infos require agents to be contacted) and are only needed
+// when instances are selected by them. Details are loaded at most once
+// and are shared between all instances of a deployment from a single listing.
+type deploymentDetails struct {
+	deployment boshdir.Deployment
+
+	infosOnce sync.Once
+	infosByID map[string]boshdir.VMInfo
+
+	manifestOnce sync.Once
+	manifest     manifest
+
+	logTag string
+	logger boshlog.Logger
+}
+
+func newDeploymentDetails(deployment boshdir.Deployment, logger boshlog.Logger) *deploymentDetails {
+	return &deploymentDetails{
+		deployment: deployment,
+		infosByID:  map[string]boshdir.VMInfo{},
+
+		logTag: "director.deploymentDetails",
+		logger: logger,
+	}
+}
+
+// VMInfo returns details reported by the agent (e.g. process state);
+// failure to load them only results in instances of this deployment
+// missing such details instead of failing whole listing
+func (d *deploymentDetails) VMInfo(id string) boshdir.VMInfo {
+	d.infosOnce.Do(func() {
+		infos, err := d.deployment.InstanceInfos()
+		if err != nil {
+			d.logger.Error(d.logTag, "Failed fetching instance infos for deployment '%s': %s", d.deployment.Name(), err)
+			return
+		}
+
+		for _, info := range infos {
+			d.infosByID[info.ID] = info
+		}
+	})
+
+	return d.infosByID[id]
+}
+
+// Manifest returns parts of the deployment manifest (e.g. network and job names)
+func (d *deploymentDetails) Manifest() manifest {
+	d.manifestOnce.Do(func() {
+		bytes, err := d.deployment.Manifest()
+		if err != nil {
+			d.logger.Error(d.logTag, "Failed fetching manifest for deployment '%s': %s", d.deployment.Name(), err)
+			return
+		}
+
+		d.manifest, err = newManifest(bytes)
+		if err != nil {
+			d.logger.Error(d.logTag, "Failed parsing manifest for deployment '%s': %s", d.deployment.Name(), err)
+		}
+	})
+
+	return d.manifest
+}
@@ -2,10 +2,16 @@ package director
 
 import (
 	boshdir "github.com/cloudfoundry/bosh-cli/director"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 )
 
 type DirectorImpl struct {
 	director boshdir.Director
+	logger   boshlog.Logger
+}
+
+func NewDirector(director boshdir.Director, logger boshlog.Logger) DirectorImpl {
+	return DirectorImpl{director: director, logger: logger}
 }
 
 func (d DirectorImpl) AllInstances() ([]Instance, error) {
@@ -22,11 +28,15 @@ func (d DirectorImpl) AllInstances() ([]Instance, error) {
 			return nil, err
 		}
 
+		// Agent reported details and manifest are only fetched if needed
+		details := newDeploymentDetails(dep, d.logger)
+
 		for _, inst := range insts {
 			instances = append(instances, InstanceImpl{
 				id:         inst.ID,
 				group:      inst.Group,
 				deployment: dep,
+				details:    details,
 				az:         inst.AZ,
 
 				cid:     inst.VMID,
@@ -0,0 +1,160 @@
+package director_test
+
+import (
+	"errors"
+	"sync"
+
+	boshdir "github.com/cloudfoundry/bosh-cli/director"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cppforlife/turbulence/director"
+)
+
+type fakeBoshDirector struct {
+	boshdir.Director
+
+	deployments []boshdir.Deployment
+}
+
+func (d fakeBoshDirector) Deployments() ([]boshdir.Deployment, error) { return d.deployments, nil }
+
+type fakeDeployment struct {
+	boshdir.Deployment
+
+	name      string
+	instances []boshdir.Instance
+
+	infos    []boshdir.VMInfo
+	infosErr error
+
+	manifest    string
+	manifestErr error
+
+	lock          sync.Mutex
+	infosCalls    int
+	manifestCalls int
+}
+
+func (d *fakeDeployment) Name() string { return d.name }
+
+func (d *fakeDeployment) Instances() ([]boshdir.Instance, error) { return d.instances, nil }
+
+func (d *fakeDeployment) InstanceInfos() ([]boshdir.VMInfo, error) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.infosCalls++
+	return d.infos, d.infosErr
+}
+
+func (d *fakeDeployment) Manifest() (string, error) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.manifestCalls++
+	return d.manifest, d.manifestErr
+}
+
+var _ = Describe("DirectorImpl", func() {
+	var (
+		dep1, dep2 *fakeDeployment
+		director   DirectorImpl
+	)
+
+	BeforeEach(func() {
+		dep1 = &fakeDeployment{
+			name: "d1",
+			instances: []boshdir.Instance{
+				{ID: "1", Group: "g1", VMID: "cid1", AgentID: "agent1"},
+				{ID: "2", Group: "g1"},
+			},
+			infos: []boshdir.VMInfo{{
+				ID:           "1",
+				AZ:           "z1",
+				State:        "started",
+				ProcessState: "running",
+				Bootstrap:    true,
+				DiskIDs:      []string{"disk1"},
+				IPs:          []string{"10.0.0.1"},
+				Processes:    []boshdir.VMInfoProcess{{Name: "proc1"}},
+			}},
+			manifest: "instance_groups: [{name: g1, networks: [{name: n1}], jobs: [{name: j1}]}]",
+		}
+
+		dep2 = &fakeDeployment{
+			name:      "d2",
+			instances: []boshdir.Instance{{ID: "3", Group: "g2", AZ: "z2", VMID: "cid3", AgentID: "agent3"}},
+		}
+
+		director = NewDirector(
+			fakeBoshDirector{deployments: []boshdir.Deployment{dep1, dep2}},
+			boshlog.NewLogger(boshlog.LevelNone),
+		)
+	})
+
+	Describe("AllInstances", func() {
+		It("does not fetch instance infos or manifests when only basic details are used", func() {
+			instances, err := director.AllInstances()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(instances).To(HaveLen(3))
+
+			inst := instances[2]
+			Expect(inst.ID()).To(Equal("3"))
+			Expect(inst.Group()).To(Equal("g2"))
+			Expect(inst.Deployment()).To(Equal("d2"))
+			Expect(inst.AZ()).To(Equal("z2"))
+			Expect(inst.AgentID()).To(Equal("agent3"))
+			Expect(inst.HasVM()).To(BeTrue())
+
+			Expect(instances[1].HasVM()).To(BeFalse())
+
+			Expect(dep1.infosCalls).To(Equal(0))
+			Expect(dep1.manifestCalls).To(Equal(0))
+			Expect(dep2.infosCalls).To(Equal(0))
+			Expect(dep2.manifestCalls).To(Equal(0))
+		})
+
+		It("fetches instance infos and manifest once per deployment when their details are used", func() {
+			instances, err := director.AllInstances()
+			Expect(err).ToNot(HaveOccurred())
+
+			inst := instances[0]
+			Expect(inst.AZ()).To(Equal("z1"))
+			Expect(inst.State()).To(Equal("started"))
+			Expect(inst.ProcessState()).To(Equal("running"))
+			Expect(inst.Bootstrap()).To(BeTrue())
+			Expect(inst.PersistentDisk()).To(BeTrue())
+			Expect(inst.IPs()).To(Equal([]string{"10.0.0.1"}))
+			Expect(inst.Networks()).To(Equal([]string{"n1"}))
+			Expect(inst.Jobs()).To(Equal([]string{"j1"}))
+			Expect(inst.Processes()).To(Equal([]string{"proc1"}))
+
+			Expect(instances[1].State()).To(BeEmpty())
+			Expect(instances[1].Jobs()).To(Equal([]string{"j1"}))
+
+			Expect(dep1.infosCalls).To(Equal(1))
+			Expect(dep1.manifestCalls).To(Equal(1))
+			Expect(dep2.infosCalls).To(Equal(0))
+			Expect(dep2.manifestCalls).To(Equal(0))
+
+			instances, err = director.AllInstances()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(instances[0].State()).To(Equal("started"))
+			Expect(dep1.infosCalls).To(Equal(2))
+		})
+
+		It("does not fail other deployments when fetching details of one deployment fails", func() {
+			dep1.infosErr = errors.New("fake-infos-err")
+			dep1.manifestErr = errors.New("fake-manifest-err")
+			dep2.infos = []boshdir.VMInfo{{ID: "3", State: "started"}}
+
+			instances, err := director.AllInstances()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(instances).To(HaveLen(3))
+
+			Expect(instances[0].State()).To(BeEmpty())
+			Expect(instances[0].Jobs()).To(BeEmpty())
+			Expect(instances[2].State()).To(Equal("started"))
+		})
+	})
+})
@@ -22,7 +22,7 @@ func (c Factory) New() (Director, error) {
 		return nil, err
 	}
 
-	return DirectorImpl{director}, nil
+	return NewDirector(director, c.logger), nil
 }
 
 func (c Factory) director() (boshdir.Director, error) {
@@ -8,19 +8,41 @@ import (
 
 type InstanceImpl struct {
 	deployment boshdir.Deployment
+	details    *deploymentDetails
 
-	deploymentName, az, group, id string
+	az, group, id string
 
 	cid, agentID string
 }
 
 func (i InstanceImpl) Deployment() string { return i.deployment.Name() }
-func (i InstanceImpl) AZ() string         { return i.az }
 func (i InstanceImpl) Group() string      { return i.group }
 func (i InstanceImpl) ID() string         { return i.id }
 func (i InstanceImpl) AgentID() string    { return i.agentID }
 func (i InstanceImpl) HasVM() bool        { return len(i.cid) > 0 }
 
+func (i InstanceImpl) AZ() string {
+	if len(i.az) > 0 {
+		return i.az
+	}
+	return i.info().AZ
+}
+
+func (i InstanceImpl) State() string        { return i.info().State }
+func (i InstanceImpl) ProcessState() string { return i.info().ProcessState }
+func (i InstanceImpl) Bootstrap() bool      { return i.info().Bootstrap }
+
+func (i InstanceImpl) PersistentDisk() bool {
+	info := i.info()
+	return len(info.DiskID) > 0 || len(info.DiskIDs) > 0
+}
+
+func (i InstanceImpl) IPs() []string      { return i.info().IPs }
+func (i InstanceImpl) Networks() []string { return i.details.Manifest().Networks(i.group) }
+
+func (i InstanceImpl) Jobs() []string      { return i.details.Manifest().Jobs(i.group) }
+func (i InstanceImpl) Processes() []string { return processNames(i.info().Processes) }
+
 func (i InstanceImpl) DeleteVM() error {
 	if !i.HasVM() {
 		return fmt.Errorf("Cannot delete VM for instance '%s' since it does not have an associated VM", i.id)
@@ -28,3 +50,15 @@ func (i InstanceImpl) DeleteVM() error {
 
 	return i.deployment.DeleteVM(i.cid)
 }
+
+func (i InstanceImpl) info() boshdir.VMInfo { return i.details.VMInfo(i.id) }
+
+func processNames(processes []boshdir.VMInfoProcess) []string {
+	var names []string
+
+	for _, proc := range processes {
+		names = append(names, proc.Name)
+	}
+
+	return names
+}
@@ -1,6 +1,8 @@
 package director
 
 type Director interface {
+	// AllInstances lists instances of all deployments; details reported by agents
+	// and taken from manifests are only fetched once instances are asked for them
 	AllInstances() ([]Instance, error)
 	SubmitEvent(EventOpts) error
 }
@@ -14,6 +16,27 @@ type Instance interface {
 	AgentID() string
 	HasVM() bool
 
+	// Instance state as desired by the director, e.g. started, stopped, detached
+	State() string
+
+	// Aggregate state of instance's processes, e.g. running, failing, unresponsive agent
+	ProcessState() string
+
+	Bootstrap() bool
+	PersistentDisk() bool
+
+	// IPs are reported by the agent hence there are none without a VM
+	IPs() []string
+
+	// Names of networks that instance is attached to, per deployment manifest
+	Networks() []string
+
+	// Names of release jobs per deployment manifest
+	Jobs() []string
+
+	// Names of monit processes reported by the agent
+	Processes() []string
+
 	DeleteVM() error
 }
 
@@ -0,0 +1,100 @@
+package director
+
+import (
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// manifest includes only parts of the deployment manifest
+// that are not returned by the director for each instance
+type manifest struct {
+	InstanceGroups []manifestGroup `yaml:"instance_groups"`
+
+	// Deployments that use v1 manifest schema
+	LegacyJobs []manifestGroup `yaml:"jobs"`
+}
+
+type manifestGroup struct {
+	Name     string            `yaml:"name"`
+	Networks []manifestNetwork `yaml:"networks"`
+
+	Jobs []manifestJob `yaml:"jobs"`
+
+	// Deployments that use v1 manifest schema
+	Templates []manifestJob `yaml:"templates"`
+	Template  interface{}   `yaml:"template"` // string or array of strings
+}
+
+type manifestNetwork struct {
+	Name string `yaml:"name"`
+}
+
+type manifestJob struct {
+	Name string `yaml:"name"`
+}
+
+func newManifest(bytes string) (manifest, error) {
+	var man manifest
+
+	err := yaml.Unmarshal([]byte(bytes), &man)
+	if err != nil {
+		return manifest{}, bosherr.WrapError(err, "Unmarshaling deployment manifest")
+	}
+
+	return man, nil
+}
+
+// Networks returns names of networks that instances of a group are attached to
+func (m manifest) Networks(group string) []string {
+	var names []string
+
+	for _, g := range m.groups(group) {
+		for _, network := range g.Networks {
+			names = append(names, network.Name)
+		}
+	}
+
+	return names
+}
+
+// Jobs returns names of release jobs that instances of a group run
+func (m manifest) Jobs(group string) []string {
+	var names []string
+
+	for _, g := range m.groups(group) {
+		for _, job := range append(g.Jobs, g.Templates...) {
+			names = append(names, job.Name)
+		}
+
+		switch tpl := g.Template.(type) {
+		case string:
+			names = append(names, tpl)
+		case []interface{}:
+			for _, name := range tpl {
+				if str, ok := name.(string); ok {
+					names = append(names, str)
+				}
+			}
+		}
+	}
+
+	return names
+}
+
+func (m manifest) groups(name string) []manifestGroup {
+	var groups []manifestGroup
+
+	for _, g := range m.InstanceGroups {
+		if g.Name == name {
+			groups = append(groups, g)
+		}
+	}
+
+	for _, g := range m.LegacyJobs {
+		if g.Name == name {
+			groups = append(groups, g)
+		}
+	}
+
+	return groups
+}
@@ -0,0 +1,86 @@
+package director_test
+
+import (
+	boshdir "github.com/cloudfoundry/bosh-cli/director"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cppforlife/turbulence/director"
+)
+
+var _ = Describe("manifest", func() {
+	instanceOf := func(manifest string) Instance {
+		dep := &fakeDeployment{
+			name:      "d1",
+			instances: []boshdir.Instance{{ID: "1", Group: "g1"}},
+			manifest:  manifest,
+		}
+
+		director := NewDirector(
+			fakeBoshDirector{deployments: []boshdir.Deployment{dep}},
+			boshlog.NewLogger(boshlog.LevelNone),
+		)
+
+		instances, err := director.AllInstances()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(instances).To(HaveLen(1))
+
+		return instances[0]
+	}
+
+	DescribeTable("networks and jobs of instance group",
+		func(manifest string, networks, jobs []string) {
+			inst := instanceOf(manifest)
+			Expect(inst.Networks()).To(Equal(networks))
+			Expect(inst.Jobs()).To(Equal(jobs))
+		},
+
+		Entry("v2 instance groups", `
+instance_groups:
+- name: g1
+  networks: [{name: n1}, {name: n2}]
+  jobs: [{name: j1}, {name: j2}]
+- name: g2
+  networks: [{name: n3}]
+  jobs: [{name: j3}]
+`, []string{"n1", "n2"}, []string{"j1", "j2"}),
+
+		Entry("v1 jobs with templates", `
+jobs:
+- name: g1
+  networks: [{name: n1}]
+  templates: [{name: j1}, {name: j2}]
+`, []string{"n1"}, []string{"j1", "j2"}),
+
+		Entry("v1 jobs with template as a string", `
+jobs:
+- name: g1
+  networks: [{name: n1}]
+  template: j1
+`, []string{"n1"}, []string{"j1"}),
+
+		Entry("v1 jobs with template as an array", `
+jobs:
+- name: g1
+  networks: [{name: n1}]
+  template: [j1, j2]
+`, []string{"n1"}, []string{"j1", "j2"}),
+
+		Entry("groups without networks", `
+instance_groups:
+- name: g1
+  jobs: [{name: j1}]
+`, nil, []string{"j1"}),
+
+		Entry("groups missing from the manifest", `
+instance_groups:
+- name: g2
+  networks: [{name: n1}]
+  jobs: [{name: j1}]
+`, nil, nil),
+
+		Entry("invalid manifests", `-`, nil, nil),
+	)
+})
@@ -0,0 +1,49 @@
+package emergency
+
+import (
+	"time"
+)
+
+type Request struct {
+	// Defaults to the authenticated user
+	By     string
+	Reason string
+}
+
+type Response struct {
+	Active bool
+
+	ActivatedAt string
+	ActivatedBy string
+	Reason      string
+
+	ClearedAt string
+	ClearedBy string
+
+	AbortedIncidentIDs []string
+}
+
+func NewResponse(s State) Response {
+	var activatedAt, clearedAt string
+
+	if (s.ActivatedAt != time.Time{}) {
+		activatedAt = s.ActivatedAt.Format(time.RFC3339)
+	}
+
+	if (s.ClearedAt != time.Time{}) {
+		clearedAt = s.ClearedAt.Format(time.RFC3339)
+	}
+
+	return Response{
+		Active: s.Active,
+
+		ActivatedAt: activatedAt,
+		ActivatedBy: s.ActivatedBy,
+		Reason:      s.Reason,
+
+		ClearedAt: clearedAt,
+		ClearedBy: s.ClearedBy,
+
+		AbortedIncidentIDs: s.AbortedIncidentIDs,
+	}
+}
@@ -0,0 +1,15 @@
+package emergency
+
+import (
+	"fmt"
+	"time"
+)
+
+type ActiveError struct {
+	State State
+}
+
+func (e ActiveError) Error() string {
+	return fmt.Sprintf("Emergency stop was activated by '%s' at '%s' (reason: '%s') and must be cleared first",
+		e.State.ActivatedBy, e.State.ActivatedAt.Format(time.RFC3339), e.State.Reason)
+}
@@ -0,0 +1,17 @@
+package emergency
+
+type Switch interface {
+	State() State
+
+	Activate(Request) (State, error)
+	Clear(Request) (State, error)
+}
+
+// Pausable is implemented by components that may continue to introduce chaos
+// (e.g. scheduler) and have to be paused while emergency stop is active
+type Pausable interface {
+	Pause()
+	Resume()
+}
+
+var _ Switch = &SwitchImpl{}
@@ -0,0 +1,223 @@
+package emergency
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+
+	"github.com/cppforlife/turbulence/incident"
+	"github.com/cppforlife/turbulence/incident/reporter"
+	"github.com/cppforlife/turbulence/store"
+)
+
+const stateRecordID = "state"
+
+type State struct {
+	Active bool
+
+	ActivatedAt time.Time
+	ActivatedBy string
+	Reason      string
+
+	ClearedAt time.Time
+	ClearedBy string
+
+	AbortedIncidentIDs []string
+}
+
+type SwitchImpl struct {
+	incidentsRepo incident.Repo
+	pausables     []Pausable
+	reporter      reporter.Reporter
+	journal       store.Journal
+
+	state     State
+	stateLock sync.RWMutex
+
+	logTag string
+	logger boshlog.Logger
+}
+
+func NewSwitch(
+	incidentsRepo incident.Repo,
+	pausables []Pausable,
+	reporter reporter.Reporter,
+	journal store.Journal,
+	logger boshlog.Logger,
+) (*SwitchImpl, error) {
+	s := &SwitchImpl{
+		incidentsRepo: incidentsRepo,
+		pausables:     pausables,
+		reporter:      reporter,
+		journal:       journal,
+
+		logTag: "emergency.SwitchImpl",
+		logger: logger,
+	}
+
+	err := s.load()
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Loading emergency stop state")
+	}
+
+	// Keep everything paused if emergency stop was not cleared before restart
+	if s.state.Active {
+		s.logger.Info(s.logTag, "Emergency stop is still active")
+
+		for _, p := range s.pausables {
+			p.Pause()
+		}
+	}
+
+	return s, nil
+}
+
+func (s *SwitchImpl) State() State {
+	s.stateLock.RLock()
+	defer s.stateLock.RUnlock()
+
+	return s.state
+}
+
+// Activate pauses scheduling of new incidents and aborts all running incidents.
+// Activating already active emergency stop aborts incidents that may have started since.
+func (s *SwitchImpl) Activate(req Request) (State, error) {
+	s.stateLock.Lock()
+
+	if !s.state.Active {
+		s.state = State{
+			Active: true,
+
+			ActivatedAt: time.Now().UTC(),
+			ActivatedBy: req.By,
+			Reason:      req.Reason,
+		}
+	}
+
+	s.logger.Info(s.logTag, "Activating emergency stop by '%s'", req.By)
+
+	for _, p := range s.pausables {
+		p.Pause()
+	}
+
+	s.stateLock.Unlock()
+
+	abortedIDs, abortErr := s.abortIncidents(req.By)
+
+	s.stateLock.Lock()
+
+	s.state.AbortedIncidentIDs = append(s.state.AbortedIncidentIDs, abortedIDs...)
+	state := s.state
+
+	s.stateLock.Unlock()
+
+	err := s.journal.Save(stateRecordID, state)
+	if err != nil {
+		return state, bosherr.WrapError(err, "Saving emergency stop state")
+	}
+
+	s.reporter.ReportEmergencyStop(reporter.EmergencyStop{
+		Active: true,
+		At:     state.ActivatedAt,
+		By:     req.By,
+		Reason: state.Reason,
+
+		AbortedIncidentIDs: state.AbortedIncidentIDs,
+	})
+
+	return state, abortErr
+}
+
+func (s *SwitchImpl) Clear(req Request) (State, error) {
+	s.stateLock.Lock()
+
+	if !s.state.Active {
+		state := s.state
+		s.stateLock.Unlock()
+		return state, nil
+	}
+
+	s.state.Active = false
+	s.state.ClearedAt = time.Now().UTC()
+	s.state.ClearedBy = req.By
+
+	state := s.state
+
+	s.logger.Info(s.logTag, "Clearing emergency stop by '%s'", req.By)
+
+	for _, p := range s.pausables {
+		p.Resume()
+	}
+
+	s.stateLock.Unlock()
+
+	err := s.journal.Save(stateRecordID, state)
+	if err != nil {
+		return state, bosherr.WrapError(err, "Saving emergency stop state")
+	}
+
+	s.reporter.ReportEmergencyStop(reporter.EmergencyStop{
+		Active: false,
+		At:     state.ClearedAt,
+		By:     req.By,
+		Reason: req.Reason,
+	})
+
+	return state, nil
+}
+
+func (s *SwitchImpl) abortIncidents(by string) ([]string, error) {
+	incidents, err := s.incidentsRepo.ListAll()
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Listing incidents")
+	}
+
+	var abortedIDs []string
+	var firstErr error
+
+	for _, incid := range incidents {
+		if (incid.ExecutionCompletedAt() != time.Time{}) || incid.IsAborted() {
+			continue
+		}
+
+		_, err := s.incidentsRepo.Abort(incid.ID(), incident.AbortRequest{By: by})
+		if err != nil {
+			if _, ok := err.(incident.IncidentCompletedError); ok {
+				continue // completed in the meantime
+			}
+
+			s.logger.Error(s.logTag, "Failed to abort incident '%s': %s", incid.ID(), err.Error())
+
+			if firstErr == nil {
+				firstErr = bosherr.WrapErrorf(err, "Aborting incident '%s'", incid.ID())
+			}
+
+			continue
+		}
+
+		abortedIDs = append(abortedIDs, incid.ID())
+	}
+
+	return abortedIDs, firstErr
+}
+
+func (s *SwitchImpl) load() error {
+	entries, err := s.journal.Load()
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if entry.ID == stateRecordID {
+			err := json.Unmarshal(entry.Record, &s.state)
+			if err != nil {
+				return bosherr.WrapError(err, "Unmarshalling emergency stop state")
+			}
+		}
+	}
+
+	return nil
+}
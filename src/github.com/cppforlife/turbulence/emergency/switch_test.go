@@ -0,0 +1,261 @@
+package emergency_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cppforlife/turbulence/blackout"
+	"github.com/cppforlife/turbulence/director"
+	. "github.com/cppforlife/turbulence/emergency"
+	"github.com/cppforlife/turbulence/incident"
+	"github.com/cppforlife/turbulence/incident/reporter"
+	"github.com/cppforlife/turbulence/policy"
+	"github.com/cppforlife/turbulence/scenario"
+	"github.com/cppforlife/turbulence/scheduledinc"
+	"github.com/cppforlife/turbulence/store"
+	"github.com/cppforlife/turbulence/tasks"
+)
+
+type fakeDirector struct{}
+
+func (fakeDirector) AllInstances() ([]director.Instance, error) { return nil, nil }
+func (fakeDirector) SubmitEvent(director.EventOpts) error       { return nil }
+
+// noopNotifier leaves created incidents and scenarios running
+type noopNotifier struct{}
+
+func (noopNotifier) IncidentWasCreated(incident.Incident) {}
+func (noopNotifier) ScenarioWasCreated(scenario.Scenario) {}
+
+type fakeReporter struct {
+	reporter.Logger
+
+	lock           sync.Mutex
+	emergencyStops []reporter.EmergencyStop
+}
+
+func (r *fakeReporter) ReportEmergencyStop(stop reporter.EmergencyStop) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.emergencyStops = append(r.emergencyStops, stop)
+}
+
+var _ = Describe("SwitchImpl", func() {
+	var (
+		logger        boshlog.Logger
+		rep           *fakeReporter
+		incidentsRepo incident.Repo
+		scenariosRepo scenario.Repo
+		scheduler     *scheduledinc.Scheduler
+		dir           string
+		journalPath   string
+	)
+
+	BeforeEach(func() {
+		logger = boshlog.NewLogger(boshlog.LevelNone)
+		rep = &fakeReporter{Logger: reporter.NewLogger(logger)}
+
+		var err error
+
+		incidentsRepo, err = incident.NewRepo(
+			boshuuid.NewGenerator(),
+			noopNotifier{},
+			rep,
+			fakeDirector{},
+			tasks.NewRepo(logger),
+			nil,
+			policy.NewPolicy(policy.Config{}, logger),
+			blackout.Calendar{},
+			store.NewMemoryJournal(),
+			logger,
+		)
+		Expect(err).ToNot(HaveOccurred())
+
+		scenariosRepo, err = scenario.NewRepo(
+			boshuuid.NewGenerator(), noopNotifier{}, incidentsRepo, store.NewMemoryJournal(), logger)
+		Expect(err).ToNot(HaveOccurred())
+
+		scheduler = scheduledinc.NewScheduler(blackout.Calendar{}, logger)
+
+		dir, err = ioutil.TempDir("", "emergency")
+		Expect(err).ToNot(HaveOccurred())
+
+		journalPath = filepath.Join(dir, "emergency_stop.json")
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	newSwitch := func() *SwitchImpl {
+		journal := store.NewFileJournal(journalPath, boshsys.NewOsFileSystem(logger), logger)
+
+		s, err := NewSwitch(incidentsRepo, []Pausable{scheduler, scenariosRepo}, rep, journal, logger)
+		Expect(err).ToNot(HaveOccurred())
+
+		return s
+	}
+
+	createIncident := func() incident.Incident {
+		incid, err := incidentsRepo.Create(incident.Request{
+			Tasks: tasks.OptionsSlice{tasks.PauseProcessOptions{ProcessName: "nats", Timeout: "1m"}},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		return incid
+	}
+
+	Describe("Activate", func() {
+		It("pauses scheduler and scenarios and aborts running incidents", func() {
+			runningIncid := createIncident()
+
+			completedIncid := createIncident()
+			Expect(completedIncid.Execute()).ToNot(HaveOccurred())
+
+			sc, err := scenariosRepo.Create(scenario.Request{
+				Steps: []scenario.StepRequest{{Wait: "1m"}},
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			s := newSwitch()
+
+			state, err := s.Activate(Request{By: "fake-user", Reason: "fake-reason"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(state.Active).To(BeTrue())
+			Expect(state.ActivatedAt.IsZero()).To(BeFalse())
+			Expect(state.ActivatedBy).To(Equal("fake-user"))
+			Expect(state.Reason).To(Equal("fake-reason"))
+			Expect(state.AbortedIncidentIDs).To(Equal([]string{runningIncid.ID()}))
+			Expect(s.State()).To(Equal(state))
+
+			Expect(scheduler.IsPaused()).To(BeTrue())
+
+			runningIncid, err = incidentsRepo.Read(runningIncid.ID())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(runningIncid.AbortedBy()).To(Equal("fake-user"))
+
+			completedIncid, err = incidentsRepo.Read(completedIncid.ID())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(completedIncid.IsAborted()).To(BeFalse())
+
+			sc, err = scenariosRepo.Read(sc.ID())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(sc.AbortedBy()).To(Equal("emergency stop"))
+
+			_, err = scenariosRepo.Create(scenario.Request{Steps: []scenario.StepRequest{{Wait: "1m"}}})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Scenarios cannot be created while emergency stop is active"))
+
+			Expect(rep.emergencyStops).To(Equal([]reporter.EmergencyStop{{
+				Active: true,
+				At:     state.ActivatedAt,
+				By:     "fake-user",
+				Reason: "fake-reason",
+
+				AbortedIncidentIDs: []string{runningIncid.ID()},
+			}}))
+		})
+
+		It("keeps original activation and aborts incidents started since when activated again", func() {
+			s := newSwitch()
+
+			firstIncid := createIncident()
+
+			firstState, err := s.Activate(Request{By: "fake-user1", Reason: "fake-reason1"})
+			Expect(err).ToNot(HaveOccurred())
+
+			secondIncid := createIncident()
+
+			state, err := s.Activate(Request{By: "fake-user2", Reason: "fake-reason2"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(state.ActivatedAt).To(Equal(firstState.ActivatedAt))
+			Expect(state.ActivatedBy).To(Equal("fake-user1"))
+			Expect(state.Reason).To(Equal("fake-reason1"))
+			Expect(state.AbortedIncidentIDs).To(Equal([]string{firstIncid.ID(), secondIncid.ID()}))
+
+			secondIncid, err = incidentsRepo.Read(secondIncid.ID())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(secondIncid.AbortedBy()).To(Equal("fake-user2"))
+		})
+	})
+
+	Describe("Clear", func() {
+		It("resumes scheduler and scenarios", func() {
+			s := newSwitch()
+
+			_, err := s.Activate(Request{By: "fake-user1"})
+			Expect(err).ToNot(HaveOccurred())
+
+			state, err := s.Clear(Request{By: "fake-user2", Reason: "fake-reason"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(state.Active).To(BeFalse())
+			Expect(state.ActivatedBy).To(Equal("fake-user1"))
+			Expect(state.ClearedAt.IsZero()).To(BeFalse())
+			Expect(state.ClearedBy).To(Equal("fake-user2"))
+
+			Expect(scheduler.IsPaused()).To(BeFalse())
+
+			_, err = scenariosRepo.Create(scenario.Request{Steps: []scenario.StepRequest{{Wait: "1m"}}})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(rep.emergencyStops).To(HaveLen(2))
+			Expect(rep.emergencyStops[1]).To(Equal(reporter.EmergencyStop{
+				Active: false,
+				At:     state.ClearedAt,
+				By:     "fake-user2",
+				Reason: "fake-reason",
+			}))
+		})
+
+		It("does nothing when emergency stop is not active", func() {
+			s := newSwitch()
+
+			scheduler.Pause()
+
+			state, err := s.Clear(Request{By: "fake-user"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(state).To(Equal(State{}))
+
+			Expect(scheduler.IsPaused()).To(BeTrue())
+			Expect(rep.emergencyStops).To(BeEmpty())
+		})
+	})
+
+	Describe("restart", func() {
+		It("keeps scheduler and scenarios paused while emergency stop is still active", func() {
+			activatedState, err := newSwitch().Activate(Request{By: "fake-user", Reason: "fake-reason"})
+			Expect(err).ToNot(HaveOccurred())
+
+			scheduler.Resume()
+			scenariosRepo.Resume()
+
+			s := newSwitch()
+			Expect(s.State()).To(Equal(activatedState))
+
+			Expect(scheduler.IsPaused()).To(BeTrue())
+
+			_, err = scenariosRepo.Create(scenario.Request{Steps: []scenario.StepRequest{{Wait: "1m"}}})
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("does not pause anything once emergency stop was cleared", func() {
+			s := newSwitch()
+
+			_, err := s.Activate(Request{By: "fake-user1"})
+			Expect(err).ToNot(HaveOccurred())
+
+			clearedState, err := s.Clear(Request{By: "fake-user2"})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(newSwitch().State()).To(Equal(clearedState))
+			Expect(scheduler.IsPaused()).To(BeFalse())
+		})
+	})
+})
@@ -0,0 +1,52 @@
+package execution
+
+import (
+	"sync"
+	"time"
+)
+
+// Abortion is shared by all copies of an incident or a scenario
+// so that executing copy notices when it's aborted
+type Abortion struct {
+	ch   chan struct{}
+	lock sync.RWMutex
+
+	at time.Time
+	by string
+}
+
+func NewAbortion() *Abortion {
+	return &Abortion{ch: make(chan struct{})}
+}
+
+// Abort returns false if it was already aborted
+func (a *Abortion) Abort(at time.Time, by string) bool {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	if (a.at != time.Time{}) {
+		return false
+	}
+
+	a.at = at
+	a.by = by
+
+	close(a.ch)
+
+	return true
+}
+
+func (a *Abortion) At() time.Time {
+	a.lock.RLock()
+	defer a.lock.RUnlock()
+	return a.at
+}
+
+func (a *Abortion) By() string {
+	a.lock.RLock()
+	defer a.lock.RUnlock()
+	return a.by
+}
+
+// Ch is closed once aborted
+func (a *Abortion) Ch() <-chan struct{} { return a.ch }
@@ -0,0 +1,71 @@
+package execution_test
+
+import (
+	"encoding/json"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cppforlife/turbulence/execution"
+)
+
+var _ = Describe("Abortion", func() {
+	It("closes channel and keeps time and author of the first abort", func() {
+		abortion := NewAbortion()
+		Expect(abortion.Ch()).ToNot(BeClosed())
+
+		at := time.Now().UTC()
+
+		Expect(abortion.Abort(at, "user-1")).To(BeTrue())
+		Expect(abortion.Abort(at.Add(time.Minute), "user-2")).To(BeFalse())
+
+		Expect(abortion.Ch()).To(BeClosed())
+		Expect(abortion.At()).To(Equal(at))
+		Expect(abortion.By()).To(Equal("user-1"))
+	})
+})
+
+var _ = Describe("Record", func() {
+	It("is running until it completes or is interrupted", func() {
+		Expect(Record{}.IsRunning()).To(BeTrue())
+		Expect(Record{ExecutionCompletedAt: time.Now()}.IsRunning()).To(BeFalse())
+
+		rec := Record{ExecutionStartedAt: time.Now()}
+		now := rec.Interrupt()
+
+		Expect(rec.IsRunning()).To(BeFalse())
+		Expect(rec.Interrupted).To(BeTrue())
+		Expect(rec.ExecutionCompletedAt).To(Equal(now))
+	})
+
+	It("is stored inline in embedding records", func() {
+		bytes, err := json.Marshal(struct {
+			ID string
+			Record
+		}{ID: "id", Record: Record{AbortedBy: "user"}})
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(string(bytes)).To(Equal(`{"ID":"id","ExecutionStartedAt":"0001-01-01T00:00:00Z",` +
+			`"ExecutionCompletedAt":"0001-01-01T00:00:00Z","AbortedAt":"0001-01-01T00:00:00Z","AbortedBy":"user"}`))
+	})
+})
+
+var _ = Describe("InterruptEvent", func() {
+	It("completes only unfinished events with an error", func() {
+		now := time.Now().UTC()
+		completedAt := now.Add(-time.Minute)
+
+		var unfinishedAt time.Time
+		var unfinishedErr, finishedErr string
+
+		InterruptEvent(&unfinishedAt, &unfinishedErr, now)
+		InterruptEvent(&completedAt, &finishedErr, now)
+
+		Expect(unfinishedAt).To(Equal(now))
+		Expect(unfinishedErr).To(Equal(InterruptedErrMsg))
+
+		Expect(completedAt).To(Equal(now.Add(-time.Minute)))
+		Expect(finishedErr).To(BeEmpty())
+	})
+})
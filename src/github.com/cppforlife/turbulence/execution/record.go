@@ -0,0 +1,44 @@
+package execution
+
+import (
+	"time"
+)
+
+const InterruptedErrMsg = "Interrupted by API server restart"
+
+// Record is a persisted execution state of an incident or a scenario;
+// it's embedded into their records so that fields are stored inline
+type Record struct {
+	ExecutionStartedAt   time.Time
+	ExecutionCompletedAt time.Time
+
+	Interrupted bool `json:",omitempty"`
+
+	AbortedAt time.Time
+	AbortedBy string `json:",omitempty"`
+}
+
+// IsRunning returns true if execution did not finish
+// before it was recorded (e.g. API server was stopped mid-execution).
+func (r Record) IsRunning() bool {
+	return !r.Interrupted && (r.ExecutionCompletedAt == time.Time{})
+}
+
+// Interrupt marks execution as interrupted and returns
+// completion time that unfinished events should use
+func (r *Record) Interrupt() time.Time {
+	now := time.Now().UTC()
+
+	r.Interrupted = true
+	r.ExecutionCompletedAt = now
+
+	return now
+}
+
+// InterruptEvent completes event with an error unless it already completed
+func InterruptEvent(completedAt *time.Time, errMsg *string, now time.Time) {
+	if (*completedAt == time.Time{}) {
+		*completedAt = now
+		*errMsg = InterruptedErrMsg
+	}
+}
@@ -0,0 +1,93 @@
+package fleet
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// HeartbeatInterval is how often agents send heartbeats
+	HeartbeatInterval = 10 * time.Second
+
+	// StaleAfter is how long agents may not send heartbeats before they are considered stale
+	StaleAfter = 3 * HeartbeatInterval
+)
+
+const (
+	StateAlive   = "alive"
+	StateStale   = "stale"
+	StateMissing = "missing"
+
+	// Instance does not have a VM hence it's not expected to have an agent
+	StateNoVM = "no-vm"
+)
+
+type Agent struct {
+	ID       string
+	Hostname string
+	Version  string
+
+	// Uptime as of the last heartbeat
+	Uptime time.Duration
+
+	RunningTaskIDs []string
+
+	// Nil if agent did not report its capabilities
+	Capabilities []string
+
+	RegisteredAt    time.Time
+	LastHeartbeatAt time.Time
+}
+
+func (a Agent) State(now time.Time) string {
+	if (a.LastHeartbeatAt == time.Time{}) {
+		return StateMissing
+	}
+
+	if now.Sub(a.LastHeartbeatAt) > StaleAfter {
+		return StateStale
+	}
+
+	return StateAlive
+}
+
+// MissingCapabilities returns required capabilities that agent does not have;
+// agents that did not report capabilities are assumed to have all of them
+func (a Agent) MissingCapabilities(required []string) []string {
+	if a.Capabilities == nil {
+		return nil
+	}
+
+	var missing []string
+
+	for _, req := range required {
+		found := false
+
+		for _, capability := range a.Capabilities {
+			if capability == req {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			missing = append(missing, req)
+		}
+	}
+
+	return missing
+}
+
+type NotAliveError struct {
+	Agent Agent
+	State string
+}
+
+func (e NotAliveError) Error() string {
+	if e.State == StateStale {
+		return fmt.Sprintf("Agent '%s' is stale (last heartbeat at '%s')",
+			e.Agent.ID, e.Agent.LastHeartbeatAt.Format(time.RFC3339))
+	}
+
+	return fmt.Sprintf("Agent '%s' is %s", e.Agent.ID, e.State)
+}
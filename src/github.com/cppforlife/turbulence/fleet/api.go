@@ -0,0 +1,133 @@
+package fleet
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/cppforlife/turbulence/director"
+)
+
+type HeartbeatRequest struct {
+	Hostname string
+	Version  string
+
+	// Seconds since agent started
+	Uptime int64
+
+	RunningTaskIDs []string
+
+	// Names of available capabilities (see tasks.ProbeCapabilities);
+	// not reported by older agents
+	Capabilities []string
+}
+
+func (r HeartbeatRequest) agent(agentID string, now time.Time) Agent {
+	return Agent{
+		ID:       agentID,
+		Hostname: r.Hostname,
+		Version:  r.Version,
+		Uptime:   time.Duration(r.Uptime) * time.Second,
+
+		RunningTaskIDs: r.RunningTaskIDs,
+		Capabilities:   r.Capabilities,
+
+		LastHeartbeatAt: now,
+	}
+}
+
+// RecoveryRequest is sent by agent after it undid changes left
+// behind by tasks that were running when agent stopped
+type RecoveryRequest struct {
+	Changes []RecoveredChange
+}
+
+type RecoveredChange struct {
+	TaskID      string
+	Description string
+
+	// Set when undoing change failed
+	Error string `json:",omitempty"`
+}
+
+// Item is a Director instance and/or its agent;
+// instance is nil for agents that do not belong to any instance
+type Item struct {
+	Instance director.Instance
+	AgentID  string
+	Agent    *Agent
+	State    string
+}
+
+type Response struct {
+	AgentID string
+
+	// One of alive, stale, missing, no-vm
+	State string
+
+	Deployment string `json:",omitempty"`
+	Group      string `json:",omitempty"`
+	InstanceID string `json:",omitempty"`
+	AZ         string `json:",omitempty"`
+
+	Hostname string
+	Version  string
+	Uptime   string
+
+	RunningTaskIDs []string
+	Capabilities   []string
+
+	RegisteredAt    string
+	LastHeartbeatAt string
+}
+
+func NewResponses(items []Item) []Response {
+	resps := []Response{}
+
+	for _, item := range items {
+		resps = append(resps, NewResponse(item))
+	}
+
+	return resps
+}
+
+func NewResponse(item Item) Response {
+	resp := Response{
+		AgentID: item.AgentID,
+		State:   item.State,
+
+		RunningTaskIDs: []string{},
+	}
+
+	if item.Instance != nil {
+		resp.Deployment = item.Instance.Deployment()
+		resp.Group = item.Instance.Group()
+		resp.InstanceID = item.Instance.ID()
+		resp.AZ = item.Instance.AZ()
+	}
+
+	if item.Agent != nil {
+		resp.Hostname = item.Agent.Hostname
+		resp.Version = item.Agent.Version
+		resp.Uptime = item.Agent.Uptime.String()
+
+		if len(item.Agent.RunningTaskIDs) > 0 {
+			resp.RunningTaskIDs = item.Agent.RunningTaskIDs
+		}
+
+		resp.Capabilities = item.Agent.Capabilities
+
+		resp.RegisteredAt = item.Agent.RegisteredAt.Format(time.RFC3339)
+		resp.LastHeartbeatAt = item.Agent.LastHeartbeatAt.Format(time.RFC3339)
+	}
+
+	return resp
+}
+
+func (r Response) InstanceName() string {
+	if len(r.InstanceID) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%s/%s", r.Group, r.InstanceID)
+}
+
+func (r Response) IsHealthy() bool { return r.State == StateAlive || r.State == StateNoVM }
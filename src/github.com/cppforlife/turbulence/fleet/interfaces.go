@@ -0,0 +1,27 @@
+package fleet
+
+import (
+	"time"
+)
+
+type Repo interface {
+	// Register records agent that (re)started
+	Register(string, HeartbeatRequest) error
+	Heartbeat(string, HeartbeatRequest) error
+
+	ListAll() []Agent
+	Find(string) (Agent, bool)
+
+	// Inventory lists all Director instances with their agents
+	// followed by agents that do not belong to any instance
+	Inventory() ([]Item, error)
+
+	// CheckAlive returns NotAliveError for agents that are missing or stale
+	CheckAlive(string, time.Time) error
+
+	// MissingCapabilities returns which of required capabilities agent lacks
+	MissingCapabilities(string, []string) []string
+
+	// RecordRecovery reports changes undone by restarted agent
+	RecordRecovery(string, RecoveryRequest) error
+}
@@ -0,0 +1,217 @@
+package fleet
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+
+	"github.com/cppforlife/turbulence/director"
+	"github.com/cppforlife/turbulence/incident/reporter"
+)
+
+// Agents are only kept in memory since they send heartbeats
+// frequently enough to be rediscovered after API server restarts.
+type repo struct {
+	director director.Director
+	reporter reporter.Reporter
+
+	startedAt time.Time
+
+	agents     map[string]Agent
+	agentsLock sync.RWMutex
+
+	logTag string
+	logger boshlog.Logger
+}
+
+func NewRepo(director director.Director, reporter reporter.Reporter, logger boshlog.Logger) Repo {
+	return &repo{
+		director: director,
+		reporter: reporter,
+
+		startedAt: time.Now(),
+
+		agents: map[string]Agent{},
+
+		logTag: "fleet.repo",
+		logger: logger,
+	}
+}
+
+func (r *repo) Register(agentID string, req HeartbeatRequest) error {
+	if len(agentID) == 0 {
+		return bosherr.Error("Must provide non-empty agent ID")
+	}
+
+	r.agentsLock.Lock()
+	defer r.agentsLock.Unlock()
+
+	now := time.Now()
+
+	agent := req.agent(agentID, now)
+	agent.RegisteredAt = now
+
+	r.agents[agentID] = agent
+
+	r.logger.Debug(r.logTag, "Registered agent '%s' (version '%s') on '%s'", agentID, req.Version, req.Hostname)
+
+	return nil
+}
+
+func (r *repo) Heartbeat(agentID string, req HeartbeatRequest) error {
+	if len(agentID) == 0 {
+		return bosherr.Error("Must provide non-empty agent ID")
+	}
+
+	r.agentsLock.Lock()
+	defer r.agentsLock.Unlock()
+
+	now := time.Now()
+
+	agent := req.agent(agentID, now)
+
+	// Agents registered before API server restarted only send heartbeats
+	if prevAgent, found := r.agents[agentID]; found {
+		agent.RegisteredAt = prevAgent.RegisteredAt
+	} else {
+		agent.RegisteredAt = now.Add(-agent.Uptime)
+	}
+
+	r.agents[agentID] = agent
+
+	return nil
+}
+
+func (r *repo) ListAll() []Agent {
+	r.agentsLock.RLock()
+	defer r.agentsLock.RUnlock()
+
+	agents := []Agent{}
+
+	for _, agent := range r.agents {
+		agents = append(agents, agent)
+	}
+
+	sort.Slice(agents, func(i, j int) bool { return agents[i].ID < agents[j].ID })
+
+	return agents
+}
+
+func (r *repo) Find(agentID string) (Agent, bool) {
+	r.agentsLock.RLock()
+	defer r.agentsLock.RUnlock()
+
+	agent, found := r.agents[agentID]
+
+	return agent, found
+}
+
+func (r *repo) Inventory() ([]Item, error) {
+	instances, err := r.director.AllInstances()
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Listing instances")
+	}
+
+	now := time.Now()
+	agents := r.ListAll()
+	items := []Item{}
+	knownAgentIDs := map[string]struct{}{}
+
+	for _, inst := range instances {
+		item := Item{Instance: inst, AgentID: inst.AgentID(), State: StateNoVM}
+
+		if inst.HasVM() {
+			knownAgentIDs[inst.AgentID()] = struct{}{}
+
+			agent, found := r.Find(inst.AgentID())
+			if found {
+				item.Agent = &agent
+			}
+
+			item.State = agent.State(now)
+		}
+
+		items = append(items, item)
+	}
+
+	for _, agent := range agents {
+		if _, found := knownAgentIDs[agent.ID]; !found {
+			agent := agent
+			items = append(items, Item{AgentID: agent.ID, Agent: &agent, State: agent.State(now)})
+		}
+	}
+
+	return items, nil
+}
+
+func (r *repo) MissingCapabilities(agentID string, required []string) []string {
+	agent, found := r.Find(agentID)
+	if !found {
+		return nil
+	}
+
+	return agent.MissingCapabilities(required)
+}
+
+func (r *repo) CheckAlive(agentID string, now time.Time) error {
+	agent, found := r.Find(agentID)
+	if !found {
+		agent = Agent{ID: agentID}
+	}
+
+	state := agent.State(now)
+
+	if state == StateAlive {
+		return nil
+	}
+
+	// Running agents may not have sent heartbeats since API server started
+	if state == StateMissing && now.Sub(r.startedAt) <= StaleAfter {
+		return nil
+	}
+
+	return NotAliveError{Agent: agent, State: state}
+}
+
+func (r *repo) RecordRecovery(agentID string, req RecoveryRequest) error {
+	if len(agentID) == 0 {
+		return bosherr.Error("Must provide non-empty agent ID")
+	}
+
+	recovery := reporter.AgentRecovery{AgentID: agentID, At: time.Now().UTC()}
+
+	instances, err := r.director.AllInstances()
+	if err != nil {
+		// Still report recovery without instance details
+		r.logger.Error(r.logTag, "Failed finding instance of agent '%s': %s", agentID, err.Error())
+	}
+
+	for _, inst := range instances {
+		if inst.HasVM() && inst.AgentID() == agentID {
+			// Only deployment of the matching instance fetches agent reported IPs
+			recovery.Instance = reporter.EventInstance{
+				ID:         inst.ID(),
+				Group:      inst.Group(),
+				Deployment: inst.Deployment(),
+				AZ:         inst.AZ(),
+				IPs:        inst.IPs(),
+			}
+			break
+		}
+	}
+
+	for _, change := range req.Changes {
+		recovery.Changes = append(recovery.Changes, reporter.AgentRecoveryChange{
+			TaskID:      change.TaskID,
+			Description: change.Description,
+			Error:       change.Error,
+		})
+	}
+
+	r.reporter.ReportAgentRecovery(recovery)
+
+	return nil
+}
@@ -0,0 +1,182 @@
+package fleet_test
+
+import (
+	"time"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cppforlife/turbulence/director"
+	. "github.com/cppforlife/turbulence/fleet"
+	"github.com/cppforlife/turbulence/incident/reporter"
+)
+
+type fakeInstance struct {
+	id, agentID string
+	missingVM   bool
+	ips         []string
+}
+
+func (i fakeInstance) ID() string         { return i.id }
+func (i fakeInstance) Group() string      { return "group" }
+func (i fakeInstance) Deployment() string { return "dep" }
+func (i fakeInstance) AZ() string         { return "z1" }
+func (i fakeInstance) AgentID() string    { return i.agentID }
+func (i fakeInstance) HasVM() bool        { return !i.missingVM }
+
+func (i fakeInstance) State() string        { return "started" }
+func (i fakeInstance) ProcessState() string { return "running" }
+func (i fakeInstance) Bootstrap() bool      { return false }
+func (i fakeInstance) PersistentDisk() bool { return false }
+func (i fakeInstance) IPs() []string        { return i.ips }
+func (i fakeInstance) Networks() []string   { return []string{"default"} }
+func (i fakeInstance) Jobs() []string       { return nil }
+func (i fakeInstance) Processes() []string  { return nil }
+func (i fakeInstance) DeleteVM() error      { return nil }
+
+type fakeDirector struct {
+	instances []director.Instance
+}
+
+func (d fakeDirector) AllInstances() ([]director.Instance, error) { return d.instances, nil }
+func (d fakeDirector) SubmitEvent(director.EventOpts) error       { return nil }
+
+type fakeReporter struct {
+	reporter.Logger
+
+	recoveries *[]reporter.AgentRecovery
+}
+
+func (r fakeReporter) ReportAgentRecovery(rec reporter.AgentRecovery) {
+	*r.recoveries = append(*r.recoveries, rec)
+}
+
+var _ = Describe("Agent", func() {
+	Describe("State", func() {
+		now := time.Now()
+
+		It("is missing when agent never sent heartbeat", func() {
+			Expect(Agent{}.State(now)).To(Equal(StateMissing))
+		})
+
+		It("is alive when agent recently sent heartbeat", func() {
+			Expect(Agent{LastHeartbeatAt: now.Add(-StaleAfter)}.State(now)).To(Equal(StateAlive))
+		})
+
+		It("is stale when agent did not send heartbeat for a while", func() {
+			Expect(Agent{LastHeartbeatAt: now.Add(-StaleAfter - time.Second)}.State(now)).To(Equal(StateStale))
+		})
+	})
+})
+
+var _ = Describe("Agent", func() {
+	Describe("MissingCapabilities", func() {
+		It("returns required capabilities that agent does not have", func() {
+			agent := Agent{Capabilities: []string{"stress", "tc"}}
+			Expect(agent.MissingCapabilities([]string{"tc", "dig", "sysrq"})).To(Equal([]string{"dig", "sysrq"}))
+		})
+
+		It("assumes that agents that did not report capabilities have all of them", func() {
+			Expect(Agent{}.MissingCapabilities([]string{"dig"})).To(BeEmpty())
+		})
+	})
+})
+
+var _ = Describe("Repo", func() {
+	var (
+		recoveries []reporter.AgentRecovery
+		repo       Repo
+	)
+
+	BeforeEach(func() {
+		dir := fakeDirector{[]director.Instance{
+			fakeInstance{id: "inst1", agentID: "agent1"},
+			fakeInstance{id: "inst2", agentID: "agent2", ips: []string{"10.0.0.2"}},
+			fakeInstance{id: "inst3", missingVM: true},
+		}}
+
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		recoveries = nil
+		rep := fakeReporter{reporter.NewLogger(logger), &recoveries}
+
+		repo = NewRepo(dir, rep, logger)
+	})
+
+	It("keeps registration time across heartbeats", func() {
+		Expect(repo.Register("agent1", HeartbeatRequest{Hostname: "host1", Version: "1"})).ToNot(HaveOccurred())
+
+		registered, found := repo.Find("agent1")
+		Expect(found).To(BeTrue())
+
+		Expect(repo.Heartbeat("agent1", HeartbeatRequest{Uptime: 10, RunningTaskIDs: []string{"task1"}})).ToNot(HaveOccurred())
+
+		agent, found := repo.Find("agent1")
+		Expect(found).To(BeTrue())
+		Expect(agent.RegisteredAt).To(Equal(registered.RegisteredAt))
+		Expect(agent.Uptime).To(Equal(10 * time.Second))
+		Expect(agent.RunningTaskIDs).To(Equal([]string{"task1"}))
+	})
+
+	It("lists instances with states of their agents followed by unknown agents", func() {
+		Expect(repo.Heartbeat("agent1", HeartbeatRequest{})).ToNot(HaveOccurred())
+		Expect(repo.Heartbeat("agent-other", HeartbeatRequest{})).ToNot(HaveOccurred())
+
+		items, err := repo.Inventory()
+		Expect(err).ToNot(HaveOccurred())
+
+		var states []string
+		for _, item := range items {
+			states = append(states, item.AgentID+"="+item.State)
+		}
+
+		Expect(states).To(Equal([]string{
+			"agent1=alive", "agent2=missing", "=no-vm", "agent-other=alive",
+		}))
+		Expect(items[3].Instance).To(BeNil())
+	})
+
+	Describe("CheckAlive", func() {
+		It("allows agents that sent heartbeat recently", func() {
+			Expect(repo.Heartbeat("agent1", HeartbeatRequest{})).ToNot(HaveOccurred())
+			Expect(repo.CheckAlive("agent1", time.Now())).ToNot(HaveOccurred())
+		})
+
+		It("returns error for stale agents", func() {
+			Expect(repo.Heartbeat("agent1", HeartbeatRequest{})).ToNot(HaveOccurred())
+
+			err := repo.CheckAlive("agent1", time.Now().Add(StaleAfter+time.Second))
+			Expect(err).To(BeAssignableToTypeOf(NotAliveError{}))
+			Expect(err.Error()).To(ContainSubstring("Agent 'agent1' is stale"))
+		})
+
+		It("allows missing agents right after start up since they may not have sent heartbeats yet", func() {
+			Expect(repo.CheckAlive("agent2", time.Now())).ToNot(HaveOccurred())
+		})
+
+		It("returns error for missing agents after start up", func() {
+			err := repo.CheckAlive("agent2", time.Now().Add(StaleAfter+time.Second))
+			Expect(err).To(MatchError("Agent 'agent2' is missing"))
+		})
+	})
+
+	It("reports recovered changes with instance of the agent", func() {
+		err := repo.RecordRecovery("agent2", RecoveryRequest{
+			Changes: []RecoveredChange{
+				{TaskID: "task1", Description: "desc1"},
+				{TaskID: "task1", Description: "desc2", Error: "err"},
+			},
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(recoveries).To(HaveLen(1))
+		Expect(recoveries[0].AgentID).To(Equal("agent2"))
+		Expect(recoveries[0].Instance).To(Equal(reporter.EventInstance{
+			ID: "inst2", Group: "group", Deployment: "dep", AZ: "z1", IPs: []string{"10.0.0.2"}}))
+		Expect(recoveries[0].Changes).To(Equal([]reporter.AgentRecoveryChange{
+			{TaskID: "task1", Description: "desc1"},
+			{TaskID: "task1", Description: "desc2", Error: "err"},
+		}))
+		Expect(recoveries[0].FailedChanges()).To(Equal(1))
+	})
+})
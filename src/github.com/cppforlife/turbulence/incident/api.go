@@ -13,6 +13,71 @@ import (
 type Request struct {
 	Tasks    tasks.OptionsSlice
 	Selector selector.Request
+
+	// Seed makes instance selection reproducible;
+	// random seed is picked if it's not specified
+	Seed int64 `json:",omitempty"`
+
+	// All selected instances are affected at once by default
+	Execution *ExecutionRequest `json:",omitempty"`
+
+	// Incident is rejected if agents lack capabilities required by tasks
+	// unless allowed; tasks on such instances fail right away
+	AllowMissingCapabilities bool `json:",omitempty"`
+}
+
+// Validate returns tasks.ValidationError so that invalid requests
+// are rejected before any tasks are dispatched to agents
+func (r Request) Validate() error {
+	var fieldErrs []tasks.FieldError
+
+	if len(r.Tasks) == 0 {
+		fieldErrs = append(fieldErrs, tasks.FieldError{Field: "Tasks", Message: "must include at least one task"})
+	}
+
+	if err := r.Tasks.Validate(); err != nil {
+		valErr, ok := err.(tasks.ValidationError)
+		if !ok {
+			return err
+		}
+		fieldErrs = append(fieldErrs, valErr.Nested("Tasks").Errors...)
+	}
+
+	if err := r.Selector.Validate(); err != nil {
+		fieldErrs = append(fieldErrs, tasks.FieldError{Field: "Selector", Message: err.Error()})
+	}
+
+	if r.Execution != nil {
+		if err := r.Execution.Validate(); err != nil {
+			fieldErrs = append(fieldErrs, tasks.FieldError{Field: "Execution", Message: err.Error()})
+		}
+
+		// Next batch waits for tasks of the previous batch to finish
+		for i, taskOpts := range r.Tasks {
+			if tasks.RunsUntilStopped(taskOpts) {
+				fieldErrs = append(fieldErrs, tasks.FieldError{
+					Field:   fmt.Sprintf("Tasks[%d]", i),
+					Message: "must finish on its own (e.g. specify Timeout) when Execution is specified",
+				})
+			}
+		}
+	}
+
+	if len(fieldErrs) > 0 {
+		return tasks.ValidationError{Errors: fieldErrs}
+	}
+
+	return nil
+}
+
+func (r Request) TaskTypes() []string {
+	var types []string
+
+	for _, taskOpts := range r.Tasks {
+		types = append(types, tasks.OptionsType(taskOpts))
+	}
+
+	return types
 }
 
 type Response struct {
@@ -22,10 +87,21 @@ type Response struct {
 
 	Tasks    tasks.OptionsSlice
 	Selector selector.Request
+	Seed     int64
+
+	// Readable form of the selector including nested expressions
+	SelectorDescription string
+
+	Execution *ExecutionRequest `json:",omitempty"`
 
 	ExecutionStartedAt   string
 	ExecutionCompletedAt string
 
+	Interrupted bool
+
+	AbortedAt string
+	AbortedBy string
+
 	Events []reporter.EventResponse
 
 	description string
@@ -50,12 +126,16 @@ func NewResponse(incident Incident) Response {
 		eventResps = append(eventResps, reporter.NewEventResponse(event))
 	}
 
-	var completedAt string
+	var completedAt, abortedAt string
 
 	if (incident.ExecutionCompletedAt() != time.Time{}) {
 		completedAt = incident.ExecutionCompletedAt().Format(time.RFC3339)
 	}
 
+	if incident.IsAborted() {
+		abortedAt = incident.AbortedAt().Format(time.RFC3339)
+	}
+
 	return Response{
 		incident: incident,
 
@@ -63,10 +143,20 @@ func NewResponse(incident Incident) Response {
 
 		Tasks:    incident.Tasks,
 		Selector: incident.Selector,
+		Seed:     incident.Seed,
+
+		SelectorDescription: incident.Selector.Description(),
+
+		Execution: incident.Execution,
 
 		ExecutionStartedAt:   incident.ExecutionStartedAt().Format(time.RFC3339),
 		ExecutionCompletedAt: completedAt,
 
+		Interrupted: incident.Interrupted(),
+
+		AbortedAt: abortedAt,
+		AbortedBy: incident.AbortedBy(),
+
 		Events: eventResps,
 	}
 }
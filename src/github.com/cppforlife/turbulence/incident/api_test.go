@@ -0,0 +1,32 @@
+package incident_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cppforlife/turbulence/incident"
+	"github.com/cppforlife/turbulence/tasks"
+)
+
+var _ = Describe("Request", func() {
+	Describe("Validate", func() {
+		It("rejects staggered execution of tasks that run until stopped", func() {
+			req := Request{
+				Tasks: tasks.OptionsSlice{
+					tasks.PauseProcessOptions{ProcessName: "nats", Timeout: "1m"},
+					tasks.PauseProcessOptions{ProcessName: "nats"},
+				},
+				Execution: &ExecutionRequest{MaxInFlight: "1"},
+			}
+
+			err := req.Validate()
+			Expect(err).To(HaveOccurred())
+			Expect(err.(tasks.ValidationError).Fields()).To(Equal(map[string]string{
+				"Tasks[1]": "must finish on its own (e.g. specify Timeout) when Execution is specified",
+			}))
+
+			req.Execution = nil
+			Expect(req.Validate()).ToNot(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,85 @@
+package incident
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/cppforlife/turbulence/director"
+	"github.com/cppforlife/turbulence/incident/selector"
+	"github.com/cppforlife/turbulence/tasks"
+)
+
+type InstanceCapabilities struct {
+	ID         string
+	Group      string
+	Deployment string
+	AgentID    string
+
+	Missing []string
+}
+
+// MissingCapabilitiesError is returned when agents of some selected instances
+// lack capabilities required by incident tasks (e.g. stress is not installed)
+type MissingCapabilitiesError struct {
+	Instances []InstanceCapabilities
+}
+
+func (e MissingCapabilitiesError) Error() string {
+	var msgs []string
+
+	for _, inst := range e.Instances {
+		msgs = append(msgs, fmt.Sprintf("%s/%s/%s (%s)",
+			inst.Deployment, inst.Group, inst.ID, strings.Join(inst.Missing, ", ")))
+	}
+
+	return fmt.Sprintf("Instances lack capabilities required by incident tasks: %s", strings.Join(msgs, "; "))
+}
+
+func requiredCapabilities(taskOpts tasks.OptionsSlice) []string {
+	var required []string
+
+	seen := map[string]struct{}{}
+
+	for _, opts := range taskOpts {
+		for _, capability := range tasks.RequiredCapabilities(opts) {
+			if _, found := seen[capability]; !found {
+				seen[capability] = struct{}{}
+				required = append(required, capability)
+			}
+		}
+	}
+
+	sort.Strings(required)
+
+	return required
+}
+
+func (r *repo) missingCapabilities(taskOpts tasks.OptionsSlice, instances []selector.Instance) []InstanceCapabilities {
+	var result []InstanceCapabilities
+
+	required := requiredCapabilities(taskOpts)
+
+	if len(required) == 0 {
+		return nil
+	}
+
+	for _, inst := range instances {
+		dirInst := inst.(director.Instance)
+
+		missing := r.fleetRepo.MissingCapabilities(dirInst.AgentID(), required)
+
+		if len(missing) > 0 {
+			result = append(result, InstanceCapabilities{
+				ID:         dirInst.ID(),
+				Group:      dirInst.Group(),
+				Deployment: dirInst.Deployment(),
+				AgentID:    dirInst.AgentID(),
+
+				Missing: missing,
+			})
+		}
+	}
+
+	return result
+}
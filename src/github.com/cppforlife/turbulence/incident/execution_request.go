@@ -0,0 +1,170 @@
+package incident
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	"github.com/cppforlife/turbulence/incident/selector"
+)
+
+const (
+	ExecutionOrderRandom = "random"
+	ExecutionOrderAZ     = "az"
+	ExecutionOrderGroup  = "group"
+)
+
+// ExecutionRequest configures how tasks are rolled out across selected instances.
+// Next batch starts only after all tasks of the previous batch finished
+// and specified interval has passed.
+type ExecutionRequest struct {
+	// Number or percentage of selected instances affected at the same time,
+	// e.g. 1, 30%; by default all instances in the same order group
+	MaxInFlight string `json:",omitempty"`
+
+	// Times may be suffixed with ms,s,m,h
+	IntervalBetweenBatches string `json:",omitempty"`
+
+	// One of random (default), az, group;
+	// batches never span multiple AZs or groups when ordered by them
+	Order string `json:",omitempty"`
+}
+
+func (r ExecutionRequest) Validate() error {
+	_, _, err := r.maxInFlight()
+	if err != nil {
+		return err
+	}
+
+	_, err = r.Interval()
+	if err != nil {
+		return err
+	}
+
+	_, err = r.orderFunc()
+	return err
+}
+
+// Batches splits instances into batches; given the same source of randomness
+// and the same set of instances it always produces the same batches.
+func (r ExecutionRequest) Batches(instances []selector.Instance, rnd *rand.Rand) ([][]selector.Instance, error) {
+	num, percent, err := r.maxInFlight()
+	if err != nil {
+		return nil, err
+	}
+
+	orderFunc, err := r.orderFunc()
+	if err != nil {
+		return nil, err
+	}
+
+	size := len(instances)
+
+	if num > 0 {
+		size = num
+		if percent {
+			size = int(math.Ceil(float64(num) / 100.0 * float64(len(instances))))
+		}
+		if size < 1 {
+			size = 1
+		}
+	}
+
+	var batches [][]selector.Instance
+
+	for _, group := range r.orderedGroups(instances, orderFunc, rnd) {
+		for len(group) > 0 {
+			n := size
+			if n > len(group) {
+				n = len(group)
+			}
+			batches = append(batches, group[0:n])
+			group = group[n:]
+		}
+	}
+
+	return batches, nil
+}
+
+func (r ExecutionRequest) orderedGroups(instances []selector.Instance, orderFunc func(selector.Instance) string, rnd *rand.Rand) [][]selector.Instance {
+	groupsMap := map[string][]selector.Instance{}
+	names := []string{}
+
+	for _, idx := range rnd.Perm(len(instances)) {
+		name := orderFunc(instances[idx])
+		if _, found := groupsMap[name]; !found {
+			names = append(names, name)
+		}
+		groupsMap[name] = append(groupsMap[name], instances[idx])
+	}
+
+	sort.Strings(names)
+
+	var groups [][]selector.Instance
+
+	for _, name := range names {
+		groups = append(groups, groupsMap[name])
+	}
+
+	return groups
+}
+
+func (r ExecutionRequest) maxInFlight() (int, bool, error) {
+	if len(r.MaxInFlight) == 0 {
+		return 0, false, nil
+	}
+
+	str := strings.TrimSuffix(r.MaxInFlight, "%")
+	percent := str != r.MaxInFlight
+
+	num, err := strconv.Atoi(str)
+	if err != nil || num < 1 {
+		return 0, false, bosherr.Errorf(
+			"Expected MaxInFlight '%s' to be a positive number or percentage", r.MaxInFlight)
+	}
+
+	if percent && num > 100 {
+		return 0, false, bosherr.Errorf("Expected MaxInFlight '%s' to be at most 100%%", r.MaxInFlight)
+	}
+
+	return num, percent, nil
+}
+
+func (r ExecutionRequest) Interval() (time.Duration, error) {
+	if len(r.IntervalBetweenBatches) == 0 {
+		return 0, nil
+	}
+
+	interval, err := time.ParseDuration(r.IntervalBetweenBatches)
+	if err != nil {
+		return 0, bosherr.WrapErrorf(err, "Parsing IntervalBetweenBatches")
+	}
+
+	if interval < 0 {
+		return 0, bosherr.Errorf("Expected IntervalBetweenBatches '%s' to not be negative", r.IntervalBetweenBatches)
+	}
+
+	return interval, nil
+}
+
+func (r ExecutionRequest) orderFunc() (func(selector.Instance) string, error) {
+	switch strings.ToLower(r.Order) {
+	case "", ExecutionOrderRandom:
+		return func(selector.Instance) string { return "" }, nil
+
+	case ExecutionOrderAZ:
+		return func(i selector.Instance) string { return i.AZ() }, nil
+
+	case ExecutionOrderGroup:
+		return func(i selector.Instance) string { return i.Deployment() + "/" + i.Group() }, nil
+
+	default:
+		return nil, bosherr.Errorf("Expected Order '%s' to be one of: %s, %s, %s",
+			r.Order, ExecutionOrderRandom, ExecutionOrderAZ, ExecutionOrderGroup)
+	}
+}
@@ -0,0 +1,105 @@
+package incident_test
+
+import (
+	"math/rand"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cppforlife/turbulence/incident"
+	"github.com/cppforlife/turbulence/incident/selector"
+	fakeselector "github.com/cppforlife/turbulence/incident/selector/fakes"
+)
+
+var _ = Describe("ExecutionRequest", func() {
+	var (
+		instances []selector.Instance
+	)
+
+	BeforeEach(func() {
+		instances = nil
+
+		for _, az := range []string{"z1", "z2", "z3"} {
+			for _, id := range []string{"id1", "id2", "id3", "id4"} {
+				instances = append(instances, fakeselector.SimpleInstance{InstanceID: az + "-" + id, GroupName: "group1", DeploymentName: "dep1", AZName: az})
+			}
+		}
+	})
+
+	batchSizes := func(batches [][]selector.Instance) []int {
+		var sizes []int
+		for _, batch := range batches {
+			sizes = append(sizes, len(batch))
+		}
+		return sizes
+	}
+
+	Describe("Batches", func() {
+		It("returns single batch with all instances by default", func() {
+			batches, err := ExecutionRequest{}.Batches(instances, rand.New(rand.NewSource(1)))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(batchSizes(batches)).To(Equal([]int{12}))
+			Expect(batches[0]).To(ConsistOf(instances))
+		})
+
+		It("splits instances by max in flight count", func() {
+			batches, err := ExecutionRequest{MaxInFlight: "5"}.Batches(instances, rand.New(rand.NewSource(1)))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(batchSizes(batches)).To(Equal([]int{5, 5, 2}))
+		})
+
+		It("splits instances by max in flight percentage rounding up", func() {
+			batches, err := ExecutionRequest{MaxInFlight: "30%"}.Batches(instances, rand.New(rand.NewSource(1)))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(batchSizes(batches)).To(Equal([]int{4, 4, 4}))
+		})
+
+		It("does not mix AZs in a batch when ordered by AZ", func() {
+			req := ExecutionRequest{MaxInFlight: "3", Order: "AZ"}
+
+			batches, err := req.Batches(instances, rand.New(rand.NewSource(1)))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(batchSizes(batches)).To(Equal([]int{3, 1, 3, 1, 3, 1}))
+
+			for i, batch := range batches {
+				for _, inst := range batch {
+					Expect(inst.AZ()).To(Equal([]string{"z1", "z2", "z3"}[i/2]))
+				}
+			}
+		})
+
+		It("returns the same batches given the same seed", func() {
+			req := ExecutionRequest{MaxInFlight: "1"}
+
+			first, err := req.Batches(instances, rand.New(rand.NewSource(42)))
+			Expect(err).ToNot(HaveOccurred())
+
+			second, err := req.Batches(instances, rand.New(rand.NewSource(42)))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(second).To(Equal(first))
+		})
+	})
+
+	Describe("Validate", func() {
+		It("accepts valid options", func() {
+			req := ExecutionRequest{MaxInFlight: "10%", IntervalBetweenBatches: "1m", Order: "group"}
+			Expect(req.Validate()).ToNot(HaveOccurred())
+		})
+
+		It("rejects invalid max in flight", func() {
+			for _, val := range []string{"0", "-1", "abc", "101%"} {
+				Expect(ExecutionRequest{MaxInFlight: val}.Validate()).To(HaveOccurred())
+			}
+		})
+
+		It("rejects invalid interval", func() {
+			Expect(ExecutionRequest{IntervalBetweenBatches: "1x"}.Validate()).To(HaveOccurred())
+		})
+
+		It("rejects unknown order", func() {
+			err := ExecutionRequest{Order: "deployment"}.Validate()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Expected Order 'deployment' to be one of: random, az, group"))
+		})
+	})
+})
@@ -7,8 +7,11 @@ import (
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 
 	"github.com/cppforlife/turbulence/director"
+	"github.com/cppforlife/turbulence/execution"
+	"github.com/cppforlife/turbulence/fleet"
 	"github.com/cppforlife/turbulence/incident/reporter"
 	"github.com/cppforlife/turbulence/incident/selector"
+	"github.com/cppforlife/turbulence/policy"
 	"github.com/cppforlife/turbulence/tasks"
 )
 
@@ -16,16 +19,26 @@ type Incident struct {
 	director   director.Director
 	reporter   reporter.Reporter
 	tasksRepo  tasks.Repo
+	fleetRepo  fleet.Repo
+	policy     policy.Policy
 	updateFunc func(Incident) error
 
 	id string
 
 	Tasks    tasks.OptionsSlice
 	Selector selector.Request
+	Seed     int64
+
+	Execution *ExecutionRequest
 
 	executionStartedAt   time.Time
 	executionCompletedAt time.Time
 
+	// Set when API server stopped before incident completed
+	interrupted bool
+
+	abortion *execution.Abortion
+
 	events *reporter.Events
 
 	logTag string
@@ -37,6 +50,7 @@ func (i Incident) Events() *reporter.Events { return i.events }
 
 func (i Incident) ExecutionStartedAt() time.Time   { return i.executionStartedAt }
 func (i Incident) ExecutionCompletedAt() time.Time { return i.executionCompletedAt }
+func (i Incident) Interrupted() bool               { return i.interrupted }
 
 func (i Incident) HasKillTask() bool {
 	for _, task := range i.Tasks {
@@ -47,18 +61,20 @@ func (i Incident) HasKillTask() bool {
 	return false
 }
 
-func (i Incident) TaskTypes() []string {
-	var types []string
+func (i Incident) TaskTypes() []string { return i.request().TaskTypes() }
 
-	for _, taskOpts := range i.Tasks {
-		types = append(types, tasks.OptionsType(taskOpts))
-	}
+func (i Incident) request() Request {
+	return Request{
+		Tasks:    i.Tasks,
+		Selector: i.Selector,
+		Seed:     i.Seed,
 
-	return types
+		Execution: i.Execution,
+	}
 }
 
 func (i Incident) ShortDescription() (string, error) {
-	b, err := json.Marshal(Request{i.Tasks, i.Selector})
+	b, err := json.Marshal(i.request())
 	if err != nil {
 		return "", err
 	}
@@ -67,7 +83,7 @@ func (i Incident) ShortDescription() (string, error) {
 }
 
 func (i Incident) Description() (string, error) {
-	b, err := json.MarshalIndent(Request{i.Tasks, i.Selector}, "", "    ")
+	b, err := json.MarshalIndent(i.request(), "", "    ")
 	if err != nil {
 		return "", err
 	}
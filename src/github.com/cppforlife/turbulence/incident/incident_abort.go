@@ -0,0 +1,63 @@
+package incident
+
+import (
+	"fmt"
+	"time"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	tubtasks "github.com/cppforlife/turbulence/tasks"
+)
+
+type AbortRequest struct {
+	// Defaults to the authenticated user
+	By string
+}
+
+type IncidentCompletedError struct {
+	ID string
+}
+
+func (e IncidentCompletedError) Error() string {
+	return fmt.Sprintf("Incident '%s' already completed", e.ID)
+}
+
+func (i Incident) AbortedAt() time.Time { return i.abortion.At() }
+func (i Incident) AbortedBy() string    { return i.abortion.By() }
+func (i Incident) IsAborted() bool      { return (i.AbortedAt() != time.Time{}) }
+
+// AbortCh is closed once incident is aborted
+func (i Incident) AbortCh() <-chan struct{} { return i.abortion.Ch() }
+
+func (i Incident) abort(by string) error {
+	if (i.executionCompletedAt != time.Time{}) {
+		return IncidentCompletedError{ID: i.id}
+	}
+
+	if !i.abortion.Abort(time.Now().UTC(), by) {
+		return nil // already aborted
+	}
+
+	i.logger.Info(i.logTag, "Aborting incident '%s' by '%s'", i.id, by)
+
+	var firstErr error
+
+	// Tasks that were not consumed yet are dequeued by QueueAndWait;
+	// tasks that are running are stopped by their agents
+	for _, event := range i.events.Events() {
+		if !event.IsAction() || len(event.ID) == 0 {
+			continue
+		}
+
+		if event.Type == tubtasks.OptionsType(tubtasks.KillOptions{}) {
+			continue
+		}
+
+		err := i.tasksRepo.UpdateState(event.ID, tubtasks.StateRequest{Stop: true})
+		if err != nil && firstErr == nil {
+			firstErr = bosherr.WrapErrorf(err, "Stopping task '%s'", event.ID)
+		}
+	}
+
+	return firstErr
+}
@@ -0,0 +1,187 @@
+package incident_test
+
+import (
+	"time"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cppforlife/turbulence/blackout"
+	"github.com/cppforlife/turbulence/director"
+	"github.com/cppforlife/turbulence/fleet"
+	. "github.com/cppforlife/turbulence/incident"
+	"github.com/cppforlife/turbulence/incident/reporter"
+	fakeselector "github.com/cppforlife/turbulence/incident/selector/fakes"
+	"github.com/cppforlife/turbulence/policy"
+	"github.com/cppforlife/turbulence/store"
+	"github.com/cppforlife/turbulence/tasks"
+)
+
+type fakeInstance struct {
+	fakeselector.SimpleInstance
+
+	deletingCh chan struct{}
+	deleteCh   chan struct{}
+}
+
+func (i fakeInstance) AgentID() string { return "agent-" + i.InstanceID }
+
+func (i fakeInstance) DeleteVM() error {
+	close(i.deletingCh)
+	<-i.deleteCh
+	return nil
+}
+
+type fakeDirector struct {
+	instances []director.Instance
+}
+
+func (d fakeDirector) AllInstances() ([]director.Instance, error) { return d.instances, nil }
+func (d fakeDirector) SubmitEvent(director.EventOpts) error       { return nil }
+
+// fakeFleet considers all agents alive and capable
+type fakeFleet struct {
+	fleet.Repo
+}
+
+func (fakeFleet) CheckAlive(string, time.Time) error            { return nil }
+func (fakeFleet) MissingCapabilities(string, []string) []string { return nil }
+
+// executingNotifier executes created incidents like the worker does
+type executingNotifier struct {
+	executedCh chan error
+}
+
+func (n executingNotifier) IncidentWasCreated(i Incident) { n.executedCh <- i.Execute() }
+
+var _ = Describe("Incident", func() {
+	Describe("abort", func() {
+		var (
+			tasksRepo tasks.Repo
+			instance  fakeInstance
+			notifier  executingNotifier
+			repo      Repo
+		)
+
+		BeforeEach(func() {
+			logger := boshlog.NewLogger(boshlog.LevelNone)
+
+			tasksRepo = tasks.NewRepo(logger)
+
+			instance = fakeInstance{
+				SimpleInstance: fakeselector.SimpleInstance{InstanceID: "1", GroupName: "g1", DeploymentName: "d1", AZName: "z1"},
+
+				deletingCh: make(chan struct{}),
+				deleteCh:   make(chan struct{}),
+			}
+
+			notifier = executingNotifier{executedCh: make(chan error, 1)}
+
+			var err error
+
+			repo, err = NewRepo(
+				boshuuid.NewGenerator(),
+				notifier,
+				reporter.NewLogger(logger),
+				fakeDirector{instances: []director.Instance{instance}},
+				tasksRepo,
+				fakeFleet{},
+				policy.NewPolicy(policy.Config{}, logger),
+				blackout.Calendar{},
+				store.NewMemoryJournal(),
+				logger,
+			)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		taskEvent := func(incident Incident, eventType string) *reporter.Event {
+			for _, event := range incident.Events().Events() {
+				if event.Type == eventType {
+					return event
+				}
+			}
+			return nil
+		}
+
+		It("dequeues tasks that were not consumed by the agent", func() {
+			incident, err := repo.Create(Request{
+				Tasks: tasks.OptionsSlice{tasks.PauseProcessOptions{ProcessName: "nats", Timeout: "1m"}},
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			Eventually(func() *reporter.Event {
+				return taskEvent(incident, "PauseProcess")
+			}).ShouldNot(BeNil())
+
+			_, err = repo.Abort(incident.ID(), AbortRequest{By: "fake-user"})
+			Expect(err).ToNot(HaveOccurred())
+
+			Eventually(notifier.executedCh, 5*time.Second).Should(Receive(HaveOccurred()))
+
+			incident, err = repo.Read(incident.ID())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(taskEvent(incident, "PauseProcess").Error).To(MatchError("Incident was aborted by 'fake-user'"))
+
+			queuedTasks, err := tasksRepo.Consume(instance.AgentID())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(queuedTasks).To(BeEmpty())
+		})
+
+		It("asks agent to stop tasks that it is running", func() {
+			incident, err := repo.Create(Request{
+				Tasks: tasks.OptionsSlice{tasks.PauseProcessOptions{ProcessName: "nats", Timeout: "1m"}},
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			runningTasks, _, err := tasksRepo.Poll(instance.AgentID(), nil, 5*time.Second, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(runningTasks).To(HaveLen(1))
+
+			taskID := runningTasks[0].ID
+
+			_, err = repo.Abort(incident.ID(), AbortRequest{By: "fake-user"})
+			Expect(err).ToNot(HaveOccurred())
+
+			_, stopTaskIDs, err := tasksRepo.Poll(instance.AgentID(), []string{taskID}, 5*time.Second, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(stopTaskIDs).To(Equal([]string{taskID}))
+
+			Expect(tasksRepo.Update(taskID, tasks.ResultRequest{})).ToNot(HaveOccurred())
+
+			Eventually(notifier.executedCh, 5*time.Second).Should(Receive(BeNil()))
+
+			incident, err = repo.Read(incident.ID())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(incident.AbortedBy()).To(Equal("fake-user"))
+		})
+
+		It("does not interrupt VM deletion that already started", func() {
+			incident, err := repo.Create(Request{Tasks: tasks.OptionsSlice{tasks.KillOptions{}}})
+			Expect(err).ToNot(HaveOccurred())
+
+			Eventually(instance.deletingCh, 5*time.Second).Should(BeClosed())
+
+			_, err = repo.Abort(incident.ID(), AbortRequest{By: "fake-user"})
+			Expect(err).ToNot(HaveOccurred())
+
+			close(instance.deleteCh)
+
+			Eventually(notifier.executedCh, 5*time.Second).Should(Receive(BeNil()))
+
+			incident, err = repo.Read(incident.ID())
+			Expect(err).ToNot(HaveOccurred())
+
+			event := taskEvent(incident, "Kill")
+			Expect(event.Error).ToNot(HaveOccurred())
+
+			state, err := tasksRepo.FetchState(event.ID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(state.Stop).To(BeFalse())
+
+			_, err = repo.Abort(incident.ID(), AbortRequest{By: "fake-user"})
+			Expect(err).To(Equal(IncidentCompletedError{ID: incident.ID()}))
+		})
+	})
+})
@@ -2,6 +2,9 @@ package incident
 
 import (
 	"errors"
+	"math/rand"
+	"strings"
+	"sync"
 	"time"
 
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
@@ -22,15 +25,42 @@ func (i Incident) Execute() error {
 	}
 
 	i.reporter.ReportIncidentExecutionStart(i)
-	i.logger.Debug(i.logTag, "Waiting for incident '%s' events completion", i.id)
+
+	resultsDoneCh := make(chan struct{})
+
+	// Results are consumed while tasks are executing
+	// since batches wait for results of previous batches
+	i.events.Hold()
+
+	go func() {
+		resultsCh := i.events.Results()
+
+		// Events are updated by other goroutines as well (e.g. batches),
+		// hence event fields are guarded by the events lock
+		for {
+			select {
+			case r, ok := <-resultsCh:
+				if !ok {
+					close(resultsDoneCh)
+					return
+				}
+
+				r.Event.MarkResult(r.Result, r.Error)
+				i.update()
+
+			case p := <-i.events.Progress():
+				p.Event.MarkProgress(p.Progress)
+				i.update()
+			}
+		}
+	}()
 
 	i.executeTasks()
+	i.events.Release()
 
-	// Serialize updates to the incident and events
-	for r := range i.events.Results() {
-		r.Event.MarkError(r.Error)
-		i.update()
-	}
+	i.logger.Debug(i.logTag, "Waiting for incident '%s' events completion", i.id)
+
+	<-resultsDoneCh
 
 	i.logger.Debug(i.logTag, "Incident '%s' events completed", i.id)
 
@@ -50,40 +80,113 @@ func (i Incident) executeTasks() {
 		return
 	}
 
-	var selectedInstances []selector.Instance
+	var allInstances []selector.Instance
 
 	for _, inst := range instances {
-		selectedInstances = append(selectedInstances, inst)
+		allInstances = append(allInstances, inst)
 	}
 
 	event = i.events.Add(reporter.Event{Type: reporter.EventTypeSelect})
-	selectedInstances, err = i.Selector.AsSelector().Select(selectedInstances)
+	selectedInstances, err := i.Selector.AsSeededSelector(i.Seed).Select(allInstances)
 	if event.MarkError(err) {
 		return
 	}
 
-	for _, inst := range selectedInstances {
+	// Set of instances may have changed since incident was created
+	event = i.events.Add(reporter.Event{Type: reporter.EventTypePolicy})
+	err = i.policy.Check(i.TaskTypes(), allInstances, selectedInstances)
+	if event.MarkError(err) {
+		return
+	}
+
+	if i.Execution == nil {
+		i.executeBatch(selectedInstances)
+		i.update()
+		return
+	}
+
+	interval, err := i.Execution.Interval()
+	if err != nil {
+		i.events.Add(reporter.Event{Type: reporter.EventTypeBatch}).MarkError(err)
+		return
+	}
+
+	batches, err := i.Execution.Batches(selectedInstances, rand.New(rand.NewSource(i.Seed)))
+	if err != nil {
+		i.events.Add(reporter.Event{Type: reporter.EventTypeBatch}).MarkError(err)
+		return
+	}
+
+	for idx, batch := range batches {
+		if idx > 0 && interval > 0 {
+			select {
+			case <-i.AbortCh():
+			case <-time.After(interval):
+			}
+		}
+
+		if i.IsAborted() {
+			i.logger.Debug(i.logTag, "Skipping remaining batches since incident '%s' was aborted", i.id)
+			break
+		}
+
+		i.logger.Debug(i.logTag, "Executing batch %d/%d of incident '%s'", idx+1, len(batches), i.id)
+
+		event := i.events.Add(reporter.Event{Type: reporter.EventTypeBatch})
+		i.update()
+
+		batchWg := i.executeBatch(batch)
+		batchDoneCh := make(chan struct{})
+
+		go func() {
+			batchWg.Wait()
+			close(batchDoneCh)
+		}()
+
+		select {
+		case <-batchDoneCh:
+			event.MarkError(nil)
+		case <-i.AbortCh():
+			event.MarkError(i.abortedErr())
+		}
+
+		i.update()
+	}
+}
+
+// executeBatch starts tasks against all given instances;
+// returned wait group is done once all of the tasks finish
+func (i Incident) executeBatch(instances []selector.Instance) *sync.WaitGroup {
+	wg := &sync.WaitGroup{}
+
+	for _, inst := range instances {
+		if i.IsAborted() {
+			i.logger.Debug(i.logTag, "Skipping remaining instances since incident '%s' was aborted", i.id)
+			break
+		}
+
 		eventTpl := reporter.Event{
 			Instance: reporter.EventInstance{
 				ID:         inst.ID(),
 				Group:      inst.Group(),
 				Deployment: inst.Deployment(),
 				AZ:         inst.AZ(),
+				IPs:        inst.IPs(),
 			},
 		}
 		// Ignore all other tasks if we are planning to kill the VM
 		// todo raise error if other tasks are provided?
 		if i.HasKillTask() {
-			i.killInstance(eventTpl, inst.(director.Instance))
+			i.killInstance(eventTpl, inst.(director.Instance), wg)
 		} else {
-			i.executeNonKillTasks(eventTpl, inst.(director.Instance))
+			i.executeNonKillTasks(eventTpl, inst.(director.Instance), wg)
 		}
 	}
 
-	i.update()
+	return wg
 }
 
-func (i Incident) executeNonKillTasks(eventTpl reporter.Event, instance director.Instance) {
+func (i Incident) executeNonKillTasks(eventTpl reporter.Event, instance director.Instance, wg *sync.WaitGroup) {
 	var tasks []tubtasks.Task
 	var events []*reporter.Event
 
@@ -97,6 +200,13 @@ func (i Incident) executeNonKillTasks(eventTpl reporter.Event, instance director
 			continue
 		}
 
+		missing := i.fleetRepo.MissingCapabilities(instance.AgentID(), tubtasks.RequiredCapabilities(taskOpts))
+		if len(missing) > 0 {
+			event.MarkError(bosherr.Errorf("Agent '%s' lacks capabilities: %s",
+				instance.AgentID(), strings.Join(missing, ", ")))
+			continue
+		}
+
 		task := tubtasks.Task{
 			ID:       event.ID,
 			Optionss: []tubtasks.Options{taskOpts}, // todo change to singular
@@ -106,41 +216,109 @@ func (i Incident) executeNonKillTasks(eventTpl reporter.Event, instance director
 		events = append(events, event)
 	}
 
+	wg.Add(len(events))
+
+	// Fail fast instead of waiting for agents that are not running
+	err := i.fleetRepo.CheckAlive(instance.AgentID(), time.Now())
+	if err != nil {
+		for _, event := range events {
+			wg.Done()
+			i.events.RegisterResult(reporter.EventResult{Event: event, Error: err})
+		}
+		return
+	}
+
 	go func() {
-		err := i.tasksRepo.QueueAndWait(instance.AgentID(), tasks)
+		err := i.tasksRepo.QueueAndWait(instance.AgentID(), tasks, i.AbortCh())
 		if err != nil {
 			i.logger.Error(i.logTag, "Failed to queue/wait for agent '%s': %s", instance.AgentID(), err.Error())
 
+			if i.IsAborted() {
+				err = i.abortedErr()
+			}
+
 			for _, event := range events {
-				i.events.RegisterResult(reporter.EventResult{event, err})
+				wg.Done()
+				i.events.RegisterResult(reporter.EventResult{Event: event, Error: err})
 			}
 
 			return
 		}
 
+		// Tasks may have been consumed after incident was aborted
+		if i.IsAborted() {
+			for _, event := range events {
+				err := i.tasksRepo.UpdateState(event.ID, tubtasks.StateRequest{Stop: true})
+				if err != nil {
+					i.logger.Error(i.logTag, "Failed to stop task '%s': %s", event.ID, err.Error())
+				}
+			}
+		}
+
 		for _, event := range events {
-			go func() {
+			go func(event *reporter.Event) {
+				i.followProgress(event)
+
 				req, err := i.tasksRepo.Wait(event.ID)
 				if err == nil && len(req.Error) > 0 {
-					err = errors.New(req.Error) // todo better error reporting?
+					err = errors.New(req.Error)
 				}
-				i.events.RegisterResult(reporter.EventResult{event, err})
-			}()
+				wg.Done()
+				i.events.RegisterResult(reporter.EventResult{Event: event, Result: req.Result, Error: err})
+			}(event)
 		}
 	}()
 }
 
-func (i Incident) killInstance(eventTpl reporter.Event, instance director.Instance) {
+// followProgress records progress reported by the agent until task finishes
+func (i Incident) followProgress(event *reporter.Event) {
+	var known int
+
+	for {
+		progress, finished, err := i.tasksRepo.WaitProgress(event.ID, known)
+		if err != nil {
+			i.logger.Error(i.logTag, "Failed to wait for task '%s' progress: %s", event.ID, err.Error())
+			return
+		}
+
+		if finished {
+			return
+		}
+
+		known = len(progress)
+
+		i.events.RegisterProgress(reporter.EventProgress{Event: event, Progress: progress})
+	}
+}
+
+func (i Incident) killInstance(eventTpl reporter.Event, instance director.Instance, wg *sync.WaitGroup) {
 	eventTpl.Type = tubtasks.OptionsType(tubtasks.KillOptions{})
 
 	event := i.events.Add(eventTpl)
 
+	wg.Add(1)
+
 	go func() {
-		err := instance.DeleteVM()
-		i.events.RegisterResult(reporter.EventResult{event, err})
+		var err error
+
+		// VM deletion cannot be interrupted hence abort only
+		// prevents deletion of VMs that were not deleted yet
+		select {
+		case <-i.AbortCh():
+			err = i.abortedErr()
+		default:
+			err = instance.DeleteVM()
+		}
+
+		wg.Done()
+		i.events.RegisterResult(reporter.EventResult{Event: event, Error: err})
 	}()
 }
 
+func (i Incident) abortedErr() error {
+	return bosherr.Errorf("Incident was aborted by '%s'", i.AbortedBy())
+}
+
 func (i Incident) update() {
 	err := i.updateFunc(i)
 	if err != nil {
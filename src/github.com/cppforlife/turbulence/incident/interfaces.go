@@ -3,7 +3,9 @@ package incident
 type Repo interface {
 	ListAll() ([]Incident, error)
 	Create(Request) (Incident, error)
+	Preview(Request) (Preview, error)
 	Read(string) (Incident, error)
+	Abort(string, AbortRequest) (Incident, error)
 }
 
 type RepoNotifier interface {
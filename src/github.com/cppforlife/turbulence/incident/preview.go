@@ -0,0 +1,156 @@
+package incident
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	"github.com/cppforlife/turbulence/director"
+	"github.com/cppforlife/turbulence/incident/selector"
+	"github.com/cppforlife/turbulence/tasks"
+)
+
+// Preview shows which instances would be affected by an incident
+// without queueing any tasks or deleting any VMs
+type Preview struct {
+	Tasks    tasks.OptionsSlice
+	Selector selector.Request
+
+	// Readable form of the selector including nested expressions
+	SelectorDescription string
+
+	// Seed can be included in the incident request
+	// to make the same selection when it's executed
+	Seed int64
+
+	Execution *ExecutionRequest `json:",omitempty"`
+
+	// Set when incident would be rejected by the API server policy
+	PolicyViolation string `json:",omitempty"`
+
+	Instances []PreviewInstance
+}
+
+type PreviewInstance struct {
+	ID         string
+	Group      string
+	Deployment string
+	AZ         string
+	IPs        []string
+	AgentID    string
+
+	// Capabilities required by tasks that agent does not have
+	MissingCapabilities []string `json:",omitempty"`
+
+	// Batch number starting with 1 when execution is staggered
+	Batch int `json:",omitempty"`
+}
+
+var (
+	seedRand     = rand.New(rand.NewSource(time.Now().UnixNano()))
+	seedRandLock sync.Mutex
+)
+
+func newSeed() int64 {
+	seedRandLock.Lock()
+	defer seedRandLock.Unlock()
+
+	for {
+		// Zero is used to indicate that seed was not specified
+		if seed := seedRand.Int63(); seed != 0 {
+			return seed
+		}
+	}
+}
+
+// selectInstances returns all instances and instances that would be affected
+func (r *repo) selectInstances(req selector.Request, seed int64) ([]selector.Instance, []selector.Instance, error) {
+	instances, err := r.director.AllInstances()
+	if err != nil {
+		return nil, nil, bosherr.WrapError(err, "Finding instances")
+	}
+
+	var allInstances []selector.Instance
+
+	for _, inst := range instances {
+		allInstances = append(allInstances, inst)
+	}
+
+	selectedInstances, err := req.AsSeededSelector(seed).Select(allInstances)
+	if err != nil {
+		return nil, nil, bosherr.WrapError(err, "Selecting instances")
+	}
+
+	return allInstances, selectedInstances, nil
+}
+
+func (r *repo) Preview(req Request) (Preview, error) {
+	preview := Preview{
+		Tasks:    req.Tasks,
+		Selector: req.Selector,
+		Seed:     req.Seed,
+
+		SelectorDescription: req.Selector.Description(),
+
+		Execution: req.Execution,
+
+		Instances: []PreviewInstance{},
+	}
+
+	if preview.Seed == 0 {
+		preview.Seed = newSeed()
+	}
+
+	err := req.Validate()
+	if err != nil {
+		return Preview{}, err
+	}
+
+	allInstances, selectedInstances, err := r.selectInstances(req.Selector, preview.Seed)
+	if err != nil {
+		return Preview{}, err
+	}
+
+	err = r.policy.Check(req.TaskTypes(), allInstances, selectedInstances)
+	if err != nil {
+		preview.PolicyViolation = err.Error()
+	}
+
+	batches := [][]selector.Instance{selectedInstances}
+
+	if req.Execution != nil {
+		batches, err = req.Execution.Batches(selectedInstances, rand.New(rand.NewSource(preview.Seed)))
+		if err != nil {
+			return Preview{}, bosherr.WrapError(err, "Splitting instances into batches")
+		}
+	}
+
+	required := requiredCapabilities(req.Tasks)
+
+	for idx, batch := range batches {
+		for _, inst := range batch {
+			dirInst := inst.(director.Instance)
+
+			previewInst := PreviewInstance{
+				ID:         dirInst.ID(),
+				Group:      dirInst.Group(),
+				Deployment: dirInst.Deployment(),
+				AZ:         dirInst.AZ(),
+				IPs:        dirInst.IPs(),
+				AgentID:    dirInst.AgentID(),
+
+				MissingCapabilities: r.fleetRepo.MissingCapabilities(dirInst.AgentID(), required),
+			}
+
+			if req.Execution != nil {
+				previewInst.Batch = idx + 1
+			}
+
+			preview.Instances = append(preview.Instances, previewInst)
+		}
+	}
+
+	return preview, nil
+}
@@ -0,0 +1,114 @@
+package incident
+
+import (
+	"errors"
+	"time"
+
+	"github.com/cppforlife/turbulence/execution"
+	"github.com/cppforlife/turbulence/incident/reporter"
+	"github.com/cppforlife/turbulence/incident/selector"
+	"github.com/cppforlife/turbulence/tasks"
+)
+
+// record is a persisted representation of an incident
+type record struct {
+	ID string
+
+	Tasks    tasks.OptionsSlice
+	Selector selector.Request
+	Seed     int64
+
+	Execution *ExecutionRequest `json:",omitempty"`
+
+	execution.Record
+
+	Events []eventRecord
+}
+
+type eventRecord struct {
+	ID   string
+	Type string
+
+	Instance reporter.EventInstance
+
+	ExecutionStartedAt   time.Time
+	ExecutionCompletedAt time.Time
+
+	Progress []tasks.Progress `json:",omitempty"`
+	Result   *tasks.Result    `json:",omitempty"`
+
+	Error string `json:",omitempty"`
+}
+
+func newRecord(i Incident) record {
+	rec := record{
+		ID: i.id,
+
+		Tasks:    i.Tasks,
+		Selector: i.Selector,
+		Seed:     i.Seed,
+
+		Execution: i.Execution,
+
+		Record: execution.Record{
+			ExecutionStartedAt:   i.executionStartedAt,
+			ExecutionCompletedAt: i.executionCompletedAt,
+
+			Interrupted: i.interrupted,
+
+			AbortedAt: i.AbortedAt(),
+			AbortedBy: i.AbortedBy(),
+		},
+	}
+
+	for _, ev := range i.events.Events() {
+		rec.Events = append(rec.Events, eventRecord{
+			ID:   ev.ID,
+			Type: ev.Type,
+
+			Instance: ev.Instance,
+
+			ExecutionStartedAt:   ev.ExecutionStartedAt,
+			ExecutionCompletedAt: ev.ExecutionCompletedAt,
+
+			Progress: ev.Progress,
+			Result:   ev.Result,
+
+			Error: ev.ErrorStr(),
+		})
+	}
+
+	return rec
+}
+
+// Interrupt marks incident and all its unfinished events as interrupted
+func (r *record) Interrupt() {
+	now := r.Record.Interrupt()
+
+	for i := range r.Events {
+		execution.InterruptEvent(&r.Events[i].ExecutionCompletedAt, &r.Events[i].Error, now)
+	}
+}
+
+func (r eventRecord) Event() reporter.Event {
+	var err error
+
+	if len(r.Error) > 0 {
+		err = errors.New(r.Error)
+	}
+
+	return reporter.Event{
+		ID:   r.ID,
+		Type: r.Type,
+
+		Instance: r.Instance,
+
+		ExecutionStartedAt:   r.ExecutionStartedAt,
+		ExecutionCompletedAt: r.ExecutionCompletedAt,
+
+		Progress: r.Progress,
+		Result:   r.Result,
+
+		Error: err,
+	}
+}
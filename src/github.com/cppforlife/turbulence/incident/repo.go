@@ -1,15 +1,22 @@
 package incident
 
 import (
+	"encoding/json"
 	"fmt"
 	"sync"
+	"time"
 
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
 
+	"github.com/cppforlife/turbulence/blackout"
 	"github.com/cppforlife/turbulence/director"
+	"github.com/cppforlife/turbulence/execution"
+	"github.com/cppforlife/turbulence/fleet"
 	"github.com/cppforlife/turbulence/incident/reporter"
+	"github.com/cppforlife/turbulence/policy"
+	"github.com/cppforlife/turbulence/store"
 	"github.com/cppforlife/turbulence/tasks"
 )
 
@@ -27,10 +34,15 @@ type repo struct {
 	reporter  reporter.Reporter
 	director  director.Director
 	tasksRepo tasks.Repo
+	fleetRepo fleet.Repo
+	policy    policy.Policy
+	calendar  blackout.Calendar
+	journal   store.Journal
 
 	incidents     []Incident
 	incidentsLock sync.RWMutex
 
+	logTag string
 	logger boshlog.Logger
 }
 
@@ -40,16 +52,33 @@ func NewRepo(
 	reporter reporter.Reporter,
 	director director.Director,
 	tasksRepo tasks.Repo,
+	fleetRepo fleet.Repo,
+	policy policy.Policy,
+	calendar blackout.Calendar,
+	journal store.Journal,
 	logger boshlog.Logger,
-) Repo {
-	return &repo{
+) (Repo, error) {
+	r := &repo{
 		uuidGen:   uuidGen,
 		notifier:  notifier,
 		reporter:  reporter,
 		director:  director,
 		tasksRepo: tasksRepo,
-		logger:    logger,
+		fleetRepo: fleetRepo,
+		policy:    policy,
+		calendar:  calendar,
+		journal:   journal,
+
+		logTag: "incident.repo",
+		logger: logger,
 	}
+
+	err := r.load()
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Loading incidents")
+	}
+
+	return r, nil
 }
 
 func (r *repo) ListAll() ([]Incident, error) {
@@ -63,26 +92,53 @@ func (r *repo) ListAll() ([]Incident, error) {
 }
 
 func (r *repo) Create(req Request) (Incident, error) {
+	err := req.Validate()
+	if err != nil {
+		return Incident{}, err
+	}
+
+	err = r.calendar.Check(time.Now())
+	if err != nil {
+		return Incident{}, err
+	}
+
 	id, err := r.uuidGen.Generate()
 	if err != nil {
 		return Incident{}, bosherr.WrapError(err, "Generating incident ID")
 	}
 
-	incident := Incident{
-		director:   r.director,
-		reporter:   r.reporter,
-		tasksRepo:  r.tasksRepo,
-		updateFunc: r.update,
+	incident := r.newIncident(id)
+	incident.Tasks = req.Tasks
+	incident.Selector = req.Selector
+	incident.Seed = req.Seed
+	incident.Execution = req.Execution
 
-		id: id,
+	if incident.Seed == 0 {
+		incident.Seed = newSeed()
+	}
 
-		Tasks:    req.Tasks,
-		Selector: req.Selector,
+	// Reject incident upfront since selection made with the same seed
+	// is expected to be the same when incident is executed
+	allInstances, selectedInstances, err := r.selectInstances(incident.Selector, incident.Seed)
+	if err != nil {
+		return Incident{}, err
+	}
 
-		events: reporter.NewEvents(r.uuidGen, r.reporter, id, r.logger),
+	err = r.policy.Check(incident.TaskTypes(), allInstances, selectedInstances)
+	if err != nil {
+		return Incident{}, err
+	}
 
-		logTag: "incident.Incident",
-		logger: r.logger,
+	if !req.AllowMissingCapabilities {
+		missing := r.missingCapabilities(incident.Tasks, selectedInstances)
+		if len(missing) > 0 {
+			return Incident{}, MissingCapabilitiesError{Instances: missing}
+		}
+	}
+
+	err = r.journal.Save(id, newRecord(incident))
+	if err != nil {
+		return Incident{}, bosherr.WrapError(err, "Saving incident")
 	}
 
 	r.incidentsLock.Lock()
@@ -108,6 +164,31 @@ func (r *repo) Read(id string) (Incident, error) {
 	return Incident{}, IncidentNotFoundError{ID: id}
 }
 
+func (r *repo) Abort(id string, req AbortRequest) (Incident, error) {
+	incident, err := r.Read(id)
+	if err != nil {
+		return Incident{}, err
+	}
+
+	err = incident.abort(req.By)
+	if err != nil {
+		return Incident{}, err
+	}
+
+	// Save latest copy since executing incident may have updated it
+	incident, err = r.Read(id)
+	if err != nil {
+		return Incident{}, err
+	}
+
+	err = r.journal.Save(incident.ID(), newRecord(incident))
+	if err != nil {
+		return Incident{}, bosherr.WrapError(err, "Saving aborted incident")
+	}
+
+	return incident, nil
+}
+
 func (r *repo) update(updatedIncident Incident) error {
 	r.incidentsLock.Lock()
 	defer r.incidentsLock.Unlock()
@@ -119,5 +200,77 @@ func (r *repo) update(updatedIncident Incident) error {
 		}
 	}
 
+	return r.journal.Save(updatedIncident.ID(), newRecord(updatedIncident))
+}
+
+func (r *repo) newIncident(id string) Incident {
+	return Incident{
+		director:   r.director,
+		reporter:   r.reporter,
+		tasksRepo:  r.tasksRepo,
+		fleetRepo:  r.fleetRepo,
+		policy:     r.policy,
+		updateFunc: r.update,
+
+		id: id,
+
+		abortion: execution.NewAbortion(),
+
+		events: reporter.NewEvents(r.uuidGen, r.reporter, id, r.logger),
+
+		logTag: "incident.Incident",
+		logger: r.logger,
+	}
+}
+
+// load restores previously saved incidents; incidents that were executing
+// when API server stopped are marked as interrupted since they cannot be resumed.
+func (r *repo) load() error {
+	entries, err := r.journal.Load()
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		var rec record
+
+		err := json.Unmarshal(entry.Record, &rec)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Unmarshalling incident '%s'", entry.ID)
+		}
+
+		if rec.IsRunning() {
+			r.logger.Info(r.logTag, "Marking incident '%s' as interrupted", rec.ID)
+
+			rec.Interrupt()
+
+			err = r.journal.Save(rec.ID, rec)
+			if err != nil {
+				return bosherr.WrapErrorf(err, "Saving interrupted incident '%s'", rec.ID)
+			}
+		}
+
+		incident := r.newIncident(rec.ID)
+		incident.Tasks = rec.Tasks
+		incident.Selector = rec.Selector
+		incident.Seed = rec.Seed
+		incident.Execution = rec.Execution
+		incident.executionStartedAt = rec.ExecutionStartedAt
+		incident.executionCompletedAt = rec.ExecutionCompletedAt
+		incident.interrupted = rec.Interrupted
+
+		if (rec.AbortedAt != time.Time{}) {
+			incident.abortion.Abort(rec.AbortedAt, rec.AbortedBy)
+		}
+
+		for _, evRec := range rec.Events {
+			incident.events.Restore(evRec.Event())
+		}
+
+		r.incidents = append(r.incidents, incident)
+	}
+
+	r.logger.Debug(r.logTag, "Loaded '%d' incidents", len(r.incidents))
+
 	return nil
 }
@@ -3,7 +3,10 @@ package reporter
 import (
 	"fmt"
 	"html/template"
+	"strings"
 	"time"
+
+	"github.com/cppforlife/turbulence/tasks"
 )
 
 type EventResponse struct {
@@ -17,6 +20,10 @@ type EventResponse struct {
 	ExecutionStartedAt   string
 	ExecutionCompletedAt string
 
+	// Only present for tasks executed by agents
+	Progress []EventProgressResp `json:",omitempty"`
+	Result   *EventResultResp    `json:",omitempty"`
+
 	Error string
 }
 
@@ -25,16 +32,29 @@ type EventInstanceResp struct {
 	Group      string
 	Deployment string
 	AZ         string
+	IPs        []string
 }
 
-func NewEventResponse(event *Event) EventResponse {
-	var completedAt string
+type EventProgressResp struct {
+	State string
+	At    string
+}
 
-	if (event.ExecutionCompletedAt != time.Time{}) {
-		completedAt = event.ExecutionCompletedAt.Format(time.RFC3339)
-	}
+type EventResultResp struct {
+	Actions     []string
+	ResolvedIPs map[string][]string
+	PIDs        []int
+
+	StartedAt  string
+	AppliedAt  string // empty if changes were not applied
+	RevertedAt string // empty if changes were not reverted
 
-	return EventResponse{
+	Reverted    bool
+	RevertError string
+}
+
+func NewEventResponse(event *Event) EventResponse {
+	resp := EventResponse{
 		event: event,
 
 		ID:   event.ID,
@@ -45,17 +65,55 @@ func NewEventResponse(event *Event) EventResponse {
 			Group:      event.Instance.Group,
 			Deployment: event.Instance.Deployment,
 			AZ:         event.Instance.AZ,
+			IPs:        event.Instance.IPs,
 		},
 
 		ExecutionStartedAt:   event.ExecutionStartedAt.Format(time.RFC3339),
-		ExecutionCompletedAt: completedAt,
+		ExecutionCompletedAt: formatOptionalTime(event.ExecutionCompletedAt),
 
 		Error: event.ErrorStr(),
 	}
+
+	for _, progress := range event.Progress {
+		resp.Progress = append(resp.Progress, EventProgressResp{
+			State: progress.State,
+			At:    progress.At.Format(time.RFC3339),
+		})
+	}
+
+	if event.Result != nil {
+		resp.Result = newEventResultResp(*event.Result)
+	}
+
+	return resp
+}
+
+func newEventResultResp(result tasks.Result) *EventResultResp {
+	return &EventResultResp{
+		Actions:     result.Actions,
+		ResolvedIPs: result.ResolvedIPs,
+		PIDs:        result.PIDs,
+
+		StartedAt:  formatOptionalTime(result.StartedAt),
+		AppliedAt:  formatOptionalTime(result.AppliedAt),
+		RevertedAt: formatOptionalTime(result.RevertedAt),
+
+		Reverted:    result.RevertSucceeded(),
+		RevertError: result.RevertError,
+	}
+}
+
+func formatOptionalTime(t time.Time) string {
+	if (t == time.Time{}) {
+		return ""
+	}
+	return t.Format(time.RFC3339)
 }
 
 func (r EventResponse) IsAction() bool { return r.event.IsAction() }
 
+func (r EventResponse) ProgressState() string { return r.event.ProgressState() }
+
 func (r EventResponse) DescriptionHTML() template.HTML {
 	content := ""
 
@@ -66,6 +124,10 @@ func (r EventResponse) DescriptionHTML() template.HTML {
 		if len(r.Instance.AZ) > 0 {
 			content += " <span>AZ</span> " + r.Instance.AZ
 		}
+
+		if len(r.Instance.IPs) > 0 {
+			content += " <span>IPs</span> " + strings.Join(r.Instance.IPs, ", ")
+		}
 	}
 
 	return template.HTML(content)
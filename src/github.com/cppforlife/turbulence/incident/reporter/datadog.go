@@ -161,6 +161,43 @@ func (r Datadog) ReportEventExecutionCompletion(incidentID string, e Event) {
 	}
 }
 
+func (r Datadog) ReportEmergencyStop(e EmergencyStop) {
+	alertType := "success"
+
+	if e.Active {
+		alertType = "error"
+	}
+
+	text := fmt.Sprintf("Reason: %s", e.Reason)
+
+	if len(e.AbortedIncidentIDs) > 0 {
+		text += fmt.Sprintf("\nAborted incidents: %s", strings.Join(e.AbortedIncidentIDs, ", "))
+	}
+
+	event := &datadog.Event{
+		Title: fmt.Sprintf("Emergency stop %s by '%s'", e.Action(), e.By),
+		Text:  text,
+		Time:  int(e.At.Unix()),
+
+		Priority:  "normal",
+		AlertType: alertType,
+
+		Host:        "turbulence-api",
+		Aggregation: "",
+		SourceType:  "turbulence-api",
+
+		Tags:     []string{"emergency-stop:" + e.Action()},
+		Resource: "",
+	}
+
+	event, err := r.client.PostEvent(event)
+	if err != nil {
+		r.logger.Error(r.logTag, "Failed to send emergency stop event: %s event=%#v", err.Error(), event)
+	} else {
+		r.logger.Debug(r.logTag, "Posted emergency stop datadog event '%d'", event.Id)
+	}
+}
+
 func (r Datadog) incidentTitle(prefix string, i Incident) string {
 	return fmt.Sprintf("%s incident '%s': %s", prefix, i.ID(), strings.Join(i.TaskTypes(), ", "))
 }
@@ -192,3 +229,50 @@ func (c DatadogConfig) Validate() error {
 
 	return nil
 }
+
+func (r Datadog) ReportAgentRecovery(rec AgentRecovery) {
+	alertType := "warning"
+
+	if rec.FailedChanges() > 0 {
+		alertType = "error"
+	}
+
+	var lines []string
+
+	for _, change := range rec.Changes {
+		line := fmt.Sprintf("Task %s: %s", change.TaskID, change.Description)
+		if len(change.Error) > 0 {
+			line += fmt.Sprintf(" (failed: %s)", change.Error)
+		}
+		lines = append(lines, line)
+	}
+
+	tags := []string{"agent:" + rec.AgentID}
+
+	if len(rec.Instance.Deployment) > 0 {
+		tags = append(tags, "deployment:"+rec.Instance.Deployment)
+	}
+
+	event := &datadog.Event{
+		Title: fmt.Sprintf("Agent '%s' undid %d leftover changes", rec.AgentID, len(rec.Changes)),
+		Text:  strings.Join(lines, "\n"),
+		Time:  int(rec.At.Unix()),
+
+		Priority:  "normal",
+		AlertType: alertType,
+
+		Host:        "turbulence-api",
+		Aggregation: "",
+		SourceType:  "turbulence-api",
+
+		Tags:     tags,
+		Resource: "",
+	}
+
+	event, err := r.client.PostEvent(event)
+	if err != nil {
+		r.logger.Error(r.logTag, "Failed to send agent recovery event: %s event=%#v", err.Error(), event)
+	} else {
+		r.logger.Debug(r.logTag, "Posted agent '%s' recovery datadog event '%d'", rec.AgentID, event.Id)
+	}
+}
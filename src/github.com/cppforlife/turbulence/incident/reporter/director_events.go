@@ -63,6 +63,7 @@ func (r DirectorEvents) ReportEventExecutionStart(incidentID string, e Event) {
 		Context: map[string]interface{}{
 			"type":        e.Type,
 			"incident_id": incidentID,
+			"ips":         e.Instance.IPs,
 		},
 	})
 	r.logErr(err)
@@ -91,6 +92,53 @@ func (r DirectorEvents) ReportEventExecutionCompletion(incidentID string, e Even
 	r.logErr(err)
 }
 
+func (r DirectorEvents) ReportEmergencyStop(e EmergencyStop) {
+	err := r.director.SubmitEvent(director.EventOpts{
+		Action:     e.Action(),
+		ObjectType: "turbulence-emergency-stop",
+		ObjectName: e.By,
+		Context: map[string]interface{}{
+			"reason":            e.Reason,
+			"aborted_incidents": e.AbortedIncidentIDs,
+		},
+	})
+	r.logErr(err)
+}
+
+func (r DirectorEvents) ReportAgentRecovery(rec AgentRecovery) {
+	var changes []map[string]string
+
+	for _, change := range rec.Changes {
+		changes = append(changes, map[string]string{
+			"task_id":     change.TaskID,
+			"description": change.Description,
+			"error":       change.Error,
+		})
+	}
+
+	errorStr := ""
+
+	if failed := rec.FailedChanges(); failed > 0 {
+		errorStr = fmt.Sprintf("Failed to undo %d of %d changes", failed, len(rec.Changes))
+	}
+
+	opts := director.EventOpts{
+		Action:     "recover",
+		ObjectType: "turbulence-agent",
+		ObjectName: rec.AgentID,
+		Deployment: rec.Instance.Deployment,
+		Context:    map[string]interface{}{"changes": changes},
+		Error:      errorStr,
+	}
+
+	if len(rec.Instance.ID) > 0 {
+		opts.Instance = fmt.Sprintf("%s/%s", rec.Instance.Group, rec.Instance.ID)
+	}
+
+	err := r.director.SubmitEvent(opts)
+	r.logErr(err)
+}
+
 func (r DirectorEvents) logErr(err error) {
 	if err != nil {
 		r.logger.Error(r.logTag, "Failed submitting event: %s", err)
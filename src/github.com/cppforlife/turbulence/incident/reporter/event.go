@@ -3,11 +3,15 @@ package reporter
 import (
 	"sync"
 	"time"
+
+	"github.com/cppforlife/turbulence/tasks"
 )
 
 const (
 	EventTypeFind   = "Find"
 	EventTypeSelect = "Select"
+	EventTypeBatch  = "Batch"
+	EventTypePolicy = "Policy"
 )
 
 type Event struct {
@@ -16,6 +20,10 @@ type Event struct {
 
 	resultsWg *sync.WaitGroup
 
+	// Shared with all events of the incident; guards fields below
+	// since events are updated while incident is being recorded
+	lock *sync.RWMutex
+
 	ID   string // may be empty
 	Type string
 
@@ -24,6 +32,12 @@ type Event struct {
 	ExecutionStartedAt   time.Time
 	ExecutionCompletedAt time.Time
 
+	// Reported by agents while they execute the task
+	Progress []tasks.Progress
+
+	// Reported by agents that executed the task; nil otherwise
+	Result *tasks.Result
+
 	Error error
 }
 
@@ -32,10 +46,20 @@ type EventInstance struct {
 	Group      string
 	Deployment string
 	AZ         string
+	IPs        []string
 }
 
 func (e *Event) IsAction() bool {
-	return e.Type != EventTypeFind && e.Type != EventTypeSelect
+	return e.Type != EventTypeFind && e.Type != EventTypeSelect &&
+		e.Type != EventTypeBatch && e.Type != EventTypePolicy
+}
+
+// ProgressState returns the latest state reported by the agent
+func (e *Event) ProgressState() string {
+	if len(e.Progress) == 0 {
+		return ""
+	}
+	return e.Progress[len(e.Progress)-1].State
 }
 
 func (e *Event) ErrorStr() string {
@@ -46,13 +70,35 @@ func (e *Event) ErrorStr() string {
 }
 
 func (e *Event) MarkError(err error) bool {
+	e.lock.Lock()
 	e.Error = err
 	e.ExecutionCompletedAt = time.Now().UTC()
+	event := *e
+	e.lock.Unlock()
 
 	// Call it done after updating event
 	e.resultsWg.Done()
 
-	e.reporter.ReportEventExecutionCompletion(e.incidentID, *e)
+	e.reporter.ReportEventExecutionCompletion(e.incidentID, event)
 
 	return err != nil
 }
+
+// MarkResult completes event with the result reported by the agent
+func (e *Event) MarkResult(result *tasks.Result, err error) bool {
+	e.lock.Lock()
+	if result != nil {
+		e.Progress = result.Progress
+	}
+	e.Result = result
+	e.lock.Unlock()
+
+	return e.MarkError(err)
+}
+
+func (e *Event) MarkProgress(progress []tasks.Progress) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	e.Progress = progress
+}
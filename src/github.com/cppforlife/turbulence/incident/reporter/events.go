@@ -6,6 +6,8 @@ import (
 
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
+
+	"github.com/cppforlife/turbulence/tasks"
 )
 
 type Events struct {
@@ -16,14 +18,23 @@ type Events struct {
 	resultsWg sync.WaitGroup
 	resultsCh chan EventResult
 
-	events []*Event
+	progressCh chan EventProgress
+
+	events     []*Event
+	eventsLock sync.RWMutex
 
 	logger boshlog.Logger
 }
 
 type EventResult struct {
-	Event *Event
-	Error error
+	Event  *Event
+	Result *tasks.Result
+	Error  error
+}
+
+type EventProgress struct {
+	Event    *Event
+	Progress []tasks.Progress
 }
 
 func NewEvents(uuidGen boshuuid.Generator, reporter Reporter, incidentID string, logger boshlog.Logger) *Events {
@@ -32,6 +43,7 @@ func NewEvents(uuidGen boshuuid.Generator, reporter Reporter, incidentID string,
 		reporter:   reporter,
 		incidentID: incidentID,
 		resultsCh:  make(chan EventResult),
+		progressCh: make(chan EventProgress),
 		logger:     logger,
 	}
 }
@@ -40,6 +52,15 @@ func (e *Events) RegisterResult(r EventResult) {
 	e.resultsCh <- r
 }
 
+// RegisterProgress must be called before result of the event is registered
+func (e *Events) RegisterProgress(p EventProgress) {
+	e.progressCh <- p
+}
+
+func (e *Events) Progress() chan EventProgress {
+	return e.progressCh
+}
+
 func (e *Events) Results() chan EventResult {
 	go func() {
 		e.resultsWg.Wait()
@@ -49,13 +70,17 @@ func (e *Events) Results() chan EventResult {
 	return e.resultsCh
 }
 
-func (e *Events) Add(event Event) *Event {
-	e.events = append(e.events, &event)
+// Hold keeps results channel open until Release is called
+// so that events can be added while results are being consumed
+func (e *Events) Hold()    { e.resultsWg.Add(1) }
+func (e *Events) Release() { e.resultsWg.Done() }
 
+func (e *Events) Add(event Event) *Event {
 	e.resultsWg.Add(1)
 
 	event.resultsWg = &e.resultsWg
 	event.reporter = e.reporter
+	event.lock = &e.eventsLock
 
 	id, err := e.uuidGen.Generate()
 	if err != nil {
@@ -67,21 +92,53 @@ func (e *Events) Add(event Event) *Event {
 	event.incidentID = e.incidentID
 	event.ExecutionStartedAt = time.Now().UTC()
 
+	// Event is only visible to others once it's fully built
+	e.eventsLock.Lock()
+	e.events = append(e.events, &event)
+	e.eventsLock.Unlock()
+
 	e.reporter.ReportEventExecutionStart(e.incidentID, event)
 
 	return &event
 }
 
+// Restore adds previously recorded event without reporting it
+// or waiting for its result since it's not going to be executed again.
+func (e *Events) Restore(event Event) *Event {
+	event.resultsWg = &e.resultsWg
+	event.reporter = e.reporter
+	event.lock = &e.eventsLock
+	event.incidentID = e.incidentID
+
+	e.eventsLock.Lock()
+	e.events = append(e.events, &event)
+	e.eventsLock.Unlock()
+
+	return &event
+}
+
+// Events returns copies of events since they may be
+// concurrently updated as tasks report their results
 func (e *Events) Events() []*Event {
 	if e == nil {
 		return nil
 	}
 
-	return e.events
+	e.eventsLock.RLock()
+	defer e.eventsLock.RUnlock()
+
+	var events []*Event
+
+	for _, ev := range e.events {
+		event := *ev
+		events = append(events, &event)
+	}
+
+	return events
 }
 
 func (e *Events) FirstError() error {
-	for _, ev := range e.events {
+	for _, ev := range e.Events() {
 		if ev.Error != nil {
 			return ev.Error
 		}
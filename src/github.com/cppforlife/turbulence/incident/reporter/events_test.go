@@ -0,0 +1,137 @@
+package reporter_test
+
+import (
+	"errors"
+	"sync"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cppforlife/turbulence/incident/reporter"
+	"github.com/cppforlife/turbulence/tasks"
+)
+
+var _ = Describe("Events", func() {
+	var (
+		events *reporter.Events
+	)
+
+	BeforeEach(func() {
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		events = reporter.NewEvents(boshuuid.NewGenerator(), reporter.NewLogger(logger), "incident1", logger)
+	})
+
+	// Run with -race to catch unguarded access to event fields
+	It("allows events to be updated while they are being added and read", func() {
+		events.Hold()
+
+		consumedCh := make(chan struct{})
+
+		go func() {
+			resultsCh := events.Results()
+
+			for {
+				select {
+				case r, ok := <-resultsCh:
+					if !ok {
+						close(consumedCh)
+						return
+					}
+					r.Event.MarkResult(r.Result, r.Error)
+
+				case p := <-events.Progress():
+					p.Event.MarkProgress(p.Progress)
+				}
+			}
+		}()
+
+		readerDoneCh := make(chan struct{})
+		readerStopCh := make(chan struct{})
+
+		go func() {
+			defer close(readerDoneCh)
+
+			for {
+				select {
+				case <-readerStopCh:
+					return
+				default:
+					for _, ev := range events.Events() {
+						_ = ev.ProgressState()
+						_ = ev.ErrorStr()
+						_ = ev.Result
+						_ = ev.ExecutionCompletedAt
+					}
+				}
+			}
+		}()
+
+		wg := &sync.WaitGroup{}
+
+		for i := 0; i < 20; i++ {
+			batch := events.Add(reporter.Event{Type: reporter.EventTypeBatch})
+			task := events.Add(reporter.Event{Type: "Noop"})
+
+			wg.Add(1)
+
+			go func(task *reporter.Event) {
+				defer wg.Done()
+
+				events.RegisterProgress(reporter.EventProgress{
+					Event:    task,
+					Progress: []tasks.Progress{{State: tasks.ProgressApplying}},
+				})
+
+				events.RegisterResult(reporter.EventResult{
+					Event:  task,
+					Result: &tasks.Result{Progress: []tasks.Progress{{State: tasks.ProgressReverted}}},
+					Error:  errors.New("task-err"),
+				})
+			}(task)
+
+			batch.MarkError(nil)
+		}
+
+		wg.Wait()
+		events.Release()
+		<-consumedCh
+
+		close(readerStopCh)
+		<-readerDoneCh
+
+		all := events.Events()
+		Expect(all).To(HaveLen(40))
+
+		for _, ev := range all {
+			Expect(ev.ID).ToNot(BeEmpty())
+			Expect(ev.ExecutionCompletedAt.IsZero()).To(BeFalse())
+
+			if ev.Type == reporter.EventTypeBatch {
+				Expect(ev.Error).To(BeNil())
+			} else {
+				Expect(ev.ProgressState()).To(Equal(tasks.ProgressReverted))
+				Expect(ev.Error).To(MatchError("task-err"))
+			}
+		}
+
+		Expect(events.FirstError()).To(MatchError("task-err"))
+	})
+
+	It("returns copies of events", func() {
+		events.Hold()
+		go func() {
+			for range events.Results() {
+			}
+		}()
+
+		event := events.Add(reporter.Event{Type: reporter.EventTypeFind})
+		Expect(events.Events()[0].ExecutionCompletedAt.IsZero()).To(BeTrue())
+
+		event.MarkError(nil)
+		Expect(events.Events()[0].ExecutionCompletedAt.IsZero()).To(BeFalse())
+
+		events.Release()
+	})
+})
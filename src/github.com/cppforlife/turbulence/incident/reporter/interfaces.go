@@ -22,6 +22,55 @@ type Reporter interface {
 
 	ReportEventExecutionStart(string, Event)
 	ReportEventExecutionCompletion(string, Event)
+
+	ReportEmergencyStop(EmergencyStop)
+	ReportAgentRecovery(AgentRecovery)
+}
+
+// EmergencyStop is reported when emergency stop is activated or cleared
+type EmergencyStop struct {
+	Active bool
+
+	At     time.Time
+	By     string
+	Reason string
+
+	AbortedIncidentIDs []string
+}
+
+func (e EmergencyStop) Action() string {
+	if e.Active {
+		return "activate"
+	}
+	return "clear"
+}
+
+// AgentRecovery is reported when restarted agent undoes changes
+// left behind by tasks that were running when it stopped
+type AgentRecovery struct {
+	AgentID  string
+	Instance EventInstance // may be empty
+
+	At      time.Time
+	Changes []AgentRecoveryChange
+}
+
+type AgentRecoveryChange struct {
+	TaskID      string
+	Description string
+	Error       string
+}
+
+func (r AgentRecovery) FailedChanges() int {
+	var failed int
+
+	for _, change := range r.Changes {
+		if len(change.Error) > 0 {
+			failed++
+		}
+	}
+
+	return failed
 }
 
 var _ Reporter = Multi{}
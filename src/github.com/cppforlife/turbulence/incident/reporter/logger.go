@@ -53,10 +53,22 @@ func (r Logger) ReportEventExecutionCompletion(incidentID string, e Event) {
 	r.logger.Debug(r.logTag, "%s error='%s'", r.eventDesc("completed", e), errorStr)
 }
 
+func (r Logger) ReportEmergencyStop(e EmergencyStop) {
+	r.logger.Info(r.logTag, "emergency-stop action='%s' by='%s' reason='%s' aborted-incidents='%s'",
+		e.Action(), e.By, e.Reason, strings.Join(e.AbortedIncidentIDs, ","))
+}
+
+func (r Logger) ReportAgentRecovery(rec AgentRecovery) {
+	for _, change := range rec.Changes {
+		r.logger.Info(r.logTag, "agent-recovery agent='%s' deployment='%s' instance='%s/%s' task='%s' change='%s' error='%s'",
+			rec.AgentID, rec.Instance.Deployment, rec.Instance.Group, rec.Instance.ID, change.TaskID, change.Description, change.Error)
+	}
+}
+
 func (r Logger) incidentDesc(prefix string, i Incident) string {
-	return fmt.Sprintf("%s incident='%s' types='%s'", prefix, i.ID, strings.Join(i.TaskTypes(), ","))
+	return fmt.Sprintf("%s incident='%s' types='%s'", prefix, i.ID(), strings.Join(i.TaskTypes(), ","))
 }
 
 func (r Logger) eventDesc(prefix string, e Event) string {
-	return fmt.Sprintf("%s event='%s' type='%s' deployment='%s' instance='%s/%s'", prefix, e.ID, e.Type, e.Instance.Deployment, e.Instance.Group, e.Instance.ID)
+	return fmt.Sprintf("%s event='%s' type='%s' deployment='%s' instance='%s/%s' ips='%s'", prefix, e.ID, e.Type, e.Instance.Deployment, e.Instance.Group, e.Instance.ID, strings.Join(e.Instance.IPs, ","))
 }
@@ -31,3 +31,15 @@ func (r Multi) ReportEventExecutionCompletion(incidentID string, e Event) {
 		rep.ReportEventExecutionCompletion(incidentID, e)
 	}
 }
+
+func (r Multi) ReportEmergencyStop(e EmergencyStop) {
+	for _, rep := range r.reps {
+		rep.ReportEmergencyStop(e)
+	}
+}
+
+func (r Multi) ReportAgentRecovery(rec AgentRecovery) {
+	for _, rep := range r.reps {
+		rep.ReportAgentRecovery(rec)
+	}
+}
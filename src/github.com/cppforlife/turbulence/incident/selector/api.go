@@ -1,5 +1,12 @@
 package selector
 
+import (
+	"fmt"
+	"math/rand"
+	"net"
+	"strconv"
+)
+
 type Request struct {
 	IncludeMissing bool `json:",omitempty"`
 
@@ -7,6 +14,37 @@ type Request struct {
 	Deployment *NameRequest `json:",omitempty"`
 	Group      *NameRequest `json:",omitempty"`
 	ID         *IDRequest   `json:",omitempty"`
+
+	// Select only instances in any of the states (e.g. started)
+	// or with any of the process states (e.g. running, failing)
+	State        *ValuesRequest `json:",omitempty"`
+	ProcessState *ValuesRequest `json:",omitempty"`
+
+	// Select only instances that are (or are not when false)
+	// bootstrap instances of their groups or that have persistent disks
+	Bootstrap      *bool `json:",omitempty"`
+	PersistentDisk *bool `json:",omitempty"`
+
+	// Select only instances that have at least one
+	// IP address or network matching any of the values
+	IP      *ValuesRequest `json:",omitempty"`
+	CIDR    *ValuesRequest `json:",omitempty"`
+	Network *ValuesRequest `json:",omitempty"`
+
+	// Select only instances that run at least one release job
+	// (per deployment manifest) or monit process matching any of the values
+	Job     *ValuesRequest `json:",omitempty"`
+	Process *ValuesRequest `json:",omitempty"`
+
+	// Expressions narrow down instances selected by rules above
+	// and are applied in the following order
+	Not   *Request  `json:",omitempty"`
+	AnyOf []Request `json:",omitempty"`
+	AllOf []Request `json:",omitempty"`
+
+	// Picks instances with regard to their AZs out of instances
+	// selected by all of the rules and expressions above
+	Topology *TopologyRequest `json:",omitempty"`
 }
 
 type NameRequest struct {
@@ -19,38 +57,213 @@ type IDRequest struct {
 	Limit  Limit    `json:",omitempty"`
 }
 
-// todo State
-// todo ProcessState
-// todo PersistentDisk
-// todo Bootstrap
+type TopologyRequest struct {
+	Strategy string // spread or concentrate
+	Limit    Limit  `json:",omitempty"`
+
+	MaxPerAZ         int `json:",omitempty"`
+	MaxPerGroupPerAZ int `json:",omitempty"`
+}
+
+// ValuesRequest matches any of the values with wildcard support
+// (except for CIDRs, e.g. 10.0.16.0/20)
+type ValuesRequest struct {
+	Values []string
+}
+
+func (a Request) Validate() error {
+	return a.validate("", false)
+}
+
+func (a Request) validate(path string, nested bool) error {
+	if nested && a.IncludeMissing {
+		return fmt.Errorf("%sIncludeMissing can only be set on the top level selector", path)
+	}
+
+	values := map[string]*ValuesRequest{
+		"State": a.State, "ProcessState": a.ProcessState,
+		"IP": a.IP, "CIDR": a.CIDR, "Network": a.Network, "Job": a.Job, "Process": a.Process}
+
+	for _, name := range []string{"State", "ProcessState", "IP", "CIDR", "Network", "Job", "Process"} {
+		if req := values[name]; req != nil && len(req.Values) == 0 {
+			return fmt.Errorf("%s%s must include at least one value", path, name)
+		}
+	}
+
+	if a.CIDR != nil {
+		for _, val := range a.CIDR.Values {
+			_, _, err := net.ParseCIDR(val)
+			if err != nil {
+				return fmt.Errorf("%sCIDR value '%s' is not valid: %s", path, val, err)
+			}
+		}
+	}
+
+	if a.Topology != nil {
+		err := a.Topology.validate(path + "Topology.")
+		if err != nil {
+			return err
+		}
+	}
+
+	if a.Not != nil {
+		err := a.Not.validate(path+"Not.", true)
+		if err != nil {
+			return err
+		}
+	}
+
+	expressions := map[string][]Request{"AnyOf": a.AnyOf, "AllOf": a.AllOf}
+
+	for _, name := range []string{"AnyOf", "AllOf"} {
+		reqs := expressions[name]
+
+		if reqs != nil && len(reqs) == 0 {
+			return fmt.Errorf("%s%s must include at least one selector", path, name)
+		}
+
+		for i, req := range reqs {
+			err := req.validate(fmt.Sprintf("%s%s[%d].", path, name, i), true)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (a TopologyRequest) validate(path string) error {
+	if a.Strategy != TopologySpread && a.Strategy != TopologyConcentrate {
+		return fmt.Errorf("%sStrategy must be one of '%s', '%s'", path, TopologySpread, TopologyConcentrate)
+	}
+
+	if a.MaxPerAZ < 0 {
+		return fmt.Errorf("%sMaxPerAZ cannot be negative", path)
+	}
+
+	if a.MaxPerGroupPerAZ < 0 {
+		return fmt.Errorf("%sMaxPerGroupPerAZ cannot be negative", path)
+	}
+
+	return nil
+}
 
 func (a Request) AsSelector() Selector {
+	return a.asSelector(nil, false)
+}
+
+// AsSeededSelector returns selector that makes the same selection
+// given the same seed and the same set of instances
+func (a Request) AsSeededSelector(seed int64) Selector {
+	return a.asSelector(rand.New(rand.NewSource(seed)), false)
+}
+
+func (a Request) asSelector(rnd *rand.Rand, nested bool) Selector {
 	selectors := []Selector{}
 
-	// By default we avoid running any tasks against instances without VMs
-	if !a.IncludeMissing {
+	// By default we avoid running any tasks against instances without VMs;
+	// nested selectors pick from instances that were already filtered
+	if !a.IncludeMissing && !nested {
 		f := func(i Instance) (bool, error) { return i.HasVM(), nil }
 		selectors = append(selectors, ByFilter{f})
 	}
 
+	// Rules without limits come first so that limits apply to matching instances
+	if a.State != nil {
+		f := func(i Instance) string { return i.State() }
+		selectors = append(selectors, NewByNames(a.State.Values, f))
+	}
+
+	if a.ProcessState != nil {
+		f := func(i Instance) string { return i.ProcessState() }
+		selectors = append(selectors, NewByNames(a.ProcessState.Values, f))
+	}
+
+	if a.Bootstrap != nil {
+		f := func(i Instance) string { return strconv.FormatBool(i.Bootstrap()) }
+		selectors = append(selectors, NewByNames([]string{strconv.FormatBool(*a.Bootstrap)}, f))
+	}
+
+	if a.PersistentDisk != nil {
+		f := func(i Instance) string { return strconv.FormatBool(i.PersistentDisk()) }
+		selectors = append(selectors, NewByNames([]string{strconv.FormatBool(*a.PersistentDisk)}, f))
+	}
+
+	if a.IP != nil {
+		f := func(i Instance) []string { return i.IPs() }
+		selectors = append(selectors, NewByAnyNames(a.IP.Values, f))
+	}
+
+	if a.CIDR != nil {
+		f := func(i Instance) []string { return i.IPs() }
+		selectors = append(selectors, NewByCIDRs(a.CIDR.Values, f))
+	}
+
+	if a.Network != nil {
+		f := func(i Instance) []string { return i.Networks() }
+		selectors = append(selectors, NewByAnyNames(a.Network.Values, f))
+	}
+
+	if a.Job != nil {
+		f := func(i Instance) []string { return i.Jobs() }
+		selectors = append(selectors, NewByAnyNames(a.Job.Values, f))
+	}
+
+	if a.Process != nil {
+		f := func(i Instance) []string { return i.Processes() }
+		selectors = append(selectors, NewByAnyNames(a.Process.Values, f))
+	}
+
 	if a.AZ != nil {
 		f := func(i Instance) string { return i.AZ() }
-		selectors = append(selectors, Generic{[]string{a.AZ.Name}, a.AZ.Limit, f})
+		selectors = append(selectors, Generic{[]string{a.AZ.Name}, a.AZ.Limit, f, rnd})
 	}
 
 	if a.Deployment != nil {
 		f := func(i Instance) string { return i.Deployment() }
-		selectors = append(selectors, Generic{[]string{a.Deployment.Name}, a.Deployment.Limit, f})
+		selectors = append(selectors, Generic{[]string{a.Deployment.Name}, a.Deployment.Limit, f, rnd})
 	}
 
 	if a.Group != nil {
 		f := func(i Instance) string { return i.Group() }
-		selectors = append(selectors, Generic{[]string{a.Group.Name}, a.Group.Limit, f})
+		selectors = append(selectors, Generic{[]string{a.Group.Name}, a.Group.Limit, f, rnd})
 	}
 
 	if a.ID != nil {
 		f := func(i Instance) string { return i.ID() }
-		selectors = append(selectors, Generic{a.ID.Values, a.ID.Limit, f})
+		selectors = append(selectors, Generic{a.ID.Values, a.ID.Limit, f, rnd})
+	}
+
+	if a.Not != nil {
+		selectors = append(selectors, Except{a.Not.asSelector(rnd, true)})
+	}
+
+	if len(a.AnyOf) > 0 {
+		var anyOf []Selector
+
+		for _, req := range a.AnyOf {
+			anyOf = append(anyOf, req.asSelector(rnd, true))
+		}
+
+		selectors = append(selectors, AnyOf{anyOf})
+	}
+
+	// Each selector picks from instances selected by the previous one
+	for _, req := range a.AllOf {
+		selectors = append(selectors, req.asSelector(rnd, true))
+	}
+
+	if a.Topology != nil {
+		selectors = append(selectors, Topology{
+			Strategy: a.Topology.Strategy,
+			Limit:    a.Topology.Limit,
+
+			MaxPerAZ:         a.Topology.MaxPerAZ,
+			MaxPerGroupPerAZ: a.Topology.MaxPerGroupPerAZ,
+
+			Rand: rnd,
+		})
 	}
 
 	return Multiple{selectors}
@@ -7,19 +7,9 @@ import (
 	. "github.com/onsi/gomega"
 
 	. "github.com/cppforlife/turbulence/incident/selector"
+	fakeselector "github.com/cppforlife/turbulence/incident/selector/fakes"
 )
 
-type SimpleInstance struct {
-	id, group, deployment, az string
-	missingVM                 bool
-}
-
-func (i SimpleInstance) ID() string         { return i.id }
-func (i SimpleInstance) Group() string      { return i.group }
-func (i SimpleInstance) Deployment() string { return i.deployment }
-func (i SimpleInstance) AZ() string         { return i.az }
-func (i SimpleInstance) HasVM() bool        { return !i.missingVM }
-
 var _ = Describe("Limit", func() {
 	Describe("Limit", func() {
 		It("does basic selection", func() {
@@ -42,13 +32,13 @@ var _ = Describe("Limit", func() {
 			Expect(err).ToNot(HaveOccurred())
 
 			in := []Instance{
-				SimpleInstance{id: "id1-missing-vm", group: "group1", deployment: "dep1", az: "az1", missingVM: true},
-				SimpleInstance{id: "id1", group: "group1", deployment: "dep1", az: "az1"},
-				SimpleInstance{id: "id2", group: "group1", deployment: "dep1", az: "az1"},
-				SimpleInstance{id: "id3", group: "group1", deployment: "dep1", az: "az1"},
-				SimpleInstance{id: "id1", group: "group2", deployment: "dep1", az: "az1"},
-				SimpleInstance{id: "id2", group: "group2", deployment: "dep1", az: "az2"},
-				SimpleInstance{id: "id1", group: "group1", deployment: "dep2", az: "az1"},
+				fakeselector.SimpleInstance{InstanceID: "id1-missing-vm", GroupName: "group1", DeploymentName: "dep1", AZName: "az1", MissingVM: true},
+				fakeselector.SimpleInstance{InstanceID: "id1", GroupName: "group1", DeploymentName: "dep1", AZName: "az1"},
+				fakeselector.SimpleInstance{InstanceID: "id2", GroupName: "group1", DeploymentName: "dep1", AZName: "az1"},
+				fakeselector.SimpleInstance{InstanceID: "id3", GroupName: "group1", DeploymentName: "dep1", AZName: "az1"},
+				fakeselector.SimpleInstance{InstanceID: "id1", GroupName: "group2", DeploymentName: "dep1", AZName: "az1"},
+				fakeselector.SimpleInstance{InstanceID: "id2", GroupName: "group2", DeploymentName: "dep1", AZName: "az2"},
+				fakeselector.SimpleInstance{InstanceID: "id1", GroupName: "group1", DeploymentName: "dep2", AZName: "az1"},
 			}
 
 			out, err := req.AsSelector().Select(in)
@@ -57,6 +47,41 @@ var _ = Describe("Limit", func() {
 			Expect(out[0].ID()).ToNot(Equal("id1-missing-vm"))
 		})
 
+		It("makes the same selection given the same seed", func() {
+			str := `{ "Group": { "Name": "*", "Limit": "2" }, "ID": { "Limit": "50%" } }`
+
+			var req Request
+
+			err := json.Unmarshal([]byte(str), &req)
+			Expect(err).ToNot(HaveOccurred())
+
+			var in []Instance
+
+			for _, group := range []string{"group1", "group2", "group3", "group4"} {
+				for _, id := range []string{"id1", "id2", "id3", "id4"} {
+					in = append(in, fakeselector.SimpleInstance{InstanceID: id, GroupName: group, DeploymentName: "dep1"})
+				}
+			}
+
+			ids := func(insts []Instance) []string {
+				var result []string
+				for _, inst := range insts {
+					result = append(result, inst.Group()+"/"+inst.ID())
+				}
+				return result
+			}
+
+			first, err := req.AsSeededSelector(42).Select(in)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(first).To(HaveLen(4))
+
+			for i := 0; i < 10; i++ {
+				out, err := req.AsSeededSelector(42).Select(in)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(ids(out)).To(Equal(ids(first)))
+			}
+		})
+
 		It("supports wildcards", func() {
 			str := `{ "Deployment": { "Name": "*-dep1-*" } }`
 
@@ -66,11 +91,11 @@ var _ = Describe("Limit", func() {
 			Expect(err).ToNot(HaveOccurred())
 
 			in := []Instance{
-				SimpleInstance{deployment: "dep1"},
-				SimpleInstance{deployment: "1-dep1-2"},
-				SimpleInstance{deployment: "1-dep1-3-other"},
-				SimpleInstance{deployment: "1-dep1"},
-				SimpleInstance{deployment: "dep1-2"},
+				fakeselector.SimpleInstance{DeploymentName: "dep1"},
+				fakeselector.SimpleInstance{DeploymentName: "1-dep1-2"},
+				fakeselector.SimpleInstance{DeploymentName: "1-dep1-3-other"},
+				fakeselector.SimpleInstance{DeploymentName: "1-dep1"},
+				fakeselector.SimpleInstance{DeploymentName: "dep1-2"},
 			}
 
 			out, err := req.AsSelector().Select(in)
@@ -81,5 +106,202 @@ var _ = Describe("Limit", func() {
 				out[1].Deployment(),
 			}).To(ConsistOfLen(2, []string{"1-dep1-2", "1-dep1-3-other"}))
 		})
+
+		It("selects by instance state, process state, bootstrap and persistent disk", func() {
+			in := []Instance{
+				fakeselector.SimpleInstance{InstanceID: "id1", InstanceState: "started", InstanceProcessState: "running", IsBootstrap: true, HasPersistentDisk: true},
+				fakeselector.SimpleInstance{InstanceID: "id2", InstanceState: "started", InstanceProcessState: "failing", HasPersistentDisk: true},
+				fakeselector.SimpleInstance{InstanceID: "id3", InstanceState: "stopped", InstanceProcessState: "stopped"},
+				fakeselector.SimpleInstance{InstanceID: "id4", InstanceState: "started", InstanceProcessState: "unresponsive agent", IsBootstrap: true},
+			}
+
+			selectIDs := func(str string) []string {
+				var req Request
+
+				err := json.Unmarshal([]byte(str), &req)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(req.Validate()).ToNot(HaveOccurred())
+
+				out, err := req.AsSelector().Select(in)
+				Expect(err).ToNot(HaveOccurred())
+
+				var ids []string
+				for _, inst := range out {
+					ids = append(ids, inst.ID())
+				}
+				return ids
+			}
+
+			Expect(selectIDs(`{ "State": { "Values": ["started"] } }`)).To(Equal([]string{"id1", "id2", "id4"}))
+			Expect(selectIDs(`{ "ProcessState": { "Values": ["running", "unresponsive*"] } }`)).To(Equal([]string{"id1", "id4"}))
+			Expect(selectIDs(`{ "Bootstrap": true }`)).To(Equal([]string{"id1", "id4"}))
+			Expect(selectIDs(`{ "PersistentDisk": false }`)).To(Equal([]string{"id3", "id4"}))
+			Expect(selectIDs(`{ "Bootstrap": true, "PersistentDisk": true }`)).To(Equal([]string{"id1"}))
+		})
+
+		It("requires state values", func() {
+			req := Request{ProcessState: &ValuesRequest{}}
+			Expect(req.Validate()).To(MatchError("ProcessState must include at least one value"))
+		})
+
+		It("selects by IP, CIDR and network", func() {
+			in := []Instance{
+				fakeselector.SimpleInstance{InstanceID: "id1", IPAddrs: []string{"10.0.16.5"}, NetworkNames: []string{"services"}},
+				fakeselector.SimpleInstance{InstanceID: "id2", IPAddrs: []string{"10.0.32.5", "10.0.16.6"}, NetworkNames: []string{"default", "services"}},
+				fakeselector.SimpleInstance{InstanceID: "id3", IPAddrs: []string{"10.0.32.6"}, NetworkNames: []string{"default"}},
+				fakeselector.SimpleInstance{InstanceID: "id4"},
+			}
+
+			selectIDs := func(str string) []string {
+				var req Request
+
+				err := json.Unmarshal([]byte(str), &req)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(req.Validate()).ToNot(HaveOccurred())
+
+				out, err := req.AsSelector().Select(in)
+				Expect(err).ToNot(HaveOccurred())
+
+				var ids []string
+				for _, inst := range out {
+					ids = append(ids, inst.ID())
+				}
+				return ids
+			}
+
+			Expect(selectIDs(`{ "IP": { "Values": ["10.0.32.6"] } }`)).To(Equal([]string{"id3"}))
+			Expect(selectIDs(`{ "IP": { "Values": ["10.0.16.*"] } }`)).To(Equal([]string{"id1", "id2"}))
+			Expect(selectIDs(`{ "CIDR": { "Values": ["10.0.32.0/20"] } }`)).To(Equal([]string{"id2", "id3"}))
+			Expect(selectIDs(`{ "Network": { "Values": ["services"] } }`)).To(Equal([]string{"id1", "id2"}))
+			Expect(selectIDs(`{ "Network": { "Values": ["default"] }, "CIDR": { "Values": ["10.0.16.0/24"] } }`)).To(Equal([]string{"id2"}))
+		})
+
+		It("selects by release jobs and monit processes", func() {
+			in := []Instance{
+				fakeselector.SimpleInstance{InstanceID: "id1", JobNames: []string{"etcd", "consul_agent"}, ProcessNames: []string{"etcd", "consul_agent"}},
+				fakeselector.SimpleInstance{InstanceID: "id2", JobNames: []string{"gorouter"}, ProcessNames: []string{"gorouter", "metron_agent"}},
+				fakeselector.SimpleInstance{InstanceID: "id3", JobNames: []string{"bbs"}},
+			}
+
+			selectIDs := func(str string) []string {
+				var req Request
+
+				err := json.Unmarshal([]byte(str), &req)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(req.Validate()).ToNot(HaveOccurred())
+
+				out, err := req.AsSelector().Select(in)
+				Expect(err).ToNot(HaveOccurred())
+
+				var ids []string
+				for _, inst := range out {
+					ids = append(ids, inst.ID())
+				}
+				return ids
+			}
+
+			Expect(selectIDs(`{ "Job": { "Values": ["etcd"] } }`)).To(Equal([]string{"id1"}))
+			Expect(selectIDs(`{ "Job": { "Values": ["gorouter", "bbs"] } }`)).To(Equal([]string{"id2", "id3"}))
+			Expect(selectIDs(`{ "Process": { "Values": ["*_agent"] } }`)).To(Equal([]string{"id1", "id2"}))
+			Expect(selectIDs(`{ "Job": { "Values": ["bbs"] }, "Process": { "Values": ["*"] } }`)).To(BeEmpty())
+
+			req := Request{Job: &ValuesRequest{}}
+			Expect(req.Validate()).To(MatchError("Job must include at least one value"))
+		})
+
+		It("requires valid CIDRs", func() {
+			req := Request{CIDR: &ValuesRequest{Values: []string{"10.0.0.0"}}}
+			Expect(req.Validate()).To(MatchError("CIDR value '10.0.0.0' is not valid: invalid CIDR address: 10.0.0.0"))
+
+			req = Request{Not: &Request{Network: &ValuesRequest{}}}
+			Expect(req.Validate()).To(MatchError("Not.Network must include at least one value"))
+		})
+	})
+
+	Describe("expressions", func() {
+		in := []Instance{
+			fakeselector.SimpleInstance{InstanceID: "id1", GroupName: "router", DeploymentName: "cf", AZName: "z1"},
+			fakeselector.SimpleInstance{InstanceID: "id2", GroupName: "router", DeploymentName: "cf", AZName: "z2"},
+			fakeselector.SimpleInstance{InstanceID: "id3", GroupName: "router", DeploymentName: "cf", AZName: "z3"},
+			fakeselector.SimpleInstance{InstanceID: "id4", GroupName: "postgres", DeploymentName: "cf", AZName: "z1"},
+			fakeselector.SimpleInstance{InstanceID: "id5", GroupName: "mysql", DeploymentName: "cf", AZName: "z2"},
+			fakeselector.SimpleInstance{InstanceID: "id6", GroupName: "mysql", DeploymentName: "cf", AZName: "z3", MissingVM: true},
+		}
+
+		parse := func(str string) Request {
+			var req Request
+
+			err := json.Unmarshal([]byte(str), &req)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(req.Validate()).ToNot(HaveOccurred())
+
+			return req
+		}
+
+		selectIDs := func(req Request) []string {
+			out, err := req.AsSelector().Select(in)
+			Expect(err).ToNot(HaveOccurred())
+
+			var ids []string
+			for _, inst := range out {
+				ids = append(ids, inst.ID())
+			}
+			return ids
+		}
+
+		It("excludes instances selected by Not", func() {
+			req := parse(`{ "Group": { "Name": "router" }, "Not": { "AZ": { "Name": "z3" } } }`)
+			Expect(selectIDs(req)).To(Equal([]string{"id1", "id2"}))
+		})
+
+		It("unions instances selected by AnyOf without including missing VMs", func() {
+			req := parse(`{ "AnyOf": [ { "Group": { "Name": "postgres" } }, { "Group": { "Name": "mysql" } } ] }`)
+			Expect(selectIDs(req)).To(Equal([]string{"id4", "id5"}))
+		})
+
+		It("applies AllOf selectors one after another", func() {
+			req := parse(`{ "Not": { "AZ": { "Name": "z3" } }, "AllOf": [ { "Group": { "Name": "router" } }, { "AZ": { "Name": "z2" } } ] }`)
+			Expect(selectIDs(req)).To(Equal([]string{"id2"}))
+		})
+
+		It("keeps limit semantics in nested selectors", func() {
+			req := parse(`{ "AnyOf": [
+				{ "Group": { "Name": "router" }, "AZ": { "Name": "z1" }, "ID": { "Limit": "1" } },
+				{ "Group": { "Name": "router" }, "AZ": { "Name": "z2" }, "ID": { "Limit": "1" } }
+			] }`)
+			Expect(selectIDs(req)).To(Equal([]string{"id1", "id2"}))
+
+			req = parse(`{ "Group": { "Name": "router" }, "Not": { "ID": { "Limit": "2" } } }`)
+			Expect(selectIDs(req)).To(HaveLen(1))
+		})
+
+		It("describes nested expressions", func() {
+			req := parse(`{
+				"Group": { "Name": "router" },
+				"Not": { "AZ": { "Name": "z3" } },
+				"AnyOf": [ { "ID": { "Limit": "1" } }, { "Bootstrap": true, "ProcessState": { "Values": ["running"] } } ]
+			}`)
+
+			Expect(req.Description()).To(Equal(
+				"Group=router AND NOT (AZ=z3) AND ANY OF (ID=* (limit 1); ProcessState in [running] AND Bootstrap)"))
+
+			Expect(Request{}.Description()).To(Equal("all instances"))
+		})
+
+		It("validates nested selectors", func() {
+			var req Request
+
+			err := json.Unmarshal([]byte(`{ "AnyOf": [ { "Not": { "State": {} } } ] }`), &req)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(req.Validate()).To(MatchError("AnyOf[0].Not.State must include at least one value"))
+
+			req = Request{}
+
+			err = json.Unmarshal([]byte(`{ "AllOf": [ { "IncludeMissing": true } ] }`), &req)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(req.Validate()).To(MatchError("AllOf[0].IncludeMissing can only be set on the top level selector"))
+
+			Expect(Request{AnyOf: []Request{}}.Validate()).To(MatchError("AnyOf must include at least one selector"))
+		})
 	})
 })
@@ -0,0 +1,147 @@
+package selector
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Description returns human readable form of the request,
+// e.g. "Group=router AND NOT (AZ=z3)"
+func (a Request) Description() string {
+	var rules []string
+
+	if a.IncludeMissing {
+		rules = append(rules, "including instances without VMs")
+	}
+
+	if a.State != nil {
+		rules = append(rules, describeValues("State", a.State.Values, Limit{}))
+	}
+
+	if a.ProcessState != nil {
+		rules = append(rules, describeValues("ProcessState", a.ProcessState.Values, Limit{}))
+	}
+
+	if a.Bootstrap != nil {
+		rules = append(rules, describeFlag("Bootstrap", *a.Bootstrap))
+	}
+
+	if a.PersistentDisk != nil {
+		rules = append(rules, describeFlag("PersistentDisk", *a.PersistentDisk))
+	}
+
+	if a.IP != nil {
+		rules = append(rules, describeValues("IP", a.IP.Values, Limit{}))
+	}
+
+	if a.CIDR != nil {
+		rules = append(rules, describeValues("CIDR", a.CIDR.Values, Limit{}))
+	}
+
+	if a.Network != nil {
+		rules = append(rules, describeValues("Network", a.Network.Values, Limit{}))
+	}
+
+	if a.Job != nil {
+		rules = append(rules, describeValues("Job", a.Job.Values, Limit{}))
+	}
+
+	if a.Process != nil {
+		rules = append(rules, describeValues("Process", a.Process.Values, Limit{}))
+	}
+
+	if a.AZ != nil {
+		rules = append(rules, describeName("AZ", a.AZ))
+	}
+
+	if a.Deployment != nil {
+		rules = append(rules, describeName("Deployment", a.Deployment))
+	}
+
+	if a.Group != nil {
+		rules = append(rules, describeName("Group", a.Group))
+	}
+
+	if a.ID != nil {
+		rules = append(rules, describeValues("ID", a.ID.Values, a.ID.Limit))
+	}
+
+	if a.Not != nil {
+		rules = append(rules, "NOT ("+a.Not.Description()+")")
+	}
+
+	if len(a.AnyOf) > 0 {
+		rules = append(rules, describeExpression("ANY OF", a.AnyOf))
+	}
+
+	if len(a.AllOf) > 0 {
+		rules = append(rules, describeExpression("ALL OF", a.AllOf))
+	}
+
+	if a.Topology != nil {
+		rules = append(rules, describeTopology(*a.Topology))
+	}
+
+	if len(rules) == 0 {
+		return "all instances"
+	}
+
+	return strings.Join(rules, " AND ")
+}
+
+func describeName(rule string, req *NameRequest) string {
+	name := req.Name
+	if len(name) == 0 {
+		name = "*"
+	}
+
+	return withLimit(rule+"="+name, req.Limit)
+}
+
+func describeValues(rule string, values []string, limit Limit) string {
+	if len(values) == 0 {
+		return withLimit(rule+"=*", limit)
+	}
+
+	return withLimit(fmt.Sprintf("%s in [%s]", rule, strings.Join(values, ", ")), limit)
+}
+
+func describeFlag(rule string, value bool) string {
+	if value {
+		return rule
+	}
+
+	return "NOT " + rule
+}
+
+func describeExpression(op string, reqs []Request) string {
+	var descs []string
+
+	for _, req := range reqs {
+		descs = append(descs, req.Description())
+	}
+
+	return op + " (" + strings.Join(descs, "; ") + ")"
+}
+
+func describeTopology(req TopologyRequest) string {
+	desc := withLimit("Topology="+req.Strategy, req.Limit)
+
+	if req.MaxPerAZ > 0 {
+		desc += fmt.Sprintf(" (max %d per AZ)", req.MaxPerAZ)
+	}
+
+	if req.MaxPerGroupPerAZ > 0 {
+		desc += fmt.Sprintf(" (max %d per group per AZ)", req.MaxPerGroupPerAZ)
+	}
+
+	return desc
+}
+
+func withLimit(desc string, limit Limit) string {
+	if str := limit.String(); len(str) > 0 {
+		return desc + " (limit " + str + ")"
+	}
+
+	return desc
+}
@@ -0,0 +1,44 @@
+package fakes
+
+import (
+	"github.com/cppforlife/turbulence/incident/selector"
+)
+
+// SimpleInstance is an instance with fixed attributes used to test selection
+type SimpleInstance struct {
+	InstanceID     string
+	GroupName      string
+	DeploymentName string
+	AZName         string
+	MissingVM      bool
+
+	InstanceState        string
+	InstanceProcessState string
+	IsBootstrap          bool
+	HasPersistentDisk    bool
+
+	IPAddrs      []string
+	NetworkNames []string
+
+	JobNames     []string
+	ProcessNames []string
+}
+
+var _ selector.Instance = SimpleInstance{}
+
+func (i SimpleInstance) ID() string         { return i.InstanceID }
+func (i SimpleInstance) Group() string      { return i.GroupName }
+func (i SimpleInstance) Deployment() string { return i.DeploymentName }
+func (i SimpleInstance) AZ() string         { return i.AZName }
+func (i SimpleInstance) HasVM() bool        { return !i.MissingVM }
+
+func (i SimpleInstance) State() string        { return i.InstanceState }
+func (i SimpleInstance) ProcessState() string { return i.InstanceProcessState }
+func (i SimpleInstance) Bootstrap() bool      { return i.IsBootstrap }
+func (i SimpleInstance) PersistentDisk() bool { return i.HasPersistentDisk }
+
+func (i SimpleInstance) IPs() []string      { return i.IPAddrs }
+func (i SimpleInstance) Networks() []string { return i.NetworkNames }
+
+func (i SimpleInstance) Jobs() []string      { return i.JobNames }
+func (i SimpleInstance) Processes() []string { return i.ProcessNames }
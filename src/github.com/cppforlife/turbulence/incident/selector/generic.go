@@ -1,7 +1,10 @@
 package selector
 
 import (
+	"math/rand"
+	"net"
 	"path/filepath"
+	"sort"
 )
 
 type Multiple struct {
@@ -21,10 +24,77 @@ func (m Multiple) Select(instances []Instance) ([]Instance, error) {
 	return instances, nil
 }
 
+// Except selects instances that are not selected by its selector
+type Except struct {
+	Selector Selector
+}
+
+func (n Except) Select(instances []Instance) ([]Instance, error) {
+	excluded, err := n.Selector.Select(instances)
+	if err != nil {
+		return nil, err
+	}
+
+	excludedKeys := instanceKeys(excluded)
+
+	f := func(inst Instance) (bool, error) {
+		_, found := excludedKeys[instanceKey(inst)]
+		return !found, nil
+	}
+
+	return ByFilter{f}.Select(instances)
+}
+
+// AnyOf selects instances that are selected by at least one of its selectors;
+// each selector picks from all given instances
+type AnyOf struct {
+	Selectors []Selector
+}
+
+func (a AnyOf) Select(instances []Instance) ([]Instance, error) {
+	selectedKeys := map[string]struct{}{}
+
+	for _, sel := range a.Selectors {
+		selected, err := sel.Select(instances)
+		if err != nil {
+			return nil, err
+		}
+
+		for key := range instanceKeys(selected) {
+			selectedKeys[key] = struct{}{}
+		}
+	}
+
+	// Keep original order of instances
+	f := func(inst Instance) (bool, error) {
+		_, found := selectedKeys[instanceKey(inst)]
+		return found, nil
+	}
+
+	return ByFilter{f}.Select(instances)
+}
+
+func instanceKey(inst Instance) string {
+	return inst.Deployment() + "/" + inst.Group() + "/" + inst.ID()
+}
+
+func instanceKeys(instances []Instance) map[string]struct{} {
+	keys := map[string]struct{}{}
+
+	for _, inst := range instances {
+		keys[instanceKey(inst)] = struct{}{}
+	}
+
+	return keys
+}
+
 type Generic struct {
 	Names []string
 	Limit Limit
 	Func  func(Instance) string
+
+	// Optional source of randomness for the limit
+	Rand *rand.Rand
 }
 
 func (g Generic) Select(instances []Instance) ([]Instance, error) {
@@ -37,7 +107,7 @@ func (g Generic) Select(instances []Instance) ([]Instance, error) {
 		}
 	}
 
-	groups, err := g.Limit.Limit(GroupBy{g.Func}.Groups(instances))
+	groups, err := g.Limit.LimitWithRand(GroupBy{g.Func}.Groups(instances), g.Rand)
 	if err != nil {
 		return nil, err
 	}
@@ -59,6 +129,42 @@ func NewByNames(names []string, f func(Instance) string) ByFilter {
 	return ByFilter{byNames}
 }
 
+// NewByAnyNames matches instances with at least one value matching any of the names
+func NewByAnyNames(names []string, f func(Instance) []string) ByFilter {
+	byAnyNames := func(inst Instance) (bool, error) {
+		for _, val := range f(inst) {
+			matched, err := NewByNames(names, func(Instance) string { return val }).Func(inst)
+			if matched || err != nil {
+				return matched, err
+			}
+		}
+		return false, nil
+	}
+
+	return ByFilter{byAnyNames}
+}
+
+// NewByCIDRs matches instances with at least one IP within any of the CIDRs
+func NewByCIDRs(cidrs []string, f func(Instance) []string) ByFilter {
+	byCIDRs := func(inst Instance) (bool, error) {
+		for _, cidr := range cidrs {
+			_, ipNet, err := net.ParseCIDR(cidr)
+			if err != nil {
+				return false, err
+			}
+
+			for _, val := range f(inst) {
+				if ip := net.ParseIP(val); ip != nil && ipNet.Contains(ip) {
+					return true, nil
+				}
+			}
+		}
+		return false, nil
+	}
+
+	return ByFilter{byCIDRs}
+}
+
 type ByFilter struct {
 	Func func(Instance) (bool, error)
 }
@@ -96,5 +202,8 @@ func (g GroupBy) Groups(instances []Instance) []string {
 		groups = append(groups, g)
 	}
 
+	// Stable order allows to reproduce limited selection
+	sort.Strings(groups)
+
 	return groups
 }
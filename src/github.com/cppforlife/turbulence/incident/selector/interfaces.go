@@ -6,6 +6,17 @@ type Instance interface {
 	Deployment() string
 	AZ() string
 	HasVM() bool
+
+	State() string
+	ProcessState() string
+	Bootstrap() bool
+	PersistentDisk() bool
+
+	IPs() []string
+	Networks() []string
+
+	Jobs() []string
+	Processes() []string
 }
 
 type Selector interface {
@@ -86,6 +86,12 @@ func NewLimit(start, end int, percent bool) (Limit, error) {
 }
 
 func (l Limit) Limit(in []string) ([]string, error) {
+	return l.LimitWithRand(in, nil)
+}
+
+// LimitWithRand uses given source of randomness to pick items
+// so that selection can be reproduced; global source is used if nil.
+func (l Limit) LimitWithRand(in []string, rnd *rand.Rand) ([]string, error) {
 	if !l.applied {
 		return in, nil
 	}
@@ -97,7 +103,7 @@ func (l Limit) Limit(in []string) ([]string, error) {
 
 	picked := []string{}
 
-	for _, idx := range l.selectIdxs(len(in)) {
+	for _, idx := range l.selectIdxs(len(in), rnd) {
 		picked = append(picked, in[idx])
 	}
 
@@ -122,12 +128,29 @@ func (l Limit) String() string {
 	return fmt.Sprintf("%d%s-%d%s", l.start, suffix, l.end, suffix)
 }
 
-func (l Limit) selectIdxs(max int) []int {
+func (l Limit) selectIdxs(max int, rnd *rand.Rand) []int {
+	intn, perm := rand.Intn, rand.Perm
+	if rnd != nil {
+		intn, perm = rnd.Intn, rnd.Perm
+	}
+
 	n := l.start
 	if l.end > l.start {
-		n += rand.Intn(l.end - l.start)
+		n += intn(l.end - l.start)
+	}
+	return perm(max)[0:l.numOrPercent(n, max)]
+}
+
+// bounds returns smallest and largest number of items limit allows
+// out of max items without capping them at max
+func (l Limit) bounds(max int) (int, int) {
+	if !l.applied {
+		return max, max
+	}
+	if l.percent {
+		return l.numOrPercent(l.start, max), l.numOrPercent(l.end, max)
 	}
-	return rand.Perm(max)[0:l.numOrPercent(n, max)]
+	return l.start, l.end
 }
 
 func (l Limit) numOrPercent(n, max int) int {
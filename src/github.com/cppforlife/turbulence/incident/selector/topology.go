@@ -0,0 +1,263 @@
+package selector
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+)
+
+const (
+	TopologySpread      = "spread"
+	TopologyConcentrate = "concentrate"
+)
+
+// Topology picks instances with regard to AZs they are in. Spread picks
+// instances round-robin across AZs, so that chosen instances are in as many
+// AZs as possible; concentrate picks instances from a single AZ.
+// Instances without an AZ are considered to be in the same AZ.
+type Topology struct {
+	Strategy string
+
+	// Number of instances to pick; by default as many as maximums allow
+	Limit Limit
+
+	// Maximums are not enforced when 0
+	MaxPerAZ         int
+	MaxPerGroupPerAZ int
+
+	// Optional source of randomness
+	Rand *rand.Rand
+}
+
+// topologyAZ keeps track of instances picked from a single AZ
+type topologyAZ struct {
+	name      string
+	instances []Instance // not yet picked in random order
+
+	picked         int
+	pickedPerGroup map[string]int
+
+	maxPerAZ    int
+	maxPerGroup int
+}
+
+func (t Topology) Select(instances []Instance) ([]Instance, error) {
+	azs := t.azs(instances)
+
+	var picked []Instance
+	var err error
+
+	switch t.Strategy {
+	case TopologySpread:
+		picked, err = t.spread(azs, len(instances))
+	case TopologyConcentrate:
+		picked, err = t.concentrate(azs, len(instances))
+	default:
+		err = fmt.Errorf("Unknown topology strategy '%s'", t.Strategy)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Keep original order of instances
+	pickedKeys := instanceKeys(picked)
+
+	f := func(inst Instance) (bool, error) {
+		_, found := pickedKeys[instanceKey(inst)]
+		return found, nil
+	}
+
+	return ByFilter{f}.Select(instances)
+}
+
+func (t Topology) spread(azs []*topologyAZ, max int) ([]Instance, error) {
+	capacity := 0
+
+	for _, az := range azs {
+		capacity += az.Capacity()
+	}
+
+	n, err := t.size(max, capacity, "spread across AZs")
+	if err != nil {
+		return nil, err
+	}
+
+	var picked []Instance
+
+	// Each AZ gets an instance before any AZ gets another one;
+	// AZs that are first to get extra instances are picked randomly
+	order := t.perm(len(azs))
+
+	for len(picked) < n {
+		for _, idx := range order {
+			if len(picked) < n && azs[idx].Capacity() > 0 {
+				picked = append(picked, azs[idx].Pick())
+			}
+		}
+	}
+
+	return picked, nil
+}
+
+func (t Topology) concentrate(azs []*topologyAZ, max int) ([]Instance, error) {
+	if len(azs) == 0 {
+		_, err := t.size(max, 0, "within a single AZ")
+		return nil, err
+	}
+
+	min, _ := t.Limit.bounds(max)
+	if !t.Limit.applied {
+		min = 1
+	}
+
+	largest := azs[0]
+
+	var fitting []*topologyAZ
+
+	for _, az := range azs {
+		if az.Capacity() > largest.Capacity() {
+			largest = az
+		}
+		if az.Capacity() > 0 && az.Capacity() >= min {
+			fitting = append(fitting, az)
+		}
+	}
+
+	// Let size explain why the largest AZ does not fit
+	if len(fitting) == 0 {
+		fitting = []*topologyAZ{largest}
+	}
+
+	az := fitting[t.intn(len(fitting))]
+
+	n, err := t.size(max, az.Capacity(), fmt.Sprintf("within a single AZ (largest AZ is '%s')", largest.name))
+	if err != nil {
+		return nil, err
+	}
+
+	var picked []Instance
+
+	for len(picked) < n {
+		picked = append(picked, az.Pick())
+	}
+
+	return picked, nil
+}
+
+// size returns number of instances to pick given the limit
+// and returns an error if limit cannot be satisfied
+func (t Topology) size(max, capacity int, desc string) (int, error) {
+	if !t.Limit.applied {
+		return capacity, nil
+	}
+
+	min, end := t.Limit.bounds(max)
+
+	if min > capacity {
+		return 0, fmt.Errorf("Expected to select at least %d instance(s) %s but only %d can be selected", min, desc, capacity)
+	}
+
+	if end > capacity {
+		end = capacity
+	}
+
+	if end > min {
+		return min + t.intn(end-min+1), nil
+	}
+
+	return min, nil
+}
+
+func (t Topology) azs(instances []Instance) []*topologyAZ {
+	azsByName := map[string]*topologyAZ{}
+	var names []string
+
+	for _, idx := range t.perm(len(instances)) {
+		inst := instances[idx]
+
+		az, found := azsByName[inst.AZ()]
+		if !found {
+			az = &topologyAZ{
+				name:           inst.AZ(),
+				pickedPerGroup: map[string]int{},
+				maxPerAZ:       t.MaxPerAZ,
+				maxPerGroup:    t.MaxPerGroupPerAZ,
+			}
+			azsByName[inst.AZ()] = az
+			names = append(names, inst.AZ())
+		}
+
+		az.instances = append(az.instances, inst)
+	}
+
+	// Stable order allows to reproduce selection
+	sort.Strings(names)
+
+	var azs []*topologyAZ
+
+	for _, name := range names {
+		azs = append(azs, azsByName[name])
+	}
+
+	return azs
+}
+
+func (t Topology) perm(n int) []int {
+	if t.Rand != nil {
+		return t.Rand.Perm(n)
+	}
+	return rand.Perm(n)
+}
+
+func (t Topology) intn(n int) int {
+	if t.Rand != nil {
+		return t.Rand.Intn(n)
+	}
+	return rand.Intn(n)
+}
+
+// Capacity returns number of instances that can still be picked
+func (a *topologyAZ) Capacity() int {
+	capacity := 0
+	perGroup := map[string]int{}
+
+	for _, inst := range a.instances {
+		group := groupKey(inst)
+
+		if a.maxPerGroup > 0 && a.pickedPerGroup[group]+perGroup[group] >= a.maxPerGroup {
+			continue
+		}
+
+		perGroup[group]++
+		capacity++
+	}
+
+	if a.maxPerAZ > 0 && a.picked+capacity > a.maxPerAZ {
+		capacity = a.maxPerAZ - a.picked
+	}
+
+	return capacity
+}
+
+// Pick returns next instance that can be picked; Capacity must be checked first
+func (a *topologyAZ) Pick() Instance {
+	for i, inst := range a.instances {
+		group := groupKey(inst)
+
+		if a.maxPerGroup > 0 && a.pickedPerGroup[group] >= a.maxPerGroup {
+			continue
+		}
+
+		a.instances = append(a.instances[:i:i], a.instances[i+1:]...)
+		a.picked++
+		a.pickedPerGroup[group]++
+
+		return inst
+	}
+
+	panic(fmt.Sprintf("Internal inconsistency: no instances left to pick in AZ '%s'", a.name))
+}
+
+func groupKey(inst Instance) string {
+	return inst.Deployment() + "/" + inst.Group()
+}
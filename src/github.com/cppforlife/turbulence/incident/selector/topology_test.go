@@ -0,0 +1,168 @@
+package selector_test
+
+import (
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cppforlife/turbulence/incident/selector"
+	fakeselector "github.com/cppforlife/turbulence/incident/selector/fakes"
+)
+
+var _ = Describe("Topology", func() {
+	in := []Instance{
+		fakeselector.SimpleInstance{InstanceID: "id1", GroupName: "router", DeploymentName: "cf", AZName: "z1"},
+		fakeselector.SimpleInstance{InstanceID: "id2", GroupName: "router", DeploymentName: "cf", AZName: "z1"},
+		fakeselector.SimpleInstance{InstanceID: "id3", GroupName: "api", DeploymentName: "cf", AZName: "z1"},
+		fakeselector.SimpleInstance{InstanceID: "id4", GroupName: "router", DeploymentName: "cf", AZName: "z2"},
+		fakeselector.SimpleInstance{InstanceID: "id5", GroupName: "api", DeploymentName: "cf", AZName: "z2"},
+		fakeselector.SimpleInstance{InstanceID: "id6", GroupName: "router", DeploymentName: "cf", AZName: "z3"},
+	}
+
+	parse := func(str string) Request {
+		var req Request
+
+		err := json.Unmarshal([]byte(str), &req)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(req.Validate()).ToNot(HaveOccurred())
+
+		return req
+	}
+
+	countAZs := func(out []Instance) map[string]int {
+		counts := map[string]int{}
+		for _, inst := range out {
+			counts[inst.AZ()]++
+		}
+		return counts
+	}
+
+	It("spreads one instance per AZ", func() {
+		req := parse(`{ "Topology": { "Strategy": "spread", "MaxPerAZ": 1 } }`)
+
+		for i := 0; i < 50; i++ {
+			out, err := req.AsSelector().Select(in)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(countAZs(out)).To(Equal(map[string]int{"z1": 1, "z2": 1, "z3": 1}))
+		}
+	})
+
+	It("spreads limited number of instances evenly across AZs", func() {
+		req := parse(`{ "Topology": { "Strategy": "spread", "Limit": "2" } }`)
+
+		for i := 0; i < 50; i++ {
+			out, err := req.AsSelector().Select(in)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(HaveLen(2))
+			Expect(countAZs(out)).To(HaveLen(2))
+		}
+
+		req = parse(`{ "Topology": { "Strategy": "spread", "Limit": "5" } }`)
+
+		for i := 0; i < 50; i++ {
+			out, err := req.AsSelector().Select(in)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(HaveLen(5))
+
+			// z3 only has a single instance
+			Expect(countAZs(out)).To(Equal(map[string]int{"z1": 2, "z2": 2, "z3": 1}))
+		}
+	})
+
+	It("concentrates instances in a single AZ", func() {
+		req := parse(`{ "Topology": { "Strategy": "concentrate", "Limit": "2" } }`)
+		seen := map[string]bool{}
+
+		for i := 0; i < 50; i++ {
+			out, err := req.AsSelector().Select(in)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(HaveLen(2))
+			Expect(countAZs(out)).To(HaveLen(1))
+			seen[out[0].AZ()] = true
+		}
+
+		// z3 does not have enough instances
+		Expect(seen).ToNot(HaveKey("z3"))
+	})
+
+	It("concentrates all instances of a single AZ by default", func() {
+		req := parse(`{ "Group": { "Name": "router" }, "Topology": { "Strategy": "concentrate" } }`)
+
+		for i := 0; i < 50; i++ {
+			out, err := req.AsSelector().Select(in)
+			Expect(err).ToNot(HaveOccurred())
+
+			counts := countAZs(out)
+			Expect(counts).To(HaveLen(1))
+			Expect([]map[string]int{{"z1": 2}, {"z2": 1}, {"z3": 1}}).To(ContainElement(counts))
+		}
+	})
+
+	It("picks at most given number of instances per group per AZ", func() {
+		req := parse(`{ "Topology": { "Strategy": "spread", "MaxPerGroupPerAZ": 1 } }`)
+
+		out, err := req.AsSelector().Select(in)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(countAZs(out)).To(Equal(map[string]int{"z1": 2, "z2": 2, "z3": 1}))
+
+		req = parse(`{ "Topology": { "Strategy": "concentrate", "Limit": "2", "MaxPerGroupPerAZ": 1 } }`)
+
+		for i := 0; i < 50; i++ {
+			out, err := req.AsSelector().Select(in)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(HaveLen(2))
+			Expect(out[0].AZ()).To(Equal(out[1].AZ()))
+			Expect(out[0].Group()).ToNot(Equal(out[1].Group()))
+		}
+	})
+
+	It("applies after other rules and expressions", func() {
+		req := parse(`{ "Not": { "AZ": { "Name": "z1" } }, "Topology": { "Strategy": "spread", "MaxPerAZ": 1 } }`)
+
+		out, err := req.AsSelector().Select(in)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(countAZs(out)).To(Equal(map[string]int{"z2": 1, "z3": 1}))
+	})
+
+	It("makes the same selection given the same seed", func() {
+		req := parse(`{ "Topology": { "Strategy": "spread", "Limit": "1-4" } }`)
+
+		first, err := req.AsSeededSelector(42).Select(in)
+		Expect(err).ToNot(HaveOccurred())
+
+		for i := 0; i < 10; i++ {
+			out, err := req.AsSeededSelector(42).Select(in)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(Equal(first))
+		}
+	})
+
+	It("returns an error instead of picking fewer instances", func() {
+		req := parse(`{ "Topology": { "Strategy": "spread", "Limit": "3", "MaxPerAZ": 1 } }`)
+
+		_, err := req.AsSelector().Select(in[:3])
+		Expect(err).To(MatchError("Expected to select at least 3 instance(s) spread across AZs but only 1 can be selected"))
+
+		req = parse(`{ "Topology": { "Strategy": "concentrate", "Limit": "4" } }`)
+
+		_, err = req.AsSelector().Select(in)
+		Expect(err).To(MatchError("Expected to select at least 4 instance(s) within a single AZ (largest AZ is 'z1') but only 3 can be selected"))
+
+		_, err = req.AsSelector().Select(nil)
+		Expect(err).To(MatchError("Expected to select at least 4 instance(s) within a single AZ but only 0 can be selected"))
+	})
+
+	It("requires known strategy", func() {
+		req := Request{AllOf: []Request{{Topology: &TopologyRequest{Strategy: "random"}}}}
+		Expect(req.Validate()).To(MatchError("AllOf[0].Topology.Strategy must be one of 'spread', 'concentrate'"))
+
+		req = Request{Topology: &TopologyRequest{Strategy: "spread", MaxPerAZ: -1}}
+		Expect(req.Validate()).To(MatchError("Topology.MaxPerAZ cannot be negative"))
+	})
+
+	It("describes topology", func() {
+		req := parse(`{ "Group": { "Name": "router" }, "Topology": { "Strategy": "spread", "Limit": "3", "MaxPerAZ": 1 } }`)
+		Expect(req.Description()).To(Equal("Group=router AND Topology=spread (limit 3) (max 1 per AZ)"))
+	})
+})
@@ -7,8 +7,11 @@ import (
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
 
+	"github.com/cppforlife/turbulence/blackout"
 	"github.com/cppforlife/turbulence/director"
 	"github.com/cppforlife/turbulence/incident/reporter"
+	"github.com/cppforlife/turbulence/policy"
+	"github.com/cppforlife/turbulence/store"
 )
 
 type Config struct {
@@ -24,6 +27,12 @@ type Config struct {
 
 	Director director.Config
 
+	Store store.Config
+
+	Policy policy.Config
+
+	Blackout blackout.Config
+
 	Datadog reporter.DatadogConfig
 }
 
@@ -90,6 +99,16 @@ func (c Config) Validate() error {
 		return bosherr.WrapError(err, "Validating 'Director' config")
 	}
 
+	err = c.Policy.Validate()
+	if err != nil {
+		return bosherr.WrapError(err, "Validating 'Policy' config")
+	}
+
+	err = c.Blackout.Validate()
+	if err != nil {
+		return bosherr.WrapError(err, "Validating 'Blackout' config")
+	}
+
 	err = c.Datadog.Validate()
 	if err != nil {
 		return bosherr.WrapError(err, "Validating 'Datadog' config")
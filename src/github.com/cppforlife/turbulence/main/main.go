@@ -10,11 +10,16 @@ import (
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
 	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
 
+	"github.com/cppforlife/turbulence/blackout"
 	ctrls "github.com/cppforlife/turbulence/controllers"
 	"github.com/cppforlife/turbulence/director"
+	"github.com/cppforlife/turbulence/emergency"
 	"github.com/cppforlife/turbulence/incident"
 	"github.com/cppforlife/turbulence/incident/reporter"
+	"github.com/cppforlife/turbulence/policy"
+	"github.com/cppforlife/turbulence/scenario"
 	"github.com/cppforlife/turbulence/scheduledinc"
+	"github.com/cppforlife/turbulence/store"
 )
 
 const mainLogTag = "main"
@@ -57,12 +62,22 @@ func main() {
 	rep = reporter.NewMulti([]reporter.Reporter{reporter.NewDirectorEvents(dir, logger), rep})
 
 	worker := incident.NewWorker(logger)
+	scenarioWorker := scenario.NewWorker(logger)
 
-	scheduler := scheduledinc.NewScheduler(logger)
+	calendar, err := blackout.NewCalendar(config.Blackout)
+	ensureNoErr(logger, "Failed building blackout calendar", err)
+
+	scheduler := scheduledinc.NewScheduler(calendar, logger)
 
 	go scheduler.Run()
 
-	repos, err := NewRepos(uuidGen, rep, dir, worker, scheduler, logger)
+	storeFactory := store.NewFactory(config.Store, fs, logger)
+
+	pausables := []emergency.Pausable{scheduler}
+
+	pol := policy.NewPolicy(config.Policy, logger)
+
+	repos, err := NewRepos(uuidGen, rep, dir, worker, scheduler, scenarioWorker, pausables, pol, calendar, storeFactory, logger)
 	ensureNoErr(logger, "Failed building repos", err)
 
 	controllerFactory, err := ctrls.NewFactory(repos, logger)
@@ -4,17 +4,26 @@ import (
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
 
+	"github.com/cppforlife/turbulence/blackout"
 	"github.com/cppforlife/turbulence/director"
+	"github.com/cppforlife/turbulence/emergency"
+	"github.com/cppforlife/turbulence/fleet"
 	"github.com/cppforlife/turbulence/incident"
 	"github.com/cppforlife/turbulence/incident/reporter"
+	"github.com/cppforlife/turbulence/policy"
+	"github.com/cppforlife/turbulence/scenario"
 	"github.com/cppforlife/turbulence/scheduledinc"
+	"github.com/cppforlife/turbulence/store"
 	"github.com/cppforlife/turbulence/tasks"
 )
 
 type Repos struct {
 	incidentsRepo          incident.Repo
 	scheduledIncidentsRepo scheduledinc.Repo
+	scenariosRepo          scenario.Repo
 	tasksRepo              tasks.Repo
+	fleetRepo              fleet.Repo
+	emergencySwitch        emergency.Switch
 }
 
 func NewRepos(
@@ -23,29 +32,105 @@ func NewRepos(
 	director director.Director,
 	incidentNotifier incident.RepoNotifier,
 	scheduledIncidentNotifier scheduledinc.RepoNotifier,
+	scenarioNotifier scenario.RepoNotifier,
+	emergencyPausables []emergency.Pausable,
+	policy policy.Policy,
+	calendar blackout.Calendar,
+	storeFactory store.Factory,
 	logger boshlog.Logger,
 ) (Repos, error) {
 	tasksRepo := tasks.NewRepo(logger)
+	fleetRepo := fleet.NewRepo(director, reporter, logger)
 
-	incidentsRepo := incident.NewRepo(
+	incidentsJournal, err := storeFactory.New("incidents")
+	if err != nil {
+		return Repos{}, err
+	}
+
+	incidentsRepo, err := incident.NewRepo(
 		uuidGen,
 		incidentNotifier,
 		reporter,
 		director,
 		tasksRepo,
+		fleetRepo,
+		policy,
+		calendar,
+		incidentsJournal,
+		logger,
+	)
+	if err != nil {
+		return Repos{}, err
+	}
+
+	scenariosJournal, err := storeFactory.New("scenarios")
+	if err != nil {
+		return Repos{}, err
+	}
+
+	scenariosRepo, err := scenario.NewRepo(
+		uuidGen,
+		scenarioNotifier,
+		incidentsRepo,
+		scenariosJournal,
+		logger,
+	)
+	if err != nil {
+		return Repos{}, err
+	}
+
+	// Running scenarios would continue creating incidents
+	emergencyPausables = append(emergencyPausables, scenariosRepo)
+
+	// Emergency stop is loaded before scheduled incidents are loaded
+	// so that scheduler is paused before they are scheduled again
+	emergencyJournal, err := storeFactory.New("emergency_stop")
+	if err != nil {
+		return Repos{}, err
+	}
+
+	emergencySwitch, err := emergency.NewSwitch(
+		incidentsRepo,
+		emergencyPausables,
+		reporter,
+		emergencyJournal,
 		logger,
 	)
+	if err != nil {
+		return Repos{}, err
+	}
 
-	scheduledIncidentsRepo := scheduledinc.NewRepo(
+	scheduledIncidentsJournal, err := storeFactory.New("scheduled_incidents")
+	if err != nil {
+		return Repos{}, err
+	}
+
+	scheduledIncidentsRepo, err := scheduledinc.NewRepo(
 		uuidGen,
 		scheduledIncidentNotifier,
 		incidentsRepo,
+		scheduledIncidentsJournal,
 		logger,
 	)
+	if err != nil {
+		return Repos{}, err
+	}
+
+	repos := Repos{
+		incidentsRepo:          incidentsRepo,
+		scheduledIncidentsRepo: scheduledIncidentsRepo,
+		scenariosRepo:          scenariosRepo,
+		tasksRepo:              tasksRepo,
+		fleetRepo:              fleetRepo,
+		emergencySwitch:        emergencySwitch,
+	}
 
-	return Repos{incidentsRepo, scheduledIncidentsRepo, tasksRepo}, nil
+	return repos, nil
 }
 
 func (r Repos) IncidentsRepo() incident.Repo              { return r.incidentsRepo }
 func (r Repos) ScheduledIncidentsRepo() scheduledinc.Repo { return r.scheduledIncidentsRepo }
+func (r Repos) ScenariosRepo() scenario.Repo              { return r.scenariosRepo }
 func (r Repos) TasksRepo() tasks.Repo                     { return r.tasksRepo }
+func (r Repos) FleetRepo() fleet.Repo                     { return r.fleetRepo }
+func (r Repos) EmergencySwitch() emergency.Switch         { return r.emergencySwitch }
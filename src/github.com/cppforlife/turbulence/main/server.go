@@ -54,6 +54,8 @@ func (s Server) addOperatorAPI(m *mart.ClassicMartini, controllerFactory ctrls.F
 	m.Get("/api/v1/incidents", isController.APIIndex)
 	m.Get("/api/v1/incidents/:id", isController.APIRead)
 	m.Post("/api/v1/incidents", isController.APICreate)
+	m.Post("/api/v1/incidents/preview", isController.APIPreview)
+	m.Post("/api/v1/incidents/:id/abort", isController.APIAbort)
 
 	sisController := controllerFactory.ScheduledIncidentsController
 
@@ -64,6 +66,28 @@ func (s Server) addOperatorAPI(m *mart.ClassicMartini, controllerFactory ctrls.F
 	m.Post("/api/v1/scheduled_incidents", sisController.APICreate)
 	m.Delete("/api/v1/scheduled_incidents/:id", sisController.APIDelete)
 
+	scController := controllerFactory.ScenariosController
+
+	m.Get("/scenarios", scController.Index)
+	m.Get("/scenarios/:id", scController.Read)
+	m.Get("/api/v1/scenarios", scController.APIIndex)
+	m.Get("/api/v1/scenarios/:id", scController.APIRead)
+	m.Post("/api/v1/scenarios", scController.APICreate)
+	m.Post("/api/v1/scenarios/:id/abort", scController.APIAbort)
+
+	esController := controllerFactory.EmergencyStopController
+
+	m.Post("/emergency_stop", esController.Activate)
+	m.Post("/emergency_stop/clear", esController.Clear)
+	m.Get("/api/v1/emergency_stop", esController.APIRead)
+	m.Post("/api/v1/emergency_stop", esController.APIActivate)
+	m.Delete("/api/v1/emergency_stop", esController.APIClear)
+
+	m.Get("/api/v1/task_types", controllerFactory.TaskTypesController.APIIndex)
+
+	m.Get("/agents", controllerFactory.AgentsController.Index)
+	m.Get("/api/v1/agents", controllerFactory.AgentsController.APIIndex)
+
 	// Driver may change desired state of the task so that task ends
 	m.Post("/api/v1/agent_tasks/:id/state", controllerFactory.TasksController.APIUpdateState)
 }
@@ -76,11 +100,17 @@ func (Server) addAgentAPI(m *mart.ClassicMartini, controllerFactory ctrls.Factor
 		IndentJSON: true,
 	}))
 
-	// Agent watches for tasks based on agent ID
+	// Agent registers on start up and then periodically sends heartbeats
+	m.Post("/api/v1/agents/:id/register", controllerFactory.AgentsController.APIRegister)
+	m.Post("/api/v1/agents/:id/heartbeat", controllerFactory.AgentsController.APIHeartbeat)
+	// Restarted agent reports changes left behind by its tasks that it undid
+	m.Post("/api/v1/agents/:id/recovery", controllerFactory.AgentsController.APIRecovery)
+	// Agent long-polls for new tasks and stop signals and reports task results
+	m.Post("/api/v1/agents/:id/poll", controllerFactory.TasksController.APIPoll)
+
+	// Agents of previous versions poll separately for tasks, their states and results
 	m.Post("/api/v1/agents/:id/tasks", controllerFactory.TasksController.APIConsume)
-	// Agent watches desired state of the task so that it can end it
 	m.Get("/api/v1/agent_tasks/:id/state", controllerFactory.TasksController.APIReadState)
-	// Once agent executes picked up task, its result is reported
 	m.Post("/api/v1/agent_tasks/:id", controllerFactory.TasksController.APIUpdate)
 }
 
@@ -0,0 +1,92 @@
+package policy
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+// Config limits which instances incidents may affect;
+// nothing is restricted by default
+type Config struct {
+	// Glob patterns of deployments that are never affected
+	ProtectedDeployments []string
+
+	// Glob patterns of instance groups that are never affected;
+	// patterns with a slash are matched against 'deployment/group'
+	ProtectedGroups []string
+
+	// Number or percentage (e.g. 5, 30%) of instances in a deployment
+	// (or in an instance group) that a single incident may affect
+	MaxInstancesPerDeployment string
+	MaxInstancesPerGroup      string
+
+	// Task types (e.g. Kill) allowed in deployments matching glob pattern keys;
+	// all matching patterns must allow task type
+	AllowedTaskTypes map[string][]string
+}
+
+func (c Config) Validate() error {
+	for _, pattern := range append(append([]string{}, c.ProtectedDeployments...), c.ProtectedGroups...) {
+		_, err := filepath.Match(pattern, "")
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Validating pattern '%s'", pattern)
+		}
+	}
+
+	for pattern := range c.AllowedTaskTypes {
+		_, err := filepath.Match(pattern, "")
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Validating 'AllowedTaskTypes' pattern '%s'", pattern)
+		}
+	}
+
+	_, err := newMax(c.MaxInstancesPerDeployment)
+	if err != nil {
+		return bosherr.WrapError(err, "Validating 'MaxInstancesPerDeployment'")
+	}
+
+	_, err = newMax(c.MaxInstancesPerGroup)
+	if err != nil {
+		return bosherr.WrapError(err, "Validating 'MaxInstancesPerGroup'")
+	}
+
+	return nil
+}
+
+type max struct {
+	str     string
+	num     int
+	percent bool
+}
+
+func newMax(str string) (max, error) {
+	if len(str) == 0 {
+		return max{}, nil
+	}
+
+	numStr := strings.TrimSuffix(str, "%")
+
+	num, err := strconv.Atoi(numStr)
+	if err != nil || num < 0 {
+		return max{}, bosherr.Errorf("Expected '%s' to be a non-negative number or percentage", str)
+	}
+
+	if numStr != str && num > 100 {
+		return max{}, bosherr.Errorf("Expected '%s' to be at most 100%%", str)
+	}
+
+	return max{str: str, num: num, percent: numStr != str}, nil
+}
+
+func (m max) Applied() bool { return len(m.str) > 0 }
+
+// Allowed rounds down percentages so that limit is never exceeded
+func (m max) Allowed(total int) int {
+	if m.percent {
+		return m.num * total / 100
+	}
+	return m.num
+}
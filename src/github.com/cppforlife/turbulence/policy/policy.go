@@ -0,0 +1,181 @@
+package policy
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+
+	"github.com/cppforlife/turbulence/incident/selector"
+)
+
+type ViolationError struct {
+	Rule   string
+	Reason string
+}
+
+func (e ViolationError) Error() string {
+	return fmt.Sprintf("Policy rule '%s' was violated: %s", e.Rule, e.Reason)
+}
+
+type Policy struct {
+	config Config
+
+	logTag string
+	logger boshlog.Logger
+}
+
+func NewPolicy(config Config, logger boshlog.Logger) Policy {
+	return Policy{config: config, logTag: "policy.Policy", logger: logger}
+}
+
+// Check returns ViolationError if incident with given task types
+// is not allowed to affect selected instances
+func (p Policy) Check(taskTypes []string, all, selected []selector.Instance) error {
+	checks := []func([]string, []selector.Instance, []selector.Instance) error{
+		p.checkProtectedDeployments,
+		p.checkProtectedGroups,
+		p.checkAllowedTaskTypes,
+		p.checkMaxInstancesPerDeployment,
+		p.checkMaxInstancesPerGroup,
+	}
+
+	for _, check := range checks {
+		err := check(taskTypes, all, selected)
+		if err != nil {
+			p.logger.Info(p.logTag, "Rejecting incident: %s", err.Error())
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (p Policy) checkProtectedDeployments(_ []string, _, selected []selector.Instance) error {
+	for _, inst := range selected {
+		for _, pattern := range p.config.ProtectedDeployments {
+			if matched, _ := filepath.Match(pattern, inst.Deployment()); matched {
+				return ViolationError{
+					Rule:   "ProtectedDeployments",
+					Reason: fmt.Sprintf("deployment '%s' matches protected pattern '%s'", inst.Deployment(), pattern),
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+func (p Policy) checkProtectedGroups(_ []string, _, selected []selector.Instance) error {
+	for _, inst := range selected {
+		for _, pattern := range p.config.ProtectedGroups {
+			if matched, _ := filepath.Match(pattern, groupName(inst, pattern)); matched {
+				return ViolationError{
+					Rule: "ProtectedGroups",
+					Reason: fmt.Sprintf("instance group '%s/%s' matches protected pattern '%s'",
+						inst.Deployment(), inst.Group(), pattern),
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+func (p Policy) checkAllowedTaskTypes(taskTypes []string, _, selected []selector.Instance) error {
+	var patterns []string
+
+	for pattern := range p.config.AllowedTaskTypes {
+		patterns = append(patterns, pattern)
+	}
+
+	sort.Strings(patterns)
+
+	for _, inst := range selected {
+		for _, pattern := range patterns {
+			if matched, _ := filepath.Match(pattern, inst.Deployment()); !matched {
+				continue
+			}
+
+			for _, taskType := range taskTypes {
+				if !contains(p.config.AllowedTaskTypes[pattern], taskType) {
+					return ViolationError{
+						Rule: "AllowedTaskTypes",
+						Reason: fmt.Sprintf("task type '%s' is not allowed in deployment '%s' (pattern '%s' allows: %v)",
+							taskType, inst.Deployment(), pattern, p.config.AllowedTaskTypes[pattern]),
+					}
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+func (p Policy) checkMaxInstancesPerDeployment(_ []string, all, selected []selector.Instance) error {
+	f := func(i selector.Instance) string { return i.Deployment() }
+	return p.checkMax("MaxInstancesPerDeployment", p.config.MaxInstancesPerDeployment, "deployment", f, all, selected)
+}
+
+func (p Policy) checkMaxInstancesPerGroup(_ []string, all, selected []selector.Instance) error {
+	f := func(i selector.Instance) string { return i.Deployment() + "/" + i.Group() }
+	return p.checkMax("MaxInstancesPerGroup", p.config.MaxInstancesPerGroup, "instance group", f, all, selected)
+}
+
+func (p Policy) checkMax(rule, maxStr, desc string, f func(selector.Instance) string, all, selected []selector.Instance) error {
+	m, err := newMax(maxStr)
+	if err != nil || !m.Applied() {
+		return err
+	}
+
+	totals := count(all, f)
+	counts := count(selected, f)
+
+	var names []string
+
+	for name := range counts {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		if counts[name] > m.Allowed(totals[name]) {
+			return ViolationError{
+				Rule: rule,
+				Reason: fmt.Sprintf("selected %d out of %d instances in %s '%s' (at most %s allowed)",
+					counts[name], totals[name], desc, name, m.str),
+			}
+		}
+	}
+
+	return nil
+}
+
+func groupName(inst selector.Instance, pattern string) string {
+	if strings.Contains(pattern, "/") {
+		return inst.Deployment() + "/" + inst.Group()
+	}
+	return inst.Group()
+}
+
+func count(instances []selector.Instance, f func(selector.Instance) string) map[string]int {
+	counts := map[string]int{}
+
+	for _, inst := range instances {
+		counts[f(inst)]++
+	}
+
+	return counts
+}
+
+func contains(strs []string, str string) bool {
+	for _, s := range strs {
+		if s == str {
+			return true
+		}
+	}
+	return false
+}
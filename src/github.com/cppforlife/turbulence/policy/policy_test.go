@@ -0,0 +1,129 @@
+package policy_test
+
+import (
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cppforlife/turbulence/incident/selector"
+	fakeselector "github.com/cppforlife/turbulence/incident/selector/fakes"
+	. "github.com/cppforlife/turbulence/policy"
+)
+
+var _ = Describe("Policy", func() {
+	var (
+		all []selector.Instance
+	)
+
+	BeforeEach(func() {
+		all = nil
+
+		for _, dep := range []string{"cf", "cf-mysql", "bosh"} {
+			for _, group := range []string{"router", "database"} {
+				for _, id := range []string{"id1", "id2", "id3", "id4"} {
+					all = append(all, fakeselector.SimpleInstance{InstanceID: id, GroupName: group, DeploymentName: dep, AZName: "z1"})
+				}
+			}
+		}
+	})
+
+	filter := func(dep, group string) []selector.Instance {
+		var result []selector.Instance
+		for _, inst := range all {
+			if (dep == "" || inst.Deployment() == dep) && (group == "" || inst.Group() == group) {
+				result = append(result, inst)
+			}
+		}
+		return result
+	}
+
+	check := func(config Config, taskTypes []string, selected []selector.Instance) error {
+		Expect(config.Validate()).ToNot(HaveOccurred())
+		return NewPolicy(config, boshlog.NewLogger(boshlog.LevelNone)).Check(taskTypes, all, selected)
+	}
+
+	It("allows everything by default", func() {
+		Expect(check(Config{}, []string{"Kill"}, all)).ToNot(HaveOccurred())
+	})
+
+	It("rejects protected deployments", func() {
+		config := Config{ProtectedDeployments: []string{"bosh*"}}
+
+		Expect(check(config, []string{"Kill"}, filter("cf", ""))).ToNot(HaveOccurred())
+
+		err := check(config, []string{"Kill"}, all)
+		Expect(err).To(Equal(ViolationError{
+			Rule:   "ProtectedDeployments",
+			Reason: "deployment 'bosh' matches protected pattern 'bosh*'",
+		}))
+	})
+
+	It("rejects protected groups optionally scoped to a deployment", func() {
+		config := Config{ProtectedGroups: []string{"cf-*/database"}}
+
+		Expect(check(config, []string{"Kill"}, filter("cf", "database"))).ToNot(HaveOccurred())
+
+		err := check(config, []string{"Kill"}, filter("cf-mysql", "database"))
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal("Policy rule 'ProtectedGroups' was violated: " +
+			"instance group 'cf-mysql/database' matches protected pattern 'cf-*/database'"))
+
+		config = Config{ProtectedGroups: []string{"data*"}}
+
+		err = check(config, []string{"Kill"}, filter("cf", "database"))
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("rejects task types that are not allowed in matching deployments", func() {
+		config := Config{AllowedTaskTypes: map[string][]string{"cf*": {"Stress"}}}
+
+		Expect(check(config, []string{"Stress"}, filter("cf", ""))).ToNot(HaveOccurred())
+		Expect(check(config, []string{"Kill"}, filter("bosh", ""))).ToNot(HaveOccurred())
+
+		err := check(config, []string{"Stress", "Kill"}, filter("cf-mysql", ""))
+		Expect(err).To(HaveOccurred())
+		Expect(err.(ViolationError).Rule).To(Equal("AllowedTaskTypes"))
+		Expect(err.Error()).To(ContainSubstring("task type 'Kill' is not allowed in deployment 'cf-mysql'"))
+	})
+
+	It("rejects more instances per deployment than allowed", func() {
+		config := Config{MaxInstancesPerDeployment: "50%"}
+
+		Expect(check(config, []string{"Kill"}, filter("cf", "router"))).ToNot(HaveOccurred())
+
+		err := check(config, []string{"Kill"}, filter("cf", ""))
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal("Policy rule 'MaxInstancesPerDeployment' was violated: " +
+			"selected 8 out of 8 instances in deployment 'cf' (at most 50% allowed)"))
+	})
+
+	It("rejects more instances per group than allowed", func() {
+		config := Config{MaxInstancesPerGroup: "1"}
+
+		Expect(check(config, []string{"Kill"}, filter("cf", "router")[0:1])).ToNot(HaveOccurred())
+
+		err := check(config, []string{"Kill"}, filter("cf", "router")[0:2])
+		Expect(err).To(HaveOccurred())
+		Expect(err.(ViolationError).Rule).To(Equal("MaxInstancesPerGroup"))
+	})
+
+	It("rounds down allowed percentage", func() {
+		config := Config{MaxInstancesPerGroup: "30%"}
+
+		err := check(config, []string{"Kill"}, filter("cf", "router")[0:2])
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("selected 2 out of 4 instances in instance group 'cf/router'"))
+	})
+
+	Describe("Config", func() {
+		It("rejects invalid limits", func() {
+			Expect(Config{MaxInstancesPerGroup: "abc"}.Validate()).To(HaveOccurred())
+			Expect(Config{MaxInstancesPerDeployment: "101%"}.Validate()).To(HaveOccurred())
+		})
+
+		It("rejects invalid patterns", func() {
+			Expect(Config{ProtectedDeployments: []string{"["}}.Validate()).To(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,191 @@
+package scenario
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	"github.com/cppforlife/turbulence/incident"
+)
+
+type Request struct {
+	Steps []StepRequest
+}
+
+// StepRequest is executed in the following order: previous incidents
+// are stopped, incident is created and then scenario waits.
+type StepRequest struct {
+	// Abort incidents created by previous steps that are still running
+	StopPrevious bool `json:",omitempty"`
+
+	Incident *incident.Request `json:",omitempty"`
+
+	// Times may be suffixed with ms,s,m,h
+	Wait string `json:",omitempty"`
+}
+
+type AbortRequest struct {
+	// Defaults to the authenticated user
+	By string
+}
+
+func (r Request) Validate() error {
+	if len(r.Steps) == 0 {
+		return bosherr.Error("Expected scenario to have at least one step")
+	}
+
+	for i, step := range r.Steps {
+		err := step.Validate()
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Validating step %d", i+1)
+		}
+	}
+
+	return nil
+}
+
+func (r StepRequest) Validate() error {
+	if !r.StopPrevious && r.Incident == nil && len(r.Wait) == 0 {
+		return bosherr.Error("Expected step to specify at least one of StopPrevious, Incident or Wait")
+	}
+
+	if r.Incident != nil {
+		err := r.Incident.Validate()
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err := r.WaitDuration()
+
+	return err
+}
+
+func (r StepRequest) WaitDuration() (time.Duration, error) {
+	if len(r.Wait) == 0 {
+		return 0, nil
+	}
+
+	dur, err := time.ParseDuration(r.Wait)
+	if err != nil {
+		return 0, bosherr.WrapError(err, "Parsing Wait")
+	}
+
+	if dur < 0 {
+		return 0, bosherr.Errorf("Expected Wait '%s' to not be negative", r.Wait)
+	}
+
+	return dur, nil
+}
+
+type Response struct {
+	scenario Scenario
+
+	ID string
+
+	Steps []StepRequest
+
+	ExecutionStartedAt   string
+	ExecutionCompletedAt string
+
+	Interrupted bool
+
+	AbortedAt string
+	AbortedBy string
+
+	Events []EventResponse
+}
+
+type EventResponse struct {
+	Step int
+	Type string
+
+	IncidentID string `json:",omitempty"`
+
+	ExecutionStartedAt   string
+	ExecutionCompletedAt string
+
+	Error string
+}
+
+type Responses []Response
+
+func NewResponses(scenarios []Scenario) Responses {
+	resp := []Response{}
+
+	for _, s := range scenarios {
+		resp = append(resp, NewResponse(s))
+	}
+
+	return resp
+}
+
+func NewResponse(s Scenario) Response {
+	var eventResps []EventResponse
+
+	for _, ev := range s.Events() {
+		eventResps = append(eventResps, EventResponse{
+			Step: ev.Step,
+			Type: ev.Type,
+
+			IncidentID: ev.IncidentID,
+
+			ExecutionStartedAt:   formatTime(ev.ExecutionStartedAt),
+			ExecutionCompletedAt: formatTime(ev.ExecutionCompletedAt),
+
+			Error: ev.Error,
+		})
+	}
+
+	return Response{
+		scenario: s,
+
+		ID: s.ID(),
+
+		Steps: s.Steps,
+
+		ExecutionStartedAt:   formatTime(s.ExecutionStartedAt()),
+		ExecutionCompletedAt: formatTime(s.ExecutionCompletedAt()),
+
+		Interrupted: s.Interrupted(),
+
+		AbortedAt: formatTime(s.AbortedAt()),
+		AbortedBy: s.AbortedBy(),
+
+		Events: eventResps,
+	}
+}
+
+func (r Response) URL() string { return fmt.Sprintf("/scenarios/%s", r.ID) }
+
+func (r Response) NumSteps() int { return len(r.Steps) }
+
+func (r Response) Description() (string, error) {
+	b, err := json.MarshalIndent(Request{Steps: r.Steps}, "", "    ")
+	if err != nil {
+		return "", err
+	}
+
+	return string(b), nil
+}
+
+func (r Response) HasEventErrors() bool {
+	for _, eventResp := range r.Events {
+		if len(eventResp.Error) > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r EventResponse) IncidentURL() string { return fmt.Sprintf("/incidents/%s", r.IncidentID) }
+
+func formatTime(t time.Time) string {
+	if (t == time.Time{}) {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
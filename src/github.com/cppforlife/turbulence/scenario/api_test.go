@@ -0,0 +1,62 @@
+package scenario_test
+
+import (
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cppforlife/turbulence/incident"
+	. "github.com/cppforlife/turbulence/scenario"
+	"github.com/cppforlife/turbulence/tasks"
+)
+
+var _ = Describe("Request", func() {
+	Describe("Validate", func() {
+		It("accepts steps with incidents, waits and stops", func() {
+			str := `
+{
+	"Steps": [
+		{ "Incident": { "Tasks": [{ "Type": "ControlNet", "Timeout": "10m", "Delay": "200ms" }], "Selector": { "Group": { "Name": "database" } } } },
+		{ "Wait": "2m" },
+		{ "Incident": { "Tasks": [{ "Type": "Kill" }], "Selector": { "Group": { "Name": "router" }, "ID": { "Limit": "1" } } }, "Wait": "5m" },
+		{ "StopPrevious": true }
+	]
+}`
+
+			var req Request
+
+			err := json.Unmarshal([]byte(str), &req)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(req.Steps).To(HaveLen(4))
+			Expect(req.Validate()).ToNot(HaveOccurred())
+		})
+
+		It("requires at least one step", func() {
+			err := Request{}.Validate()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Expected scenario to have at least one step"))
+		})
+
+		It("requires each step to do something", func() {
+			err := Request{Steps: []StepRequest{{Wait: "1s"}, {}}}.Validate()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Validating step 2"))
+		})
+
+		It("requires incidents to have valid task options", func() {
+			incReq := incident.Request{Tasks: tasks.OptionsSlice{tasks.FirewallOptions{}}}
+
+			err := Request{Steps: []StepRequest{{Incident: &incReq}}}.Validate()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Validating step 1"))
+			Expect(err.Error()).To(ContainSubstring("Tasks[0].Timeout must be specified"))
+		})
+
+		It("requires wait to be a duration", func() {
+			err := Request{Steps: []StepRequest{{Wait: "2 minutes"}}}.Validate()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Parsing Wait"))
+		})
+	})
+})
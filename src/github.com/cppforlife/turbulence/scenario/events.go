@@ -0,0 +1,88 @@
+package scenario
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	EventTypeStopPrevious = "StopPrevious"
+	EventTypeIncident     = "Incident"
+	EventTypeWait         = "Wait"
+)
+
+type Event struct {
+	// Starts with 1
+	Step int
+	Type string
+
+	IncidentID string `json:",omitempty"`
+
+	ExecutionStartedAt   time.Time
+	ExecutionCompletedAt time.Time
+
+	Error string `json:",omitempty"`
+}
+
+// Events are shared by all copies of a scenario
+type Events struct {
+	events []Event
+	lock   sync.RWMutex
+}
+
+func (e *Events) Add(event Event) int {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	event.ExecutionStartedAt = time.Now().UTC()
+	e.events = append(e.events, event)
+
+	return len(e.events) - 1
+}
+
+func (e *Events) Restore(events []Event) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	e.events = append(e.events, events...)
+}
+
+func (e *Events) SetIncidentID(idx int, id string) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	e.events[idx].IncidentID = id
+}
+
+// MarkError completes event and returns true if there was an error
+func (e *Events) MarkError(idx int, err error) bool {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	e.events[idx].ExecutionCompletedAt = time.Now().UTC()
+
+	if err != nil {
+		e.events[idx].Error = err.Error()
+	}
+
+	return err != nil
+}
+
+func (e *Events) Events() []Event {
+	e.lock.RLock()
+	defer e.lock.RUnlock()
+
+	return append([]Event(nil), e.events...)
+}
+
+func (e *Events) IncidentIDs() []string {
+	var ids []string
+
+	for _, ev := range e.Events() {
+		if len(ev.IncidentID) > 0 {
+			ids = append(ids, ev.IncidentID)
+		}
+	}
+
+	return ids
+}
@@ -0,0 +1,17 @@
+package scenario
+
+type Repo interface {
+	ListAll() ([]Scenario, error)
+	Create(Request) (Scenario, error)
+	Read(string) (Scenario, error)
+	Abort(string, AbortRequest) (Scenario, error)
+
+	// Running scenarios are aborted and new ones cannot be created
+	// while repo is paused (e.g. by emergency stop)
+	Pause()
+	Resume()
+}
+
+type RepoNotifier interface {
+	ScenarioWasCreated(Scenario)
+}
@@ -0,0 +1,295 @@
+package scenario
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
+
+	"github.com/cppforlife/turbulence/execution"
+	"github.com/cppforlife/turbulence/incident"
+	"github.com/cppforlife/turbulence/store"
+)
+
+const emergencyStopBy = "emergency stop"
+
+type NotFoundError struct {
+	ID string
+}
+
+func (e NotFoundError) Error() string {
+	return fmt.Sprintf("Scenario '%s' does not exist", e.ID)
+}
+
+type repo struct {
+	uuidGen       boshuuid.Generator
+	notifier      RepoNotifier
+	incidentsRepo incident.Repo
+	journal       store.Journal
+
+	scenarios     []Scenario
+	scenariosLock sync.RWMutex
+
+	paused bool
+
+	logTag string
+	logger boshlog.Logger
+}
+
+// record is a persisted representation of a scenario
+type record struct {
+	ID string
+
+	Steps []StepRequest
+
+	execution.Record
+
+	Events []Event
+}
+
+func NewRepo(
+	uuidGen boshuuid.Generator,
+	notifier RepoNotifier,
+	incidentsRepo incident.Repo,
+	journal store.Journal,
+	logger boshlog.Logger,
+) (Repo, error) {
+	r := &repo{
+		uuidGen:       uuidGen,
+		notifier:      notifier,
+		incidentsRepo: incidentsRepo,
+		journal:       journal,
+
+		logTag: "scenario.repo",
+		logger: logger,
+	}
+
+	err := r.load()
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Loading scenarios")
+	}
+
+	return r, nil
+}
+
+func (r *repo) ListAll() ([]Scenario, error) {
+	r.scenariosLock.RLock()
+	defer r.scenariosLock.RUnlock()
+
+	var reversed []Scenario
+
+	for i := len(r.scenarios) - 1; i >= 0; i-- {
+		reversed = append(reversed, r.scenarios[i])
+	}
+
+	return reversed, nil
+}
+
+func (r *repo) Create(req Request) (Scenario, error) {
+	err := req.Validate()
+	if err != nil {
+		return Scenario{}, bosherr.WrapError(err, "Validating scenario")
+	}
+
+	id, err := r.uuidGen.Generate()
+	if err != nil {
+		return Scenario{}, bosherr.WrapError(err, "Generating scenario ID")
+	}
+
+	scenario := r.newScenario(id)
+	scenario.Steps = req.Steps
+
+	r.scenariosLock.Lock()
+
+	if r.paused {
+		r.scenariosLock.Unlock()
+		return Scenario{}, bosherr.Error("Scenarios cannot be created while emergency stop is active")
+	}
+
+	err = r.journal.Save(id, newRecord(scenario))
+	if err != nil {
+		r.scenariosLock.Unlock()
+		return Scenario{}, bosherr.WrapError(err, "Saving scenario")
+	}
+
+	r.scenarios = append(r.scenarios, scenario)
+	r.scenariosLock.Unlock()
+
+	// notified after scenarios were unlocked
+	go r.notifier.ScenarioWasCreated(scenario)
+
+	return scenario, nil
+}
+
+func (r *repo) Read(id string) (Scenario, error) {
+	r.scenariosLock.RLock()
+	defer r.scenariosLock.RUnlock()
+
+	for _, scenario := range r.scenarios {
+		if scenario.ID() == id {
+			return scenario, nil
+		}
+	}
+
+	return Scenario{}, NotFoundError{ID: id}
+}
+
+func (r *repo) Abort(id string, req AbortRequest) (Scenario, error) {
+	scenario, err := r.Read(id)
+	if err != nil {
+		return Scenario{}, err
+	}
+
+	err = scenario.abort(req.By)
+	if err != nil {
+		return Scenario{}, err
+	}
+
+	// Save latest copy since executing scenario may have updated it
+	scenario, err = r.Read(id)
+	if err != nil {
+		return Scenario{}, err
+	}
+
+	err = r.journal.Save(scenario.ID(), newRecord(scenario))
+	if err != nil {
+		return Scenario{}, bosherr.WrapError(err, "Saving aborted scenario")
+	}
+
+	return scenario, nil
+}
+
+// Pause aborts running scenarios and prevents new ones from being created
+func (r *repo) Pause() {
+	r.scenariosLock.Lock()
+	r.paused = true
+	scenarios := append([]Scenario(nil), r.scenarios...)
+	r.scenariosLock.Unlock()
+
+	for _, scenario := range scenarios {
+		if (scenario.ExecutionCompletedAt() != time.Time{}) {
+			continue
+		}
+
+		_, err := r.Abort(scenario.ID(), AbortRequest{By: emergencyStopBy})
+		if err != nil {
+			if _, ok := err.(ScenarioCompletedError); !ok {
+				r.logger.Error(r.logTag, "Failed to abort scenario '%s': %s", scenario.ID(), err.Error())
+			}
+		}
+	}
+}
+
+func (r *repo) Resume() {
+	r.scenariosLock.Lock()
+	r.paused = false
+	r.scenariosLock.Unlock()
+}
+
+func (r *repo) update(updated Scenario) error {
+	r.scenariosLock.Lock()
+	defer r.scenariosLock.Unlock()
+
+	for i, scenario := range r.scenarios {
+		if scenario.ID() == updated.ID() {
+			r.scenarios[i] = updated
+			break
+		}
+	}
+
+	return r.journal.Save(updated.ID(), newRecord(updated))
+}
+
+func (r *repo) newScenario(id string) Scenario {
+	return Scenario{
+		incidentsRepo: r.incidentsRepo,
+		updateFunc:    r.update,
+
+		id: id,
+
+		abortion: execution.NewAbortion(),
+		events:   &Events{},
+
+		logTag: "scenario.Scenario",
+		logger: r.logger,
+	}
+}
+
+// load restores previously saved scenarios; scenarios that were executing
+// when API server stopped are marked as interrupted since they cannot be resumed.
+func (r *repo) load() error {
+	entries, err := r.journal.Load()
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		var rec record
+
+		err := json.Unmarshal(entry.Record, &rec)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Unmarshalling scenario '%s'", entry.ID)
+		}
+
+		if rec.IsRunning() {
+			r.logger.Info(r.logTag, "Marking scenario '%s' as interrupted", rec.ID)
+
+			rec.Interrupt()
+
+			err = r.journal.Save(rec.ID, rec)
+			if err != nil {
+				return bosherr.WrapErrorf(err, "Saving interrupted scenario '%s'", rec.ID)
+			}
+		}
+
+		scenario := r.newScenario(rec.ID)
+		scenario.Steps = rec.Steps
+		scenario.executionStartedAt = rec.ExecutionStartedAt
+		scenario.executionCompletedAt = rec.ExecutionCompletedAt
+		scenario.interrupted = rec.Interrupted
+		scenario.events.Restore(rec.Events)
+
+		if (rec.AbortedAt != time.Time{}) {
+			scenario.abortion.Abort(rec.AbortedAt, rec.AbortedBy)
+		}
+
+		r.scenarios = append(r.scenarios, scenario)
+	}
+
+	r.logger.Debug(r.logTag, "Loaded '%d' scenarios", len(r.scenarios))
+
+	return nil
+}
+
+func newRecord(s Scenario) record {
+	return record{
+		ID: s.id,
+
+		Steps: s.Steps,
+
+		Record: execution.Record{
+			ExecutionStartedAt:   s.executionStartedAt,
+			ExecutionCompletedAt: s.executionCompletedAt,
+
+			Interrupted: s.interrupted,
+
+			AbortedAt: s.AbortedAt(),
+			AbortedBy: s.AbortedBy(),
+		},
+
+		Events: s.Events(),
+	}
+}
+
+// Interrupt marks scenario and all its unfinished events as interrupted
+func (r *record) Interrupt() {
+	now := r.Record.Interrupt()
+
+	for i := range r.Events {
+		execution.InterruptEvent(&r.Events[i].ExecutionCompletedAt, &r.Events[i].Error, now)
+	}
+}
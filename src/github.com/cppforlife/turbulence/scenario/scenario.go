@@ -0,0 +1,39 @@
+package scenario
+
+import (
+	"time"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+
+	"github.com/cppforlife/turbulence/execution"
+	"github.com/cppforlife/turbulence/incident"
+)
+
+type Scenario struct {
+	incidentsRepo incident.Repo
+	updateFunc    func(Scenario) error
+
+	id string
+
+	Steps []StepRequest
+
+	executionStartedAt   time.Time
+	executionCompletedAt time.Time
+
+	// Set when API server stopped before scenario completed
+	interrupted bool
+
+	abortion *execution.Abortion
+
+	events *Events
+
+	logTag string
+	logger boshlog.Logger
+}
+
+func (s Scenario) ID() string      { return s.id }
+func (s Scenario) Events() []Event { return s.events.Events() }
+
+func (s Scenario) ExecutionStartedAt() time.Time   { return s.executionStartedAt }
+func (s Scenario) ExecutionCompletedAt() time.Time { return s.executionCompletedAt }
+func (s Scenario) Interrupted() bool               { return s.interrupted }
@@ -0,0 +1,59 @@
+package scenario
+
+import (
+	"fmt"
+	"time"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	"github.com/cppforlife/turbulence/incident"
+)
+
+type ScenarioCompletedError struct {
+	ID string
+}
+
+func (e ScenarioCompletedError) Error() string {
+	return fmt.Sprintf("Scenario '%s' already completed", e.ID)
+}
+
+func (s Scenario) AbortedAt() time.Time { return s.abortion.At() }
+func (s Scenario) AbortedBy() string    { return s.abortion.By() }
+func (s Scenario) IsAborted() bool      { return (s.AbortedAt() != time.Time{}) }
+
+// AbortCh is closed once scenario is aborted
+func (s Scenario) AbortCh() <-chan struct{} { return s.abortion.Ch() }
+
+func (s Scenario) abort(by string) error {
+	if (s.executionCompletedAt != time.Time{}) {
+		return ScenarioCompletedError{ID: s.id}
+	}
+
+	if !s.abortion.Abort(time.Now().UTC(), by) {
+		return nil // already aborted
+	}
+
+	s.logger.Info(s.logTag, "Aborting scenario '%s' by '%s'", s.id, by)
+
+	return s.stopIncidents(by)
+}
+
+// stopIncidents aborts incidents created by executed steps;
+// incidents that already completed are left alone
+func (s Scenario) stopIncidents(by string) error {
+	var firstErr error
+
+	for _, id := range s.events.IncidentIDs() {
+		_, err := s.incidentsRepo.Abort(id, incident.AbortRequest{By: by})
+		if err != nil {
+			if _, ok := err.(incident.IncidentCompletedError); ok {
+				continue
+			}
+			if firstErr == nil {
+				firstErr = bosherr.WrapErrorf(err, "Aborting incident '%s'", id)
+			}
+		}
+	}
+
+	return firstErr
+}
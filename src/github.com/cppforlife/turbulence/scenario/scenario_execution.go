@@ -0,0 +1,116 @@
+package scenario
+
+import (
+	"fmt"
+	"time"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+func (s Scenario) Execute() error {
+	s.logger.Debug(s.logTag, "Executing scenario '%s'", s.id)
+	s.executionStartedAt = time.Now().UTC()
+
+	err := s.updateFunc(s)
+	if err != nil {
+		return bosherr.Errorf("Updating execution started at")
+	}
+
+	err = s.executeSteps()
+	if err != nil && !s.IsAborted() {
+		// Avoid leaving chaos behind when scenario cannot continue
+		stopErr := s.stopIncidents(fmt.Sprintf("scenario '%s'", s.id))
+		if stopErr != nil {
+			s.logger.Error(s.logTag, "Failed to stop incidents of scenario '%s': %s", s.id, stopErr.Error())
+		}
+	}
+
+	s.executionCompletedAt = time.Now().UTC()
+	s.update()
+
+	s.logger.Debug(s.logTag, "Scenario '%s' completed", s.id)
+
+	return err
+}
+
+func (s Scenario) executeSteps() error {
+	for i, step := range s.Steps {
+		if s.IsAborted() {
+			s.logger.Debug(s.logTag, "Skipping remaining steps since scenario '%s' was aborted", s.id)
+			return nil
+		}
+
+		err := s.executeStep(i+1, step)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Executing step %d", i+1)
+		}
+	}
+
+	return nil
+}
+
+func (s Scenario) executeStep(num int, step StepRequest) error {
+	if step.StopPrevious {
+		idx := s.events.Add(Event{Step: num, Type: EventTypeStopPrevious})
+		s.update()
+
+		err := s.stopIncidents(fmt.Sprintf("scenario '%s'", s.id))
+		if s.markError(idx, err) {
+			return err
+		}
+	}
+
+	if step.Incident != nil {
+		idx := s.events.Add(Event{Step: num, Type: EventTypeIncident})
+
+		incid, err := s.incidentsRepo.Create(*step.Incident)
+		if err == nil {
+			s.events.SetIncidentID(idx, incid.ID())
+
+			// Scenario may have been aborted while incident was being created
+			if s.IsAborted() {
+				err = s.stopIncidents(s.AbortedBy())
+			}
+		}
+
+		if s.markError(idx, err) {
+			return err
+		}
+	}
+
+	dur, err := step.WaitDuration()
+	if err != nil {
+		return err
+	}
+
+	if dur > 0 {
+		idx := s.events.Add(Event{Step: num, Type: EventTypeWait})
+		s.update()
+
+		select {
+		case <-time.After(dur):
+			s.markError(idx, nil)
+		case <-s.AbortCh():
+			s.markError(idx, s.abortedErr())
+		}
+	}
+
+	return nil
+}
+
+func (s Scenario) markError(idx int, err error) bool {
+	failed := s.events.MarkError(idx, err)
+	s.update()
+	return failed
+}
+
+func (s Scenario) abortedErr() error {
+	return bosherr.Errorf("Scenario was aborted by '%s'", s.AbortedBy())
+}
+
+func (s Scenario) update() {
+	err := s.updateFunc(s)
+	if err != nil {
+		s.logger.Error(s.logTag, "Failed to update scenario '%s': %s", s.id, err.Error())
+	}
+}
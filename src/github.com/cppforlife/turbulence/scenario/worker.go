@@ -0,0 +1,21 @@
+package scenario
+
+import (
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+type Worker struct {
+	logTag string
+	logger boshlog.Logger
+}
+
+func NewWorker(logger boshlog.Logger) Worker {
+	return Worker{logTag: "scenario.Worker", logger: logger}
+}
+
+func (w Worker) ScenarioWasCreated(scenario Scenario) {
+	err := scenario.Execute()
+	if err != nil {
+		w.logger.Error(w.logTag, "Failed to execute scenario: %s", err.Error())
+	}
+}
@@ -3,8 +3,12 @@ package scheduledinc
 import (
 	"encoding/json"
 	"fmt"
+	"time"
+
+	"github.com/robfig/cron"
 
 	"github.com/cppforlife/turbulence/incident"
+	"github.com/cppforlife/turbulence/tasks"
 )
 
 type Request struct {
@@ -12,11 +16,45 @@ type Request struct {
 	Incident incident.Request
 }
 
+func (r Request) Validate() error {
+	var fieldErrs []tasks.FieldError
+
+	if len(r.Schedule) == 0 {
+		fieldErrs = append(fieldErrs, tasks.FieldError{Field: "Schedule", Message: "must be specified"})
+	} else if _, err := cron.Parse(r.Schedule); err != nil {
+		fieldErrs = append(fieldErrs, tasks.FieldError{Field: "Schedule", Message: err.Error()})
+	}
+
+	if err := r.Incident.Validate(); err != nil {
+		valErr, ok := err.(tasks.ValidationError)
+		if !ok {
+			return err
+		}
+		fieldErrs = append(fieldErrs, valErr.Nested("Incident").Errors...)
+	}
+
+	if len(fieldErrs) > 0 {
+		return tasks.ValidationError{Errors: fieldErrs}
+	}
+
+	return nil
+}
+
 type Response struct {
 	ID string
 
 	Schedule string
 	Incident incident.Request
+
+	Runs []RunResponse
+}
+
+type RunResponse struct {
+	At string
+
+	IncidentID string
+	SkippedBy  string
+	Error      string
 }
 
 type Responses []Response
@@ -32,11 +70,28 @@ func NewResponses(sis []ScheduledIncident) Responses {
 }
 
 func NewResponse(si ScheduledIncident) Response {
+	var runResps []RunResponse
+
+	// Most recent runs first
+	runs := si.Runs()
+
+	for i := len(runs) - 1; i >= 0; i-- {
+		runResps = append(runResps, RunResponse{
+			At: runs[i].At.Format(time.RFC3339),
+
+			IncidentID: runs[i].IncidentID,
+			SkippedBy:  runs[i].SkippedBy,
+			Error:      runs[i].Error,
+		})
+	}
+
 	return Response{
 		ID: si.ID,
 
 		Schedule: si.Schedule,
 		Incident: si.Incident,
+
+		Runs: runResps,
 	}
 }
 
@@ -44,6 +99,10 @@ func (r Response) URL() string {
 	return fmt.Sprintf("/scheduled_incidents/%s", r.ID)
 }
 
+func (r RunResponse) IncidentURL() string {
+	return fmt.Sprintf("/incidents/%s", r.IncidentID)
+}
+
 func (r Response) Description() (string, error) {
 	b, err := json.MarshalIndent(r.Incident, "", "    ")
 	if err != nil {
@@ -1,6 +1,7 @@
 package scheduledinc
 
 import (
+	"encoding/json"
 	"fmt"
 	"sync"
 
@@ -9,6 +10,7 @@ import (
 	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
 
 	"github.com/cppforlife/turbulence/incident"
+	"github.com/cppforlife/turbulence/store"
 )
 
 func (e NotFoundError) Error() string {
@@ -19,25 +21,48 @@ type repo struct {
 	uuidGen       boshuuid.Generator
 	notifier      RepoNotifier
 	incidentsRepo incident.Repo
+	journal       store.Journal
 
 	sis     []ScheduledIncident
 	sisLock sync.RWMutex
 
+	logTag string
 	logger boshlog.Logger
 }
 
+// record is a persisted representation of a scheduled incident
+type record struct {
+	ID string
+
+	Schedule string
+	Incident incident.Request
+
+	Runs []Run `json:",omitempty"`
+}
+
 func NewRepo(
 	uuidGen boshuuid.Generator,
 	notifier RepoNotifier,
 	incidentsRepo incident.Repo,
+	journal store.Journal,
 	logger boshlog.Logger,
-) Repo {
-	return &repo{
+) (Repo, error) {
+	r := &repo{
 		uuidGen:       uuidGen,
 		notifier:      notifier,
 		incidentsRepo: incidentsRepo,
-		logger:        logger,
+		journal:       journal,
+
+		logTag: "scheduledinc.repo",
+		logger: logger,
+	}
+
+	err := r.load()
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Loading scheduled incidents")
 	}
+
+	return r, nil
 }
 
 func (r *repo) ListAll() ([]ScheduledIncident, error) {
@@ -46,20 +71,26 @@ func (r *repo) ListAll() ([]ScheduledIncident, error) {
 }
 
 func (r *repo) Create(req Request) (ScheduledIncident, error) {
+	err := req.Validate()
+	if err != nil {
+		return ScheduledIncident{}, err
+	}
+
 	uuid, err := r.uuidGen.Generate()
 	if err != nil {
 		return ScheduledIncident{}, bosherr.WrapError(err, "Generating scheduled incident ID")
 	}
 
-	scheduledIncident := ScheduledIncident{
-		updateFunc:    r.update,
-		incidentsRepo: r.incidentsRepo,
-		logger:        r.logger,
-
+	scheduledIncident := r.newScheduledIncident(record{
 		ID: uuid,
 
 		Schedule: req.Schedule,
 		Incident: req.Incident,
+	})
+
+	err = r.journal.Save(uuid, newRecord(scheduledIncident))
+	if err != nil {
+		return ScheduledIncident{}, bosherr.WrapError(err, "Saving scheduled incident")
 	}
 
 	r.sisLock.Lock()
@@ -86,25 +117,32 @@ func (r *repo) Read(id string) (ScheduledIncident, error) {
 }
 
 func (r *repo) Delete(id string) error {
-	var deletedSi ScheduledIncident
-
 	r.sisLock.Lock()
 
 	for i, si := range r.sis {
-		if si.ID == id {
-			deletedSi = si
-			r.sis = append(r.sis[:i], r.sis[i+1:]...)
-			break
+		if si.ID != id {
+			continue
 		}
-	}
 
-	r.sisLock.Unlock()
+		// Kept in memory when it cannot be deleted from journal
+		// since it would be loaded and scheduled again after restart
+		err := r.journal.Delete(si.ID)
+		if err != nil {
+			r.sisLock.Unlock()
+			return bosherr.WrapErrorf(err, "Deleting scheduled incident '%s'", si.ID)
+		}
 
-	// notified after scheduled incidents were unlocked
-	if len(deletedSi.ID) > 0 {
-		go r.notifier.ScheduledIncidentWasDeleted(deletedSi)
+		r.sis = append(r.sis[:i], r.sis[i+1:]...)
+		r.sisLock.Unlock()
+
+		// notified after scheduled incidents were unlocked
+		go r.notifier.ScheduledIncidentWasDeleted(si)
+
+		return nil
 	}
 
+	r.sisLock.Unlock()
+
 	return nil
 }
 
@@ -115,9 +153,68 @@ func (r *repo) update(updated ScheduledIncident) error {
 	for i, incident := range r.sis {
 		if incident.ID == updated.ID {
 			r.sis[i] = updated
-			break
+
+			return r.journal.Save(updated.ID, newRecord(updated))
 		}
 	}
 
+	// Scheduled incident may have been deleted while it was running
 	return nil
 }
+
+func (r *repo) newScheduledIncident(rec record) ScheduledIncident {
+	runs := &Runs{}
+	runs.Restore(rec.Runs)
+
+	return ScheduledIncident{
+		updateFunc:    r.update,
+		incidentsRepo: r.incidentsRepo,
+		logger:        r.logger,
+
+		ID: rec.ID,
+
+		Schedule: rec.Schedule,
+		Incident: rec.Incident,
+
+		runs: runs,
+	}
+}
+
+// load restores previously saved scheduled incidents
+// and lets notifier know about them so that they are scheduled again.
+func (r *repo) load() error {
+	entries, err := r.journal.Load()
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		var rec record
+
+		err := json.Unmarshal(entry.Record, &rec)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Unmarshalling scheduled incident '%s'", entry.ID)
+		}
+
+		r.sis = append(r.sis, r.newScheduledIncident(rec))
+	}
+
+	r.logger.Debug(r.logTag, "Loaded '%d' scheduled incidents", len(r.sis))
+
+	for _, si := range r.sis {
+		r.notifier.ScheduledIncidentWasCreated(si)
+	}
+
+	return nil
+}
+
+func newRecord(si ScheduledIncident) record {
+	return record{
+		ID: si.ID,
+
+		Schedule: si.Schedule,
+		Incident: si.Incident,
+
+		Runs: si.Runs(),
+	}
+}
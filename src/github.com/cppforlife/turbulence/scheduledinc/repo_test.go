@@ -0,0 +1,151 @@
+package scheduledinc_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	fakeuuid "github.com/cloudfoundry/bosh-utils/uuid/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cppforlife/turbulence/incident"
+	. "github.com/cppforlife/turbulence/scheduledinc"
+	"github.com/cppforlife/turbulence/store"
+	"github.com/cppforlife/turbulence/tasks"
+)
+
+type fakeNotifier struct {
+	lock    sync.Mutex
+	created []string
+	deleted []string
+}
+
+func (n *fakeNotifier) ScheduledIncidentWasCreated(si ScheduledIncident) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	n.created = append(n.created, si.ID)
+}
+
+func (n *fakeNotifier) ScheduledIncidentWasDeleted(si ScheduledIncident) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	n.deleted = append(n.deleted, si.ID)
+}
+
+func (n *fakeNotifier) Created() []string {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	return append([]string(nil), n.created...)
+}
+
+func (n *fakeNotifier) Deleted() []string {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	return append([]string(nil), n.deleted...)
+}
+
+// failingJournal fails deleting records but otherwise saves them into the file journal
+type failingJournal struct {
+	store.Journal
+}
+
+func (failingJournal) Delete(string) error { return errors.New("fake-err") }
+
+var _ = Describe("Repo", func() {
+	var (
+		dir      string
+		logger   boshlog.Logger
+		journal  store.Journal
+		notifier *fakeNotifier
+		uuidGen  *fakeuuid.FakeGenerator
+	)
+
+	req := Request{
+		Schedule: "@every 1h",
+		Incident: incident.Request{Tasks: tasks.OptionsSlice{tasks.KillOptions{}}},
+	}
+
+	BeforeEach(func() {
+		var err error
+
+		dir, err = ioutil.TempDir("", "scheduledinc-test")
+		Expect(err).ToNot(HaveOccurred())
+
+		logger = boshlog.NewLogger(boshlog.LevelNone)
+		journal = store.NewFileJournal(filepath.Join(dir, "scheduled_incidents.json"), boshsys.NewOsFileSystem(logger), logger)
+		notifier = &fakeNotifier{}
+		uuidGen = fakeuuid.NewFakeGenerator()
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	newRepo := func(journal store.Journal) Repo {
+		repo, err := NewRepo(uuidGen, notifier, nil, journal, logger)
+		Expect(err).ToNot(HaveOccurred())
+		return repo
+	}
+
+	It("replays saved scheduled incidents and schedules them again", func() {
+		uuidGen.GeneratedUUID = "si-1"
+		_, err := newRepo(journal).Create(req)
+		Expect(err).ToNot(HaveOccurred())
+
+		notifier = &fakeNotifier{}
+		repo := newRepo(journal)
+
+		si, err := repo.Read("si-1")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(si.Schedule).To(Equal("@every 1h"))
+		Expect(si.Incident.Tasks).To(Equal(tasks.OptionsSlice{tasks.KillOptions{Type: "Kill"}}))
+
+		Expect(notifier.Created()).To(Equal([]string{"si-1"}))
+	})
+
+	It("does not replay deleted scheduled incidents", func() {
+		repo := newRepo(journal)
+
+		uuidGen.GeneratedUUID = "si-1"
+		_, err := repo.Create(req)
+		Expect(err).ToNot(HaveOccurred())
+
+		uuidGen.GeneratedUUID = "si-2"
+		_, err = repo.Create(req)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(repo.Delete("si-1")).To(Succeed())
+		Eventually(notifier.Deleted).Should(Equal([]string{"si-1"}))
+
+		sis, err := newRepo(journal).ListAll()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(sis).To(HaveLen(1))
+		Expect(sis[0].ID).To(Equal("si-2"))
+	})
+
+	It("keeps scheduled incident if it cannot be deleted from journal", func() {
+		repo := newRepo(failingJournal{journal})
+
+		uuidGen.GeneratedUUID = "si-1"
+		_, err := repo.Create(req)
+		Expect(err).ToNot(HaveOccurred())
+
+		err = repo.Delete("si-1")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("fake-err"))
+
+		_, err = repo.Read("si-1")
+		Expect(err).ToNot(HaveOccurred())
+
+		Consistently(notifier.Deleted).Should(BeEmpty())
+	})
+
+	It("ignores deleting scheduled incidents that do not exist", func() {
+		Expect(newRepo(journal).Delete("si-1")).To(Succeed())
+	})
+})
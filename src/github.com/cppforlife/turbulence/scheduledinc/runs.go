@@ -0,0 +1,55 @@
+package scheduledinc
+
+import (
+	"sync"
+	"time"
+)
+
+// Only most recent runs are kept
+const maxRuns = 50
+
+type Run struct {
+	At time.Time
+
+	IncidentID string `json:",omitempty"`
+
+	// Description of the blackout window when run was skipped
+	SkippedBy string `json:",omitempty"`
+
+	Error string `json:",omitempty"`
+}
+
+// Runs are shared by all copies of a scheduled incident
+type Runs struct {
+	runs []Run
+	lock sync.RWMutex
+}
+
+func (r *Runs) Add(run Run) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.runs = append(r.runs, run)
+	r.trim()
+}
+
+func (r *Runs) Restore(runs []Run) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.runs = append(r.runs, runs...)
+	r.trim()
+}
+
+func (r *Runs) Runs() []Run {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	return append([]Run(nil), r.runs...)
+}
+
+func (r *Runs) trim() {
+	if len(r.runs) > maxRuns {
+		r.runs = r.runs[len(r.runs)-maxRuns:]
+	}
+}
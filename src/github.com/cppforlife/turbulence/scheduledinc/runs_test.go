@@ -0,0 +1,37 @@
+package scheduledinc_test
+
+import (
+	"fmt"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cppforlife/turbulence/scheduledinc"
+)
+
+var _ = Describe("Runs", func() {
+	newRuns := func(from, to int) []Run {
+		var runs []Run
+		for i := from; i < to; i++ {
+			runs = append(runs, Run{IncidentID: fmt.Sprintf("incident-%d", i)})
+		}
+		return runs
+	}
+
+	It("keeps only most recent runs when adding", func() {
+		runs := &Runs{}
+
+		for _, run := range newRuns(0, 60) {
+			runs.Add(run)
+		}
+
+		Expect(runs.Runs()).To(Equal(newRuns(10, 60)))
+	})
+
+	It("keeps only most recent runs when restoring", func() {
+		runs := &Runs{}
+		runs.Restore(newRuns(0, 60))
+
+		Expect(runs.Runs()).To(Equal(newRuns(10, 60)))
+	})
+})
@@ -1,9 +1,12 @@
 package scheduledinc
 
 import (
+	"time"
+
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 
+	"github.com/cppforlife/turbulence/blackout"
 	"github.com/cppforlife/turbulence/incident"
 )
 
@@ -17,14 +20,44 @@ type ScheduledIncident struct {
 	Schedule string
 
 	Incident incident.Request
+
+	runs *Runs
 }
 
+func (si ScheduledIncident) Runs() []Run { return si.runs.Runs() }
+
 func (si ScheduledIncident) Execute() error {
-	_, err := si.incidentsRepo.Create(si.Incident)
+	incid, err := si.incidentsRepo.Create(si.Incident)
 	if err != nil {
+		if blackoutErr, ok := err.(blackout.ActiveError); ok {
+			si.Skip(blackoutErr.Window)
+			return nil
+		}
+
+		si.recordRun(Run{At: time.Now().UTC(), Error: err.Error()})
+
 		return bosherr.WrapErrorf(err,
 			"Creating incident based on scheduled incident ID '%s'", si.ID)
 	}
 
+	si.recordRun(Run{At: time.Now().UTC(), IncidentID: incid.ID()})
+
 	return nil
 }
+
+// Skip records that run did not happen because of a blackout window
+func (si ScheduledIncident) Skip(window blackout.Window) {
+	si.logger.Info("ScheduledIncident", "Skipping scheduled incident '%s' during blackout window %s",
+		si.ID, window.Description())
+
+	si.recordRun(Run{At: time.Now().UTC(), SkippedBy: window.Description()})
+}
+
+func (si ScheduledIncident) recordRun(run Run) {
+	si.runs.Add(run)
+
+	err := si.updateFunc(si)
+	if err != nil {
+		si.logger.Error("ScheduledIncident", "Failed to update scheduled incident '%s': %s", si.ID, err.Error())
+	}
+}
@@ -2,12 +2,17 @@ package scheduledinc
 
 import (
 	"sync"
+	"time"
 
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	"github.com/robfig/cron"
+
+	"github.com/cppforlife/turbulence/blackout"
 )
 
 type Scheduler struct {
+	calendar blackout.Calendar
+
 	cron      *cron.Cron
 	cronReset chan struct{}
 
@@ -15,13 +20,21 @@ type Scheduler struct {
 	items     map[string]ScheduledIncident
 	itemsLock sync.RWMutex
 
+	// Paused scheduler does not run any scheduled incidents
+	paused     bool
+	pausedLock sync.RWMutex
+
 	logTag string
 	logger boshlog.Logger
 }
 
-func NewScheduler(logger boshlog.Logger) *Scheduler {
+func NewScheduler(calendar blackout.Calendar, logger boshlog.Logger) *Scheduler {
 	return &Scheduler{
-		cronReset: make(chan struct{}),
+		calendar: calendar,
+
+		// Buffered so that reset requested while cron is being reset
+		// (e.g. when scheduled incidents are loaded on start) is not lost
+		cronReset: make(chan struct{}, 1),
 
 		items: map[string]ScheduledIncident{},
 
@@ -36,7 +49,7 @@ func (s *Scheduler) Run() {
 	}
 }
 
-func (s Scheduler) ScheduledIncidentWasCreated(si ScheduledIncident) {
+func (s *Scheduler) ScheduledIncidentWasCreated(si ScheduledIncident) {
 	s.itemsLock.Lock()
 	s.items[si.ID] = si
 	s.itemsLock.Unlock()
@@ -44,7 +57,7 @@ func (s Scheduler) ScheduledIncidentWasCreated(si ScheduledIncident) {
 	s.signalCronReset()
 }
 
-func (s Scheduler) ScheduledIncidentWasDeleted(si ScheduledIncident) {
+func (s *Scheduler) ScheduledIncidentWasDeleted(si ScheduledIncident) {
 	s.itemsLock.Lock()
 	delete(s.items, si.ID)
 	s.itemsLock.Unlock()
@@ -52,7 +65,34 @@ func (s Scheduler) ScheduledIncidentWasDeleted(si ScheduledIncident) {
 	s.signalCronReset()
 }
 
-func (s Scheduler) signalCronReset() {
+func (s *Scheduler) Pause() {
+	s.pausedLock.Lock()
+	s.paused = true
+	s.pausedLock.Unlock()
+
+	s.logger.Info(s.logTag, "Pausing scheduled incidents")
+
+	s.signalCronReset()
+}
+
+func (s *Scheduler) Resume() {
+	s.pausedLock.Lock()
+	s.paused = false
+	s.pausedLock.Unlock()
+
+	s.logger.Info(s.logTag, "Resuming scheduled incidents")
+
+	s.signalCronReset()
+}
+
+func (s *Scheduler) IsPaused() bool {
+	s.pausedLock.RLock()
+	defer s.pausedLock.RUnlock()
+
+	return s.paused
+}
+
+func (s *Scheduler) signalCronReset() {
 	select {
 	case s.cronReset <- struct{}{}:
 		// signalled
@@ -68,10 +108,24 @@ func (s *Scheduler) performCronReset() {
 
 	s.cron = cron.New()
 
+	if s.IsPaused() {
+		return
+	}
+
 	s.itemsLock.Lock()
 
 	for _, si := range s.items {
 		s.cron.AddFunc(si.Schedule, func() {
+			// Pausing may happen before cron is reset
+			if s.IsPaused() {
+				return
+			}
+
+			if window, active := s.calendar.Active(time.Now()); active {
+				si.Skip(window)
+				return
+			}
+
 			err := si.Execute()
 			if err != nil {
 				s.logger.Error(s.logTag, "Failed to queue up scheduled incident: %s", err.Error())
@@ -0,0 +1,72 @@
+package scheduledinc_test
+
+import (
+	"sync"
+	"time"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cppforlife/turbulence/blackout"
+	"github.com/cppforlife/turbulence/incident"
+	. "github.com/cppforlife/turbulence/scheduledinc"
+	"github.com/cppforlife/turbulence/store"
+	"github.com/cppforlife/turbulence/tasks"
+)
+
+type fakeIncidentsRepo struct {
+	incident.Repo
+
+	lock    sync.Mutex
+	created int
+}
+
+func (r *fakeIncidentsRepo) Create(incident.Request) (incident.Incident, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.created++
+	return incident.Incident{}, nil
+}
+
+func (r *fakeIncidentsRepo) Created() int {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.created
+}
+
+var _ = Describe("Scheduler", func() {
+	It("skips scheduled incidents during active blackout window", func() {
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+
+		calendar, err := blackout.NewCalendar(blackout.Config{
+			Windows: []blackout.WindowConfig{{Name: "freeze", From: "2000-01-01T00:00", To: "2100-01-01T00:00"}},
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		scheduler := NewScheduler(calendar, logger)
+		go scheduler.Run()
+		defer scheduler.Pause()
+
+		incidentsRepo := &fakeIncidentsRepo{}
+
+		repo, err := NewRepo(boshuuid.NewGenerator(), scheduler, incidentsRepo, store.NewMemoryJournal(), logger)
+		Expect(err).ToNot(HaveOccurred())
+
+		si, err := repo.Create(Request{
+			Schedule: "@every 1s",
+			Incident: incident.Request{Tasks: tasks.OptionsSlice{tasks.KillOptions{}}},
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		Eventually(si.Runs, 5*time.Second).ShouldNot(BeEmpty())
+
+		run := si.Runs()[0]
+		Expect(run.SkippedBy).To(Equal("'freeze' (2000-01-01T00:00 to 2100-01-01T00:00 UTC)"))
+		Expect(run.IncidentID).To(BeEmpty())
+		Expect(run.Error).To(BeEmpty())
+
+		Expect(incidentsRepo.Created()).To(Equal(0))
+	})
+})
@@ -0,0 +1,41 @@
+package store
+
+import (
+	"path/filepath"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+type Config struct {
+	// Records are only kept in memory when directory is not specified
+	Dir string
+}
+
+type Factory struct {
+	config Config
+	fs     boshsys.FileSystem
+	logger boshlog.Logger
+}
+
+func NewFactory(config Config, fs boshsys.FileSystem, logger boshlog.Logger) Factory {
+	return Factory{config: config, fs: fs, logger: logger}
+}
+
+func (f Factory) New(name string) (Journal, error) {
+	if !f.config.Required() {
+		return NewMemoryJournal(), nil
+	}
+
+	err := f.fs.MkdirAll(f.config.Dir, 0700)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Creating store directory '%s'", f.config.Dir)
+	}
+
+	path := filepath.Join(f.config.Dir, name+".json")
+
+	return NewFileJournal(path, f.fs, f.logger), nil
+}
+
+func (c Config) Required() bool { return len(c.Dir) > 0 }
@@ -0,0 +1,211 @@
+package store
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"sync"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+// minCompactEntries avoids rewriting small journals on every few saves
+const minCompactEntries = 100
+
+// FileJournal appends one JSON entry per line on each save.
+// Loading keeps last entry for each ID and compacts the file;
+// saving compacts it once most entries are superseded so that
+// journal of frequently updated records does not keep growing.
+type FileJournal struct {
+	path string
+	fs   boshsys.FileSystem
+
+	lock sync.Mutex
+
+	// Number of entries in the file and IDs of records that are not deleted
+	numEntries int
+	liveIDs    map[string]struct{}
+
+	logTag string
+	logger boshlog.Logger
+}
+
+func NewFileJournal(path string, fs boshsys.FileSystem, logger boshlog.Logger) *FileJournal {
+	return &FileJournal{
+		path: path,
+		fs:   fs,
+
+		liveIDs: map[string]struct{}{},
+
+		logTag: "store.FileJournal",
+		logger: logger,
+	}
+}
+
+func (j *FileJournal) Load() ([]Entry, error) {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+
+	if !j.fs.FileExists(j.path) {
+		return nil, nil
+	}
+
+	entries, err := j.read()
+	if err != nil {
+		return nil, err
+	}
+
+	err = j.compact(entries)
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// read returns latest non-deleted entries in order of creation
+func (j *FileJournal) read() ([]Entry, error) {
+	content, err := j.fs.ReadFile(j.path)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Reading journal '%s'", j.path)
+	}
+
+	var ids []string
+	latest := map[string]Entry{}
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(nil, 64*1024*1024)
+
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var entry Entry
+
+		err := json.Unmarshal(line, &entry)
+		if err != nil {
+			// Last line may be partially written if process was killed
+			j.logger.Error(j.logTag, "Skipping malformed journal '%s' entry: %s", j.path, err.Error())
+			continue
+		}
+
+		if _, found := latest[entry.ID]; !found {
+			ids = append(ids, entry.ID)
+		}
+
+		latest[entry.ID] = entry
+	}
+
+	err = scanner.Err()
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Scanning journal '%s'", j.path)
+	}
+
+	var entries []Entry
+
+	for _, id := range ids {
+		if !latest[id].Deleted {
+			entries = append(entries, latest[id])
+		}
+	}
+
+	return entries, nil
+}
+
+func (j *FileJournal) Save(id string, record interface{}) error {
+	recordBytes, err := json.Marshal(record)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Marshalling journal record '%s'", id)
+	}
+
+	return j.append(Entry{ID: id, Record: recordBytes})
+}
+
+func (j *FileJournal) Delete(id string) error {
+	return j.append(Entry{ID: id, Deleted: true})
+}
+
+func (j *FileJournal) append(entry Entry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Marshalling journal entry '%s'", entry.ID)
+	}
+
+	j.lock.Lock()
+	defer j.lock.Unlock()
+
+	file, err := j.fs.OpenFile(j.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Opening journal '%s'", j.path)
+	}
+
+	defer file.Close()
+
+	_, err = file.Write(append(line, '\n'))
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Appending to journal '%s'", j.path)
+	}
+
+	j.numEntries++
+
+	if entry.Deleted {
+		delete(j.liveIDs, entry.ID)
+	} else {
+		j.liveIDs[entry.ID] = struct{}{}
+	}
+
+	// Rewriting only after at least as many superseded entries as live ones
+	// were appended keeps amortized cost of saving proportional to record size
+	if j.numEntries < minCompactEntries || j.numEntries < 2*len(j.liveIDs) {
+		return nil
+	}
+
+	entries, err := j.read()
+	if err != nil {
+		return err
+	}
+
+	return j.compact(entries)
+}
+
+// compact rewrites journal with only latest entries;
+// new file is moved into place so that a crash does not lose records.
+func (j *FileJournal) compact(entries []Entry) error {
+	var buf bytes.Buffer
+
+	for _, entry := range entries {
+		line, err := json.Marshal(entry)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Marshalling journal entry '%s'", entry.ID)
+		}
+
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+
+	tmpPath := j.path + ".tmp"
+
+	err := j.fs.WriteFile(tmpPath, buf.Bytes())
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Writing compacted journal '%s'", tmpPath)
+	}
+
+	err = j.fs.Rename(tmpPath, j.path)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Replacing journal '%s'", j.path)
+	}
+
+	j.numEntries = len(entries)
+	j.liveIDs = map[string]struct{}{}
+
+	for _, entry := range entries {
+		j.liveIDs[entry.ID] = struct{}{}
+	}
+
+	return nil
+}
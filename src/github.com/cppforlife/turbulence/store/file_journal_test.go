@@ -0,0 +1,139 @@
+package store_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cppforlife/turbulence/store"
+)
+
+var _ = Describe("FileJournal", func() {
+	var (
+		dir     string
+		path    string
+		journal *FileJournal
+	)
+
+	BeforeEach(func() {
+		var err error
+
+		dir, err = ioutil.TempDir("", "store-test")
+		Expect(err).ToNot(HaveOccurred())
+
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		path = filepath.Join(dir, "records.json")
+		journal = NewFileJournal(path, boshsys.NewOsFileSystem(logger), logger)
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("returns no entries when journal does not exist", func() {
+		entries, err := journal.Load()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(entries).To(BeEmpty())
+	})
+
+	It("returns latest non-deleted records in order of creation", func() {
+		Expect(journal.Save("id1", map[string]int{"v": 1})).To(Succeed())
+		Expect(journal.Save("id2", map[string]int{"v": 1})).To(Succeed())
+		Expect(journal.Save("id3", map[string]int{"v": 1})).To(Succeed())
+		Expect(journal.Save("id1", map[string]int{"v": 2})).To(Succeed())
+		Expect(journal.Delete("id2")).To(Succeed())
+
+		entries, err := journal.Load()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(entries).To(HaveLen(2))
+
+		Expect(entries[0].ID).To(Equal("id1"))
+		Expect(string(entries[0].Record)).To(Equal(`{"v":2}`))
+
+		Expect(entries[1].ID).To(Equal("id3"))
+		Expect(string(entries[1].Record)).To(Equal(`{"v":1}`))
+	})
+
+	It("compacts journal when loading", func() {
+		Expect(journal.Save("id1", 1)).To(Succeed())
+		Expect(journal.Save("id1", 2)).To(Succeed())
+		Expect(journal.Save("id2", 1)).To(Succeed())
+		Expect(journal.Delete("id2")).To(Succeed())
+
+		_, err := journal.Load()
+		Expect(err).ToNot(HaveOccurred())
+
+		content, err := ioutil.ReadFile(path)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(content)).To(Equal(`{"ID":"id1","Record":2}` + "\n"))
+	})
+
+	It("compacts journal when saving once most entries are superseded", func() {
+		for i := 0; i < 250; i++ {
+			Expect(journal.Save("id1", i)).To(Succeed())
+		}
+
+		Expect(journal.Save("id2", 1)).To(Succeed())
+
+		content, err := ioutil.ReadFile(path)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(strings.Count(string(content), "\n")).To(BeNumerically("<", 100))
+
+		entries, err := journal.Load()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(entries).To(HaveLen(2))
+		Expect(string(entries[0].Record)).To(Equal("249"))
+		Expect(entries[1].ID).To(Equal("id2"))
+	})
+
+	It("compacts journal when saving once most records are deleted", func() {
+		for i := 0; i < 60; i++ {
+			Expect(journal.Save(fmt.Sprintf("id%d", i), i)).To(Succeed())
+		}
+
+		for i := 0; i < 59; i++ {
+			Expect(journal.Delete(fmt.Sprintf("id%d", i))).To(Succeed())
+		}
+
+		// Compacted after 100th entry
+		content, err := ioutil.ReadFile(path)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(strings.Count(string(content), "\n")).To(Equal(20 + 19))
+		Expect(string(content)).ToNot(ContainSubstring(`"id0"`))
+	})
+
+	It("does not compact journal when saving distinct records", func() {
+		for i := 0; i < 150; i++ {
+			Expect(journal.Save(fmt.Sprintf("id%d", i), i)).To(Succeed())
+		}
+
+		Expect(journal.Save("id0", 1)).To(Succeed())
+
+		content, err := ioutil.ReadFile(path)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(strings.Count(string(content), "\n")).To(Equal(151))
+	})
+
+	It("skips partially written entries", func() {
+		Expect(journal.Save("id1", 1)).To(Succeed())
+
+		file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0600)
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = file.Write([]byte(`{"ID":"id2","Rec`))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(file.Close()).To(Succeed())
+
+		entries, err := journal.Load()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(entries).To(HaveLen(1))
+		Expect(entries[0].ID).To(Equal("id1"))
+	})
+})
@@ -0,0 +1,23 @@
+package store
+
+import (
+	"encoding/json"
+)
+
+// Journal keeps latest version of each record keyed by its ID.
+type Journal interface {
+	Load() ([]Entry, error)
+
+	Save(string, interface{}) error
+	Delete(string) error
+}
+
+type Entry struct {
+	ID      string
+	Deleted bool `json:",omitempty"`
+
+	Record json.RawMessage `json:",omitempty"`
+}
+
+var _ Journal = &FileJournal{}
+var _ Journal = MemoryJournal{}
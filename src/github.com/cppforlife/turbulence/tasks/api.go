@@ -1,5 +1,9 @@
 package tasks
 
+import (
+	"time"
+)
+
 type StateRequest struct {
 	Stop bool
 }
@@ -10,4 +14,100 @@ type StateResponse struct {
 
 type ResultRequest struct {
 	Error string
+
+	// Not reported by older agents
+	Result *Result `json:",omitempty"`
+}
+
+// Result describes what agent did while executing a task
+type Result struct {
+	// Applied changes, e.g. exact nftables rules or tc qdiscs
+	Actions []string `json:",omitempty"`
+
+	// IPs that host names were resolved to keyed by host name
+	ResolvedIPs map[string][]string `json:",omitempty"`
+
+	// Processes that were paused or killed
+	PIDs []int `json:",omitempty"`
+
+	StartedAt time.Time
+
+	// States task went through in order
+	Progress []Progress `json:",omitempty"`
+
+	// Zero when changes were not applied or reverted
+	AppliedAt  time.Time
+	RevertedAt time.Time
+
+	RevertError string `json:",omitempty"`
+}
+
+const (
+	ProgressApplying  = "applying"
+	ProgressActive    = "active"
+	ProgressReverting = "reverting"
+	ProgressReverted  = "reverted"
+)
+
+// Progress is a state of the task in its lifecycle,
+// e.g. task became active once its changes were applied
+type Progress struct {
+	State string
+	At    time.Time
+}
+
+// RevertSucceeded is true when task undid its changes
+func (r Result) RevertSucceeded() bool {
+	return !r.RevertedAt.IsZero() && len(r.RevertError) == 0
+}
+
+// PollRequest is periodically sent by the agent to pick up new tasks
+// and stop signals; it also carries results of finished tasks.
+type PollRequest struct {
+	// Tasks that agent is executing and that were not yet asked to stop
+	RunningTaskIDs []string
+
+	// Progress of running tasks keyed by task ID
+	Progress map[string][]Progress `json:",omitempty"`
+
+	// Results of finished tasks keyed by task ID
+	Results map[string]ResultRequest
+
+	// Wait up to PollTimeout for new tasks or stop signals
+	Wait bool
+}
+
+type PollResponse struct {
+	Tasks       []Task
+	StopTaskIDs []string
+}
+
+type TypeResponse struct {
+	Name        string
+	Description string
+
+	// Either agent or api
+	ExecutedBy string
+
+	Options []OptionSchema
+}
+
+func NewTypeResponses(types []TaskType) []TypeResponse {
+	resps := []TypeResponse{}
+
+	for _, taskType := range types {
+		executedBy := "agent"
+		if taskType.NewTask == nil {
+			executedBy = "api"
+		}
+
+		resps = append(resps, TypeResponse{
+			Name:        taskType.Name,
+			Description: taskType.Description,
+			ExecutedBy:  executedBy,
+			Options:     taskType.OptionsSchema(),
+		})
+	}
+
+	return resps
 }
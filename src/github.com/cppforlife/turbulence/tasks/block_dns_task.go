@@ -1,11 +1,9 @@
 package tasks
 
 import (
-	"strings"
-
-	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
-	boshsys "github.com/cloudfoundry/bosh-utils/system"
+
+	"github.com/cppforlife/turbulence/tasks/netctl"
 )
 
 type BlockDNSOptions struct {
@@ -13,19 +11,41 @@ type BlockDNSOptions struct {
 	Timeout string // Times may be suffixed with ms,s,m,h
 }
 
-func (BlockDNSOptions) _private() {}
+func init() {
+	Register(TaskType{
+		Name:        "BlockDNS",
+		Description: "Drops outgoing DNS traffic",
+
+		NewOptions: func() Options { return BlockDNSOptions{} },
+		NewTask: func(opts Options, deps AgentDeps) (AgentTask, error) {
+			return NewBlockDNSTask(deps.NetCtl, opts.(BlockDNSOptions), deps.TaskID, deps.Journal, deps.Recorder, deps.Logger), nil
+		},
+
+		RequiredCapabilities: func(Options) []string { return []string{CapabilityNftables} },
+	})
+}
+
+func (o BlockDNSOptions) Validate() error {
+	var errs fieldErrors
+	errs.validateTimeout(o.Timeout, true)
+	return errs.err()
+}
 
 type BlockDNSTask struct {
-	cmdRunner boshsys.CmdRunner
-	opts      BlockDNSOptions
+	opts     BlockDNSOptions
+	filter   packetFilter
+	recorder Recorder
 }
 
 func NewBlockDNSTask(
-	cmdRunner boshsys.CmdRunner,
+	netCtl netctl.Controller,
 	opts BlockDNSOptions,
+	taskID string,
+	journal Journal,
+	recorder Recorder,
 	_ boshlog.Logger,
 ) BlockDNSTask {
-	return BlockDNSTask{cmdRunner, opts}
+	return BlockDNSTask{opts, newPacketFilter(netCtl, taskID, journal, recorder), recorder}
 }
 
 func (t BlockDNSTask) Execute(stopCh chan struct{}) error {
@@ -34,41 +54,31 @@ func (t BlockDNSTask) Execute(stopCh chan struct{}) error {
 		return err
 	}
 
-	rules := t.rules()
-
-	for _, r := range rules {
-		err := t.iptables("-A", r)
-		if err != nil {
-			return err
-		}
+	err = t.filter.Apply(t.rules())
+	if err != nil {
+		return err
 	}
 
+	t.recorder.Applied()
+
 	select {
 	case <-timeoutCh:
 	case <-stopCh:
 	}
 
-	for _, r := range rules {
-		err := t.iptables("-D", r)
-		if err != nil {
-			return err
-		}
-	}
+	t.recorder.Reverting()
 
-	return nil
-}
+	err = t.filter.Remove()
+	t.recorder.Reverted(err)
 
-func (t BlockDNSTask) rules() []string {
-	return []string{ "OUTPUT -p tcp --destination-port 53 -j DROP", "OUTPUT -p udp --destination-port 53 -j DROP" }
+	return err
 }
 
-func (t BlockDNSTask) iptables(action, rule string) error {
-	args := append([]string{action}, strings.Split(rule, " ")...)
+func (t BlockDNSTask) rules() []netctl.Rule {
+	dns := netctl.PortRange{From: 53, To: 53}
 
-	_, _, _, err := t.cmdRunner.RunCommand("iptables", args...)
-	if err != nil {
-		return bosherr.WrapError(err, "Shelling out to iptables")
+	return []netctl.Rule{
+		{Hook: netctl.HookOutput, Protocol: "tcp", DstPorts: dns, Verdict: netctl.VerdictDrop},
+		{Hook: netctl.HookOutput, Protocol: "udp", DstPorts: dns, Verdict: netctl.VerdictDrop},
 	}
-
-	return nil
 }
@@ -0,0 +1,126 @@
+package tasks
+
+import (
+	"sort"
+	"sync"
+
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+const (
+	CapabilityStress   = "stress"
+	CapabilityDig      = "dig"
+	CapabilityTC       = "tc"
+	CapabilityNetem    = "tc-netem"
+	CapabilityNftables = "nftables"
+	CapabilitySysrq    = "sysrq"
+)
+
+// Capability is a binary or a kernel feature that some tasks depend on.
+// Agents probe capabilities when they start and report available ones
+// so that API server can refuse tasks that would fail on some VMs.
+type Capability struct {
+	Name string
+
+	// Probe returns true if capability is available on the VM
+	Probe func(boshsys.CmdRunner, boshsys.FileSystem) bool
+}
+
+var capabilities = struct {
+	sync.RWMutex
+	byName map[string]Capability
+}{byName: map[string]Capability{}}
+
+func init() {
+	commandExists := func(name string) func(boshsys.CmdRunner, boshsys.FileSystem) bool {
+		return func(cmdRunner boshsys.CmdRunner, _ boshsys.FileSystem) bool {
+			return cmdRunner.CommandExists(name)
+		}
+	}
+
+	RegisterCapability(Capability{Name: CapabilityStress, Probe: commandExists("stress")})
+	RegisterCapability(Capability{Name: CapabilityDig, Probe: commandExists("dig")})
+
+	// Network changes are made via netlink hence only kernel support is needed
+	RegisterCapability(Capability{Name: CapabilityTC, Probe: kernelModules("sch_prio", "sch_htb", "cls_u32")})
+	RegisterCapability(Capability{Name: CapabilityNetem, Probe: kernelModules("sch_netem")})
+	RegisterCapability(Capability{Name: CapabilityNftables, Probe: kernelModules("nf_tables")})
+
+	RegisterCapability(Capability{
+		Name: CapabilitySysrq,
+		Probe: func(_ boshsys.CmdRunner, fs boshsys.FileSystem) bool {
+			return fs.FileExists("/proc/sysrq-trigger")
+		},
+	})
+}
+
+// kernelModules returns probe that checks that each module
+// is either already loaded (or built in) or can be loaded
+func kernelModules(names ...string) func(boshsys.CmdRunner, boshsys.FileSystem) bool {
+	return func(cmdRunner boshsys.CmdRunner, fs boshsys.FileSystem) bool {
+		for _, name := range names {
+			if fs.FileExists("/sys/module/" + name) {
+				continue
+			}
+
+			// modinfo also knows about modules built into the kernel
+			_, _, _, err := cmdRunner.RunCommand("modinfo", name)
+			if err != nil {
+				return false
+			}
+		}
+
+		return true
+	}
+}
+
+// RegisterCapability makes capability probed by agents;
+// it panics if capability with the same name is already registered.
+func RegisterCapability(capability Capability) {
+	capabilities.Lock()
+	defer capabilities.Unlock()
+
+	if len(capability.Name) == 0 || capability.Probe == nil {
+		panic("tasks: RegisterCapability requires a name and a probe")
+	}
+
+	if _, found := capabilities.byName[capability.Name]; found {
+		panic("tasks: RegisterCapability called twice for capability '" + capability.Name + "'")
+	}
+
+	capabilities.byName[capability.Name] = capability
+}
+
+// ProbeCapabilities returns sorted names of available capabilities
+func ProbeCapabilities(cmdRunner boshsys.CmdRunner, fs boshsys.FileSystem) []string {
+	capabilities.RLock()
+	defer capabilities.RUnlock()
+
+	names := []string{}
+
+	for name, capability := range capabilities.byName {
+		if capability.Probe(cmdRunner, fs) {
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// RequiredCapabilities returns capabilities needed to execute task with given options
+func RequiredCapabilities(taskOpts Options) []string {
+	name, found := findTypeName(taskOpts)
+	if !found {
+		return nil
+	}
+
+	taskType, _ := FindType(name)
+
+	if taskType.RequiredCapabilities == nil {
+		return nil
+	}
+
+	return taskType.RequiredCapabilities(taskOpts)
+}
@@ -0,0 +1,67 @@
+package tasks_test
+
+import (
+	"errors"
+
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cppforlife/turbulence/tasks"
+)
+
+var _ = Describe("ProbeCapabilities", func() {
+	var (
+		cmdRunner *fakesys.FakeCmdRunner
+		fs        *fakesys.FakeFileSystem
+	)
+
+	BeforeEach(func() {
+		cmdRunner = fakesys.NewFakeCmdRunner()
+		fs = fakesys.NewFakeFileSystem()
+	})
+
+	It("returns sorted available capabilities", func() {
+		cmdRunner.AvailableCommands = map[string]bool{"stress": true}
+		Expect(fs.WriteFileString("/proc/sysrq-trigger", "")).ToNot(HaveOccurred())
+
+		Expect(ProbeCapabilities(cmdRunner, fs)).To(Equal([]string{
+			CapabilityNftables, CapabilityStress, CapabilitySysrq, CapabilityTC, CapabilityNetem,
+		}))
+	})
+
+	It("does not include netem if kernel module is not available", func() {
+		cmdRunner.AddCmdResult("modinfo sch_netem", fakesys.FakeCmdResult{Error: errors.New("fake-err")})
+
+		Expect(ProbeCapabilities(cmdRunner, fs)).To(Equal([]string{CapabilityNftables, CapabilityTC}))
+	})
+
+	It("includes tc and nftables only if all kernel modules are loaded or available", func() {
+		Expect(fs.WriteFileString("/sys/module/sch_htb", "")).ToNot(HaveOccurred())
+		cmdRunner.AddCmdResult("modinfo sch_htb", fakesys.FakeCmdResult{Error: errors.New("fake-err")})
+		cmdRunner.AddCmdResult("modinfo cls_u32", fakesys.FakeCmdResult{Error: errors.New("fake-err")})
+		cmdRunner.AddCmdResult("modinfo nf_tables", fakesys.FakeCmdResult{Error: errors.New("fake-err")})
+
+		Expect(ProbeCapabilities(cmdRunner, fs)).To(Equal([]string{CapabilityNetem}))
+	})
+})
+
+var _ = Describe("RequiredCapabilities", func() {
+	It("requires dig only when host names need to be resolved", func() {
+		opts := TargetedBlockerOptions{Targets: []Target{{DstHost: "10.0.0.1"}}}
+		Expect(RequiredCapabilities(opts)).To(Equal([]string{CapabilityNftables}))
+
+		opts = TargetedBlockerOptions{Targets: []Target{{SrcHost: "example.com"}}}
+		Expect(RequiredCapabilities(opts)).To(Equal([]string{CapabilityNftables, CapabilityDig}))
+	})
+
+	It("requires netem unless only bandwidth is limited", func() {
+		Expect(RequiredCapabilities(ControlNetOptions{Delay: "10ms"})).To(Equal([]string{CapabilityTC, CapabilityNetem}))
+		Expect(RequiredCapabilities(ControlNetOptions{Bandwidth: "1mbps"})).To(Equal([]string{CapabilityTC}))
+	})
+
+	It("requires sysrq only when crashing", func() {
+		Expect(RequiredCapabilities(ShutdownOptions{})).To(BeEmpty())
+		Expect(RequiredCapabilities(ShutdownOptions{Crash: true})).To(Equal([]string{CapabilitySysrq}))
+	})
+})
@@ -0,0 +1,43 @@
+package tasks
+
+import (
+	"fmt"
+	"strings"
+
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+// CommandError describes failed command so that callers
+// do not need to parse wrapped error messages
+type CommandError struct {
+	Cmd        []string
+	ExitStatus int
+	Stderr     string
+	Err        error
+}
+
+func (e CommandError) Error() string {
+	msg := fmt.Sprintf("Running '%s' exited with %d", strings.Join(e.Cmd, " "), e.ExitStatus)
+
+	if stderr := strings.TrimSpace(e.Stderr); len(stderr) > 0 {
+		return msg + ": " + stderr
+	}
+
+	return msg + ": " + e.Err.Error()
+}
+
+// runCommand returns CommandError when command fails
+// (command runner fails commands that exit with non-zero status)
+func runCommand(cmdRunner boshsys.CmdRunner, cmd string, args ...string) (string, error) {
+	stdout, stderr, exitStatus, err := cmdRunner.RunCommand(cmd, args...)
+	if err != nil {
+		return stdout, CommandError{
+			Cmd:        append([]string{cmd}, args...),
+			ExitStatus: exitStatus,
+			Stderr:     stderr,
+			Err:        err,
+		}
+	}
+
+	return stdout, nil
+}
@@ -0,0 +1,27 @@
+package tasks_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cppforlife/turbulence/tasks"
+)
+
+var _ = Describe("CommandError", func() {
+	It("includes command, exit status and stderr", func() {
+		err := CommandError{
+			Cmd:        []string{"tc", "qdisc", "add"},
+			ExitStatus: 2,
+			Stderr:     "RTNETLINK answers: File exists\n",
+			Err:        errors.New("fake-err"),
+		}
+		Expect(err.Error()).To(Equal("Running 'tc qdisc add' exited with 2: RTNETLINK answers: File exists"))
+	})
+
+	It("falls back to underlying error when there is no stderr", func() {
+		err := CommandError{Cmd: []string{"tc"}, ExitStatus: -1, Err: errors.New("fake-err")}
+		Expect(err.Error()).To(Equal("Running 'tc' exited with -1: fake-err"))
+	})
+})
@@ -1,12 +1,16 @@
 package tasks
 
 import (
+	"fmt"
 	"regexp"
 	"strings"
+	"time"
 
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
+
+	"github.com/cppforlife/turbulence/tasks/netctl"
 )
 
 // See http://www.linuxfoundation.org/collaborate/workgroups/networking/netem
@@ -53,15 +57,179 @@ type DestinationTarget struct {
 	DstPort string
 }
 
-func (ControlNetOptions) _private() {}
+func init() {
+	Register(TaskType{
+		Name:        "ControlNet",
+		Description: "Delays, drops, duplicates, corrupts or reorders outgoing packets, or limits bandwidth",
+
+		NewOptions: func() Options { return ControlNetOptions{} },
+		NewTask: func(opts Options, deps AgentDeps) (AgentTask, error) {
+			return NewControlNetTask(deps.CmdRunner, deps.NetCtl, opts.(ControlNetOptions), deps.Journal, deps.Recorder, deps.Logger), nil
+		},
+
+		RequiredCapabilities: func(opts Options) []string {
+			typedOpts := opts.(ControlNetOptions)
+			required := []string{CapabilityTC}
+
+			// Bandwidth is limited with htb instead of netem
+			if len(typedOpts.Bandwidth) == 0 {
+				required = append(required, CapabilityNetem)
+			}
+
+			// Host names are resolved with dig
+			for _, target := range typedOpts.Targets {
+				if len(target.DstHost) > 0 && !destinationIpPattern.MatchString(target.DstHost) {
+					return append(required, CapabilityDig)
+				}
+			}
+
+			return required
+		},
+	})
+}
+
+func (o ControlNetOptions) Validate() error {
+	var errs fieldErrors
+
+	errs.validateTimeout(o.Timeout, true)
+
+	effects := map[string]string{
+		"Delay":       o.Delay,
+		"Loss":        o.Loss,
+		"Duplication": o.Duplication,
+		"Corruption":  o.Corruption,
+		"Reorder":     o.Reorder,
+	}
+
+	var specifiedEffects []string
+
+	for _, field := range []string{"Delay", "Loss", "Duplication", "Corruption", "Reorder"} {
+		if len(effects[field]) > 0 {
+			specifiedEffects = append(specifiedEffects, field)
+		}
+	}
+
+	if len(o.Bandwidth) > 0 {
+		if len(specifiedEffects) > 0 {
+			errs.add("Bandwidth", "cannot be limited at the same time as %s", strings.Join(specifiedEffects, ", "))
+		}
+	} else if len(specifiedEffects) == 0 {
+		errs.add("Bandwidth", "or one of Delay, Loss, Duplication, Corruption, Reorder must be specified")
+	}
+
+	if len(o.Reorder) > 0 && len(o.Delay) == 0 {
+		errs.add("Reorder", "requires Delay to be specified")
+	}
+
+	// Effects are validated the same way they are parsed when executing
+	if _, err := o.netem(); err != nil {
+		errs = append(errs, err.(ValidationError).Errors...)
+	}
+
+	if len(o.Bandwidth) > 0 {
+		if _, err := netctl.ParseRate(o.Bandwidth); err != nil {
+			errs.add("Bandwidth", "'%s' must be a rate such as 1mbit or 256kbps", o.Bandwidth)
+		}
+	}
+
+	for i, target := range o.Targets {
+		errs.addNested(fmt.Sprintf("Targets[%d]", i), target.Validate())
+	}
+
+	return errs.err()
+}
+
+// netem returns configuration of netem qdisc; options are parsed the same way as tc did
+func (o ControlNetOptions) netem() (netctl.Netem, error) {
+	var errs fieldErrors
+	var netem netctl.Netem
+
+	durations := []struct {
+		field string
+		val   string
+		dst   *time.Duration
+	}{
+		{"Delay", o.Delay, &netem.Delay},
+		{"DelayVariation", defaultStr(o.DelayVariation, "10ms"), &netem.Jitter},
+	}
+
+	for _, d := range durations {
+		if len(o.Delay) == 0 {
+			break
+		}
+
+		parsed, err := netctl.ParseTime(d.val)
+		if err != nil {
+			errs.add(d.field, "'%s' must be a time such as 50ms", d.val)
+		}
+		*d.dst = parsed
+	}
+
+	percents := []struct {
+		field string
+		val   string
+		dst   *float64
+
+		// Correlations only apply when their effect is specified
+		dependsOn *string
+	}{
+		{"Loss", o.Loss, &netem.Loss, nil},
+		{"LossCorrelation", defaultStr(o.LossCorrelation, "75%"), &netem.LossCorrelation, &o.Loss},
+		{"Duplication", o.Duplication, &netem.Duplicate, nil},
+		{"Corruption", o.Corruption, &netem.Corrupt, nil},
+		{"Reorder", o.Reorder, &netem.Reorder, nil},
+		{"ReorderCorrelation", defaultStr(o.ReorderCorrelation, "50%"), &netem.ReorderCorrelation, &o.Reorder},
+	}
+
+	for _, p := range percents {
+		if len(p.val) == 0 || (p.dependsOn != nil && len(*p.dependsOn) == 0) {
+			continue
+		}
+
+		parsed, err := netctl.ParsePercent(p.val)
+		if err != nil {
+			errs.add(p.field, "'%s' must be a percentage such as 20%%", p.val)
+		}
+		*p.dst = parsed
+	}
+
+	return netem, errs.err()
+}
+
+func (t DestinationTarget) Validate() error {
+	var errs fieldErrors
+
+	if len(t.DstHost) == 0 && len(t.DstPort) == 0 {
+		errs.add("DstHost", "or DstPort must be specified")
+	}
+
+	if len(t.DstPort) > 0 {
+		ports, err := netctl.ParsePortRange(t.DstPort)
+		if err != nil || ports.From != ports.To {
+			errs.add("DstPort", "'%s' must be a single port number", t.DstPort)
+		}
+	}
+
+	return errs.err()
+}
 
 type ControlNetTask struct {
 	cmdRunner boshsys.CmdRunner
+	netCtl    netctl.Controller
 	opts      ControlNetOptions
+	journal   Journal
+	recorder  Recorder
 }
 
-func NewControlNetTask(cmdRunner boshsys.CmdRunner, opts ControlNetOptions, _ boshlog.Logger) ControlNetTask {
-	return ControlNetTask{cmdRunner, opts}
+func NewControlNetTask(
+	cmdRunner boshsys.CmdRunner,
+	netCtl netctl.Controller,
+	opts ControlNetOptions,
+	journal Journal,
+	recorder Recorder,
+	_ boshlog.Logger,
+) ControlNetTask {
+	return ControlNetTask{cmdRunner, netCtl, opts, journal, recorder}
 }
 
 func defaultStr(v, d string) string {
@@ -93,152 +261,236 @@ func (t ControlNetTask) Execute(stopCh chan struct{}) error {
 		return err
 	}
 
-	if bandwidth {
-		for _, ifaceName := range ifaceNames {
-			err := t.configureBandwidth(ifaceName)
-			if err != nil {
-				t.resetIfaces(ifaceNames)
-				return err
-			}
-		}
-	} else {
-		opts := make([]string, 0, 16)
-
-		if delay {
-			variation := defaultStr(t.opts.DelayVariation, "10ms")
-			opts = append(opts, "delay", t.opts.Delay, variation, "distribution", "normal")
-		}
-
-		if loss {
-			correlation := defaultStr(t.opts.LossCorrelation, "75%")
-			opts = append(opts, "loss", t.opts.Loss, correlation)
+	// Check before changing any interface since resetting would
+	// remove qdiscs that were not configured by this task
+	for _, ifaceName := range ifaceNames {
+		qdisc, found, err := netctl.FindRootQdisc(t.netCtl, ifaceName)
+		if err != nil {
+			return err
 		}
 
-		if duplication {
-			opts = append(opts, "duplicate", t.opts.Duplication)
+		if found {
+			return netctl.QdiscExistsError{Qdisc: qdisc}
 		}
+	}
 
-		if corruption {
-			opts = append(opts, "corrupt", t.opts.Corruption)
-		}
+	netem, err := t.opts.netem()
+	if err != nil {
+		return err
+	}
 
-		if reorder {
-			correlation := defaultStr(t.opts.ReorderCorrelation, "50%")
-			opts = append(opts, "reorder", t.opts.Reorder, correlation)
+	for _, ifaceName := range ifaceNames {
+		if bandwidth {
+			err = t.configureBandwidth(ifaceName)
+		} else {
+			err = t.configureInterface(ifaceName, netem)
 		}
 
-		for _, ifaceName := range ifaceNames {
-			err := t.configureInterface(ifaceName, opts)
-			if err != nil {
-				t.resetIfaces(ifaceNames)
-				return err
-			}
+		if err != nil {
+			t.resetIfaces(ifaceNames)
+			return err
 		}
 	}
 
+	t.recorder.Applied()
+
 	select {
 	case <-timeoutCh:
 	case <-stopCh:
 	}
 
+	t.recorder.Reverting()
+
+	err = t.resetIfaces(ifaceNames)
+	t.recorder.Reverted(err)
 
-	return t.resetIfaces(ifaceNames)
+	return err
 }
 
-func (t ControlNetTask) configureInterface(ifaceName string, opts []string) error {
-	_, _, _, err := t.cmdRunner.RunCommand("tc", "qdisc", "add", "dev", ifaceName, "root", "handle", "1:", "prio")
+func (t ControlNetTask) configureInterface(ifaceName string, netem netctl.Netem) error {
+	err := t.addRootQdisc(netctl.Qdisc{Iface: ifaceName, Kind: "prio", Handle: controlNetHandle, Parent: netctl.HandleRoot})
 	if err != nil {
 		return err
 	}
 
-	args := []string{"qdisc", "add", "dev", ifaceName, "parent", "1:1", "handle", "30:", "netem"}
-	args = append(args, opts...)
-	_, _, _, err = t.cmdRunner.RunCommand("tc", args...)
+	qdisc := netctl.Qdisc{Iface: ifaceName, Kind: "netem", Handle: 0x300000, Parent: controlNetClassID, Netem: &netem}
+
+	err = t.netCtl.AddQdisc(qdisc)
 	if err != nil {
 		return err
 	}
 
+	t.recorder.Action(qdisc.String())
+
 	return t.configureDestination(ifaceName)
 }
 
 func (t ControlNetTask) configureBandwidth(ifaceName string) error {
-	_, _, _, err := t.cmdRunner.RunCommand("tc", "qdisc", "add", "dev", ifaceName, "root", "handle", "1:", "htb")
+	rate, err := netctl.ParseRate(t.opts.Bandwidth)
 	if err != nil {
 		return err
 	}
 
-	_, _, _, err = t.cmdRunner.RunCommand("tc", "class", "add", "dev", ifaceName, "parent", "1:", "classid", "1:1", "htb", "rate", t.opts.Bandwidth)
+	err = t.addRootQdisc(netctl.Qdisc{Iface: ifaceName, Kind: "htb", Handle: controlNetHandle, Parent: netctl.HandleRoot})
 	if err != nil {
 		return err
 	}
 
-	return t.configureDestination(ifaceName)
+	class := netctl.Class{Iface: ifaceName, Kind: "htb", Handle: controlNetClassID, Parent: controlNetHandle, Rate: rate}
+
+	err = t.netCtl.AddClass(class)
+	if err != nil {
+		return err
+	}
+
+	t.recorder.Action(class.String())
+
+	err = t.configureDestination(ifaceName)
+	if err != nil {
+		return err
+	}
+
+	return t.verifyClass(class)
 }
 
-func (t ControlNetTask) configureDestination(ifaceName string) error {
-	rules := [][]string{}
+// addRootQdisc records undo command right away so that
+// interface is reset even if agent dies before task finishes
+func (t ControlNetTask) addRootQdisc(qdisc netctl.Qdisc) error {
+	err := t.netCtl.AddQdisc(qdisc)
+	if err != nil {
+		return err
+	}
 
-	if len(t.opts.Targets) == 0 {
-		// we need to add this to forward the traffic to the default class
-		rules = [][]string{[]string{"match", "ip", "dst", "0.0.0.0/0"}}
-	} else {
-		for _, target := range t.opts.Targets {
-			if target.DstHost == "" && target.DstPort == "" {
-				return bosherr.Error("Must specify at least one of DstHost or DstPort.")
-			}
+	t.journal.Record("root qdisc on "+qdisc.Iface, netctl.DeleteRootQdiscUndoCmd(qdisc.Iface)...)
+	t.recorder.Action(qdisc.String())
 
-			var dsthosts []string
-			var dport string
+	return t.verifyRootQdisc(qdisc)
+}
 
-			if target.DstPort == "" {
-				dport = ""
-			} else if destinationPortPattern.MatchString(target.DstPort) {
-				dport = target.DstPort
-			} else {
-				return bosherr.Errorf("Invalid destination port specified %v", target.DstPort)
-			}
+const (
+	controlNetHandle  uint32 = 0x10000 // 1:
+	controlNetClassID uint32 = 0x10001 // 1:1
+)
 
-			dsthosts, err := t.getHost(target.DstHost)
-			if err != nil {
-				return err
-			}
-			
-			if len(dsthosts) == 0 {
-				// only port was specified
-				rules = append(rules, []string{"match", "ip", "dport", dport, "0xffff"})
-			} else {
-				for _, dsthost := range dsthosts {
-					args := []string{"match", "ip", "dst", dsthost}
-					
-					if (dport != "") {
-						// check if we have to add the port to the same rule
-						args = append(args, []string{"match", "ip", "dport", dport, "0xffff"}...)
-					}
-					
-					rules = append(rules, args)
-				}
-			}
+// verifyRootQdisc reads configuration back to make sure that it took effect
+func (t ControlNetTask) verifyRootQdisc(expected netctl.Qdisc) error {
+	qdisc, found, err := netctl.FindRootQdisc(t.netCtl, expected.Iface)
+	if err != nil {
+		return err
+	}
+
+	if !found || qdisc.Kind != expected.Kind || qdisc.Handle != expected.Handle {
+		return bosherr.Errorf("Expected interface '%s' to have root qdisc '%s' with handle '%s'",
+			expected.Iface, expected.Kind, expected.HandleStr())
+	}
+
+	return nil
+}
+
+func (t ControlNetTask) verifyClass(expected netctl.Class) error {
+	classes, err := t.netCtl.Classes(expected.Iface)
+	if err != nil {
+		return err
+	}
+
+	for _, class := range classes {
+		if class.Kind == expected.Kind && class.Handle == expected.Handle && class.Rate == expected.Rate {
+			return nil
 		}
 	}
-	
-	for _, rule := range rules {
-		args := []string{"filter", "add", "dev", ifaceName, "protocol", "ip", "parent", "1:", "prio", "1", "u32"}
-		args = append(args, rule...)
-		args = append(args, []string{"flowid", "1:1"}...)
 
-		_, _, _, err := t.cmdRunner.RunCommand("tc", args...)
+	return bosherr.Errorf("Expected interface '%s' to have %s class '%s' with rate '%s'",
+		expected.Iface, expected.Kind, netctl.FormatHandle(expected.Handle), netctl.FormatRate(expected.Rate))
+}
+
+func (t ControlNetTask) configureDestination(ifaceName string) error {
+	filters, err := t.filters(ifaceName)
+	if err != nil {
+		return err
+	}
+
+	for _, filter := range filters {
+		err := t.netCtl.AddFilter(filter)
 		if err != nil {
 			return err
 		}
+
+		t.recorder.Action(filter.String())
+	}
+
+	actual, err := t.netCtl.Filters(ifaceName, controlNetHandle)
+	if err != nil {
+		return err
+	}
+
+	var found int
+
+	for _, filter := range actual {
+		if filter.ClassID == controlNetClassID {
+			found++
+		}
+	}
+
+	if found != len(filters) {
+		return bosherr.Errorf("Expected interface '%s' to have %d filter(s) with flowid '%s' but found %d",
+			ifaceName, len(filters), netctl.FormatHandle(controlNetClassID), found)
 	}
 
 	return nil
 }
 
+// filters direct matching traffic into the class with effects;
+// without targets all traffic is matched since default class is not affected
+func (t ControlNetTask) filters(ifaceName string) ([]netctl.Filter, error) {
+	filter := netctl.Filter{Iface: ifaceName, Kind: "u32", Parent: controlNetHandle, Prio: 1, ClassID: controlNetClassID}
+
+	if len(t.opts.Targets) == 0 {
+		return []netctl.Filter{filter}, nil
+	}
+
+	var filters []netctl.Filter
+
+	for _, target := range t.opts.Targets {
+		filter.DstPort = 0
+
+		if len(target.DstPort) > 0 {
+			ports, err := netctl.ParsePortRange(target.DstPort)
+			if err != nil || ports.From != ports.To {
+				return nil, bosherr.Errorf("Invalid destination port specified %v", target.DstPort)
+			}
+
+			filter.DstPort = ports.From
+		}
+
+		dsthosts, err := t.getHost(target.DstHost)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(dsthosts) == 0 {
+			// only port was specified
+			filter.Dst = nil
+			filters = append(filters, filter)
+			continue
+		}
+
+		for _, dsthost := range dsthosts {
+			filter.Dst, err = netctl.ParseIPNet(dsthost)
+			if err != nil {
+				return nil, err
+			}
+
+			filters = append(filters, filter)
+		}
+	}
+
+	return filters, nil
+}
+
 func (t ControlNetTask) resetIfaces(ifaceNames []string) error {
 	errors := []error{}
 	for _, ifaceName := range ifaceNames {
-		_, _, _, err := t.cmdRunner.RunCommand("tc", "qdisc", "del", "dev", ifaceName, "root")
+		err := t.netCtl.DeleteRootQdisc(ifaceName)
 		if err != nil {
 			errors = append(errors, err)
 		}
@@ -257,7 +509,6 @@ func (t ControlNetTask) resetIfaces(ifaceNames []string) error {
 }
 
 var destinationIpPattern = regexp.MustCompile(`(\d{1,3}\.\d{1,3}\.\d{1,3}\.\d{1,3})(/\d{0,2})?`)
-var destinationPortPattern = regexp.MustCompile(`\d+(:\d+)?$`)
 
 func (t ControlNetTask) dig(hostname string) ([]string, error) {
 	args := []string{"+short", hostname}
@@ -271,6 +522,8 @@ func (t ControlNetTask) dig(hostname string) ([]string, error) {
 		return nil, bosherr.Errorf("No IPs found for host %v", hostname)
 	}
 
+	t.recorder.ResolvedIPs(hostname, ips)
+
 	return ips, nil
 }
 
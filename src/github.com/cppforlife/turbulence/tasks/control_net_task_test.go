@@ -0,0 +1,185 @@
+package tasks_test
+
+import (
+	"errors"
+	"time"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cppforlife/turbulence/tasks"
+	"github.com/cppforlife/turbulence/tasks/netctl"
+)
+
+var _ = Describe("ControlNetTask", func() {
+	var (
+		netCtl     *fakeNetCtl
+		journal    *fakeJournal
+		recorder   *ResultRecorder
+		ifaceNames []string
+	)
+
+	BeforeEach(func() {
+		netCtl = newFakeNetCtl()
+		journal = &fakeJournal{}
+		recorder = NewResultRecorder(nil)
+
+		var err error
+		ifaceNames, err = NonLocalIfaceNames()
+		Expect(err).ToNot(HaveOccurred())
+
+		if len(ifaceNames) == 0 {
+			Skip("No non-local network interfaces")
+		}
+	})
+
+	execute := func(opts ControlNetOptions) error {
+		opts.Timeout = "1m"
+
+		task := NewControlNetTask(fakesys.NewFakeCmdRunner(), netCtl, opts, journal, recorder, boshlog.NewLogger(boshlog.LevelNone))
+
+		stopCh := make(chan struct{})
+		close(stopCh)
+
+		return task.Execute(stopCh)
+	}
+
+	It("adds netem qdisc under root prio qdisc and resets interfaces once stopped", func() {
+		err := execute(ControlNetOptions{Delay: "50ms", Loss: "20%"})
+		Expect(err).ToNot(HaveOccurred())
+
+		iface := ifaceNames[0]
+
+		Expect(netCtl.qdiscs[:2]).To(Equal([]netctl.Qdisc{
+			{Iface: iface, Kind: "prio", Handle: 0x10000, Parent: netctl.HandleRoot},
+			{Iface: iface, Kind: "netem", Handle: 0x300000, Parent: 0x10001},
+		}))
+
+		Expect(netCtl.filters[0].ClassID).To(Equal(uint32(0x10001)))
+		Expect(netCtl.filters[0].Dst).To(BeNil())
+
+		Expect(recorder.Result().Actions[:3]).To(Equal([]string{
+			"qdisc prio 1: dev " + iface + " root",
+			"qdisc netem 30: dev " + iface + " parent 1:1 delay 50ms 10ms distribution normal loss 20% 75%",
+			"filter dev " + iface + " parent 1: protocol ip prio 1 u32 match ip dst 0.0.0.0/0 flowid 1:1",
+		}))
+
+		Expect(journal.undoCmds[0]).To(Equal([]string{"netctl", "delete-root-qdisc", iface}))
+		Expect(netCtl.deletedRootQdiscs).To(Equal(ifaceNames))
+		Expect(recorder.Result().RevertSucceeded()).To(BeTrue())
+	})
+
+	It("corrupts packets without other effects being specified", func() {
+		err := execute(ControlNetOptions{Corruption: "1%"})
+		Expect(err).ToNot(HaveOccurred())
+
+		iface := ifaceNames[0]
+
+		Expect(netCtl.netems[0]).To(Equal(netctl.Netem{Corrupt: 1}))
+
+		Expect(recorder.Result().Actions[1]).To(Equal(
+			"qdisc netem 30: dev " + iface + " parent 1:1 corrupt 1%"))
+	})
+
+	It("applies correlations only to effects that are specified", func() {
+		err := execute(ControlNetOptions{Duplication: "2%", Reorder: "25%", Delay: "10ms", DelayVariation: "1ms"})
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(netCtl.netems[0]).To(Equal(netctl.Netem{
+			Delay:              10 * time.Millisecond,
+			Jitter:             time.Millisecond,
+			Duplicate:          2,
+			Reorder:            25,
+			ReorderCorrelation: 50,
+		}))
+	})
+
+	It("limits bandwidth with htb class and directs targets into it", func() {
+		err := execute(ControlNetOptions{
+			Bandwidth: "1mbit",
+			Targets:   []DestinationTarget{{DstHost: "10.0.0.0/24", DstPort: "8080"}, {DstPort: "53"}},
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		iface := ifaceNames[0]
+
+		Expect(netCtl.classes[0]).To(Equal(netctl.Class{
+			Iface: iface, Kind: "htb", Handle: 0x10001, Parent: 0x10000, Rate: 125000,
+		}))
+
+		Expect(recorder.Result().Actions[:4]).To(Equal([]string{
+			"qdisc htb 1: dev " + iface + " root",
+			"class htb 1:1 dev " + iface + " parent 1: rate 1mbit",
+			"filter dev " + iface + " parent 1: protocol ip prio 1 u32 match ip dst 10.0.0.0/24 match ip dport 8080 0xffff flowid 1:1",
+			"filter dev " + iface + " parent 1: protocol ip prio 1 u32 match ip dport 53 0xffff flowid 1:1",
+		}))
+	})
+
+	It("refuses to replace already configured root qdisc", func() {
+		netCtl.qdiscs = []netctl.Qdisc{{Iface: ifaceNames[0], Kind: "htb", Handle: 0x50000, Parent: netctl.HandleRoot}}
+
+		err := execute(ControlNetOptions{Delay: "50ms"})
+		Expect(err).To(Equal(netctl.QdiscExistsError{Qdisc: netCtl.qdiscs[0]}))
+
+		Expect(journal.undoCmds).To(BeEmpty())
+		Expect(netCtl.deletedRootQdiscs).To(BeEmpty())
+	})
+
+	It("resets interfaces when adding filter fails", func() {
+		netCtl.addFilterErr = errors.New("fake-err")
+
+		err := execute(ControlNetOptions{Delay: "50ms"})
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("fake-err"))
+
+		Expect(recorder.Result().AppliedAt.IsZero()).To(BeTrue())
+		Expect(netCtl.deletedRootQdiscs).To(Equal(ifaceNames))
+	})
+
+	It("records reset error", func() {
+		netCtl.deleteRootQdiscErr = errors.New("fake-err")
+
+		Expect(execute(ControlNetOptions{Delay: "50ms"})).To(HaveOccurred())
+
+		result := recorder.Result()
+		Expect(result.RevertSucceeded()).To(BeFalse())
+		Expect(result.RevertError).To(ContainSubstring("fake-err"))
+	})
+})
+
+var _ = Describe("ControlNetOptions", func() {
+	It("requires valid times, percentages and rates", func() {
+		err := ControlNetOptions{Delay: "50parsecs", Loss: "120%"}.Validate()
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Delay"))
+		Expect(err.Error()).To(ContainSubstring("Loss"))
+
+		err = ControlNetOptions{Bandwidth: "fast"}.Validate()
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Bandwidth"))
+
+		Expect(ControlNetOptions{Timeout: "1m", Delay: "50ms", Loss: "20%"}.Validate()).ToNot(HaveOccurred())
+		Expect(ControlNetOptions{Timeout: "1m", Bandwidth: "256kbps"}.Validate()).ToNot(HaveOccurred())
+	})
+
+	It("accepts corruption on its own", func() {
+		Expect(ControlNetOptions{Timeout: "1m", Corruption: "1%"}.Validate()).ToNot(HaveOccurred())
+
+		err := ControlNetOptions{Timeout: "1m", Corruption: "lots"}.Validate()
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Corruption"))
+	})
+
+	It("requires delay when reordering packets", func() {
+		err := ControlNetOptions{Reorder: "25%"}.Validate()
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Reorder"))
+	})
+
+	It("requires a single destination port", func() {
+		opts := ControlNetOptions{Delay: "50ms", Targets: []DestinationTarget{{DstPort: "1000:2000"}}}
+		Expect(opts.Validate()).To(HaveOccurred())
+	})
+})
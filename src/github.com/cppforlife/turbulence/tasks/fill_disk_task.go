@@ -18,18 +18,42 @@ type FillDiskOptions struct {
 	Temporary  bool
 }
 
-func (FillDiskOptions) _private() {}
+func init() {
+	Register(TaskType{
+		Name:        "FillDisk",
+		Description: "Fills root, persistent, ephemeral or temporary disk",
+
+		NewOptions: func() Options { return FillDiskOptions{} },
+		NewTask: func(opts Options, deps AgentDeps) (AgentTask, error) {
+			return NewFillDiskTask(deps.CmdRunner, opts.(FillDiskOptions), deps.Journal, deps.Recorder, deps.Logger), nil
+		},
+	})
+}
+
+func (o FillDiskOptions) Validate() error {
+	var errs fieldErrors
+	errs.validateTimeout(o.Timeout, true)
+	return errs.err()
+}
 
 type FillDiskTask struct {
 	cmdRunner boshsys.CmdRunner
 	opts      FillDiskOptions
+	journal   Journal
+	recorder  Recorder
 
 	logTag string
 	logger boshlog.Logger
 }
 
-func NewFillDiskTask(cmdRunner boshsys.CmdRunner, opts FillDiskOptions, logger boshlog.Logger) FillDiskTask {
-	return FillDiskTask{cmdRunner, opts, "tasks.FillDiskTask", logger}
+func NewFillDiskTask(
+	cmdRunner boshsys.CmdRunner,
+	opts FillDiskOptions,
+	journal Journal,
+	recorder Recorder,
+	logger boshlog.Logger,
+) FillDiskTask {
+	return FillDiskTask{cmdRunner, opts, journal, recorder, "tasks.FillDiskTask", logger}
 }
 
 func (t FillDiskTask) Execute(stopCh chan struct{}) error {
@@ -52,11 +76,15 @@ func (t FillDiskTask) Execute(stopCh chan struct{}) error {
 		return err
 	}
 
+	t.recorder.Applied()
+
 	select {
 	case <-stopCh:
 	case <-timeoutCh:
 	}
 
+	t.recorder.Reverting()
+
 	if t.opts.Persistent {
 		err = t.remove("/var/vcap/store/.filler")
 	} else if t.opts.Ephemeral {
@@ -67,10 +95,17 @@ func (t FillDiskTask) Execute(stopCh chan struct{}) error {
 		err = t.remove("/.filler")
 	}
 
+	t.recorder.Reverted(err)
+
 	return err
 }
 
 func (t FillDiskTask) fill(path string) error {
+	// Record before filling since filling takes a while
+	t.journal.Record("filler file "+path, "rm", "-f", path)
+
+	t.recorder.Action("dd if=/dev/zero of=" + path + " bs=1M")
+
 	_, _, _, err := t.cmdRunner.RunCommand("dd", "if=/dev/zero", "of="+path, "bs=1M")
 	if err != nil {
 		t.logger.Debug(t.logTag, "Encountered error filling disk: ", err)
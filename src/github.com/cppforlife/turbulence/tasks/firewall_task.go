@@ -1,12 +1,12 @@
 package tasks
 
 import (
-	"fmt"
-	"strings"
+	"net"
 
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
-	boshsys "github.com/cloudfoundry/bosh-utils/system"
+
+	"github.com/cppforlife/turbulence/tasks/netctl"
 )
 
 type FirewallOptions struct {
@@ -16,13 +16,33 @@ type FirewallOptions struct {
 	BlockBOSHAgent bool
 }
 
-func (FirewallOptions) _private() {}
+func init() {
+	Register(TaskType{
+		Name:        "Firewall",
+		Description: "Drops all traffic except SSH and traffic to the API server and optionally BOSH agent",
+
+		NewOptions: func() Options { return FirewallOptions{} },
+		NewTask: func(opts Options, deps AgentDeps) (AgentTask, error) {
+			return NewFirewallTask(deps.NetCtl, opts.(FirewallOptions), deps.AllowedOutputDests, deps.TaskID, deps.Journal, deps.Recorder, deps.Logger), nil
+		},
+
+		RequiredCapabilities: func(Options) []string { return []string{CapabilityNftables} },
+	})
+}
+
+func (o FirewallOptions) Validate() error {
+	var errs fieldErrors
+	errs.validateTimeout(o.Timeout, true)
+	return errs.err()
+}
 
 type FirewallTask struct {
-	cmdRunner boshsys.CmdRunner
-	opts      FirewallOptions
+	opts FirewallOptions
 
 	allowedOutputDest []FirewallTaskDest
+
+	filter   packetFilter
+	recorder Recorder
 }
 
 type FirewallTaskDest struct {
@@ -33,12 +53,16 @@ type FirewallTaskDest struct {
 }
 
 func NewFirewallTask(
-	cmdRunner boshsys.CmdRunner,
+	netCtl netctl.Controller,
 	opts FirewallOptions,
 	allowedOutputDest []FirewallTaskDest,
+	taskID string,
+	journal Journal,
+	recorder Recorder,
 	_ boshlog.Logger,
 ) FirewallTask {
-	return FirewallTask{cmdRunner, opts, allowedOutputDest}
+	filter := newPacketFilter(netCtl, taskID, journal, recorder)
+	return FirewallTask{opts, allowedOutputDest, filter, recorder}
 }
 
 func (t FirewallTask) Execute(stopCh chan struct{}) error {
@@ -47,75 +71,98 @@ func (t FirewallTask) Execute(stopCh chan struct{}) error {
 		return err
 	}
 
-	rules := t.rules()
+	rules, err := t.rules()
+	if err != nil {
+		return err
+	}
 
-	for _, r := range rules {
-		err := t.iptables("-A", r)
-		if err != nil {
-			return err
-		}
+	err = t.filter.Apply(rules)
+	if err != nil {
+		return err
 	}
 
+	t.recorder.Applied()
+
 	select {
 	case <-timeoutCh:
 	case <-stopCh:
 	}
 
-	for _, r := range rules {
-		err := t.iptables("-D", r)
-		if err != nil {
-			return err
-		}
-	}
+	t.recorder.Reverting()
+
+	err = t.filter.Remove()
+	t.recorder.Reverted(err)
 
-	return nil
+	return err
 }
 
-func (t FirewallTask) rules() []string {
-	var rules []string
+func (t FirewallTask) rules() ([]netctl.Rule, error) {
+	var inputRules, outputRules []netctl.Rule
 
-	// Allow response traffic from allowed destinations
-	inputRuleTpl := "INPUT ! -i lo -p tcp -s %s --sport %d -m state --state NEW,ESTABLISHED -j ACCEPT"
+	established := []string{"new", "established"}
 
 	for _, dest := range t.allowedOutputDest {
-		if !t.opts.BlockBOSHAgent || !dest.IsBOSHMbus {
-			rules = append(rules, fmt.Sprintf(inputRuleTpl, dest.Host, dest.Port))
+		if t.opts.BlockBOSHAgent && dest.IsBOSHMbus {
+			continue
 		}
-	}
 
-	// Allow all localhost input traffic; allow SSH traffic; drop rest
-	inputRules := []string{
-		"INPUT ! -i lo -p tcp --dport 22 -m state --state NEW,ESTABLISHED -j ACCEPT",
-		"INPUT ! -i lo -j DROP",
-	}
+		ipNets, err := t.resolve(dest.Host)
+		if err != nil {
+			return nil, err
+		}
 
-	rules = append(rules, inputRules...)
+		port := netctl.PortRange{From: uint16(dest.Port), To: uint16(dest.Port)}
 
-	// Allow outgoing traffic to allowed destinations
-	outputRuleTpl := "OUTPUT ! -o lo -p tcp -d %s --dport %d -m state --state NEW,ESTABLISHED -j ACCEPT"
+		for _, ipNet := range ipNets {
+			// Allow response traffic from allowed destinations
+			inputRules = append(inputRules, netctl.Rule{
+				Hook: netctl.HookInput, NotIface: "lo", Protocol: "tcp", Src: ipNet, SrcPorts: port,
+				States: established, Verdict: netctl.VerdictAccept,
+			})
 
-	for _, dest := range t.allowedOutputDest {
-		if !t.opts.BlockBOSHAgent || !dest.IsBOSHMbus {
-			rules = append(rules, fmt.Sprintf(outputRuleTpl, dest.Host, dest.Port))
+			// Allow outgoing traffic to allowed destinations
+			outputRules = append(outputRules, netctl.Rule{
+				Hook: netctl.HookOutput, NotIface: "lo", Protocol: "tcp", Dst: ipNet, DstPorts: port,
+				States: established, Verdict: netctl.VerdictAccept,
+			})
 		}
 	}
 
-	// Allow all localhost output traffic; allow SSH traffic; drop rest
-	outputRules := []string{
-		"OUTPUT ! -o lo -p tcp --sport 22 -m state --state NEW,ESTABLISHED -j ACCEPT",
-		"OUTPUT ! -o lo -j DROP",
-	}
+	ssh := netctl.PortRange{From: 22, To: 22}
 
-	return append(rules, outputRules...)
-}
+	// Allow all localhost traffic; allow SSH traffic; drop rest
+	inputRules = append(inputRules,
+		netctl.Rule{Hook: netctl.HookInput, NotIface: "lo", Protocol: "tcp", DstPorts: ssh, States: established, Verdict: netctl.VerdictAccept},
+		netctl.Rule{Hook: netctl.HookInput, NotIface: "lo", Verdict: netctl.VerdictDrop},
+	)
 
-func (t FirewallTask) iptables(action, rule string) error {
-	args := append([]string{action}, strings.Split(rule, " ")...)
+	outputRules = append(outputRules,
+		netctl.Rule{Hook: netctl.HookOutput, NotIface: "lo", Protocol: "tcp", SrcPorts: ssh, States: established, Verdict: netctl.VerdictAccept},
+		netctl.Rule{Hook: netctl.HookOutput, NotIface: "lo", Verdict: netctl.VerdictDrop},
+	)
 
-	_, _, _, err := t.cmdRunner.RunCommand("iptables", args...)
+	return append(inputRules, outputRules...), nil
+}
+
+// resolve returns IPv4 addresses of the host since rules match addresses;
+// iptables used to resolve host names the same way when adding rules
+func (t FirewallTask) resolve(host string) ([]*net.IPNet, error) {
+	ips, err := net.LookupIP(host)
 	if err != nil {
-		return bosherr.WrapError(err, "Shelling out to iptables")
+		return nil, bosherr.WrapErrorf(err, "Resolving allowed destination '%s'", host)
+	}
+
+	var ipNets []*net.IPNet
+
+	for _, ip := range ips {
+		if ip4 := ip.To4(); ip4 != nil {
+			ipNets = append(ipNets, &net.IPNet{IP: ip4, Mask: net.CIDRMask(32, 32)})
+		}
+	}
+
+	if len(ipNets) == 0 {
+		return nil, bosherr.Errorf("No IPv4 addresses found for allowed destination '%s'", host)
 	}
 
-	return nil
+	return ipNets, nil
 }
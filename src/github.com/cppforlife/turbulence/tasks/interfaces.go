@@ -1,5 +1,12 @@
 package tasks
 
+import (
+	"time"
+)
+
+// PollTimeout is the longest time agent's poll is held by the API server
+const PollTimeout = 20 * time.Second
+
 type Task struct {
 	ID string
 
@@ -7,7 +14,7 @@ type Task struct {
 }
 
 type Options interface {
-	_private()
+	Validate() error
 }
 
 func (t Task) Options() Options {
@@ -21,12 +28,21 @@ type State struct {
 }
 
 type Repo interface {
-	QueueAndWait(string, []Task) error
+	QueueAndWait(string, []Task, <-chan struct{}) error
 	Consume(string) ([]Task, error)
 
+	// Poll waits until there are tasks for the agent or some of its
+	// running tasks should stop; returns nothing once timeout passes.
+	Poll(string, []string, time.Duration, <-chan struct{}) ([]Task, []string, error)
+
 	Wait(string) (ResultRequest, error)
 	Update(string, ResultRequest) error
 
+	// WaitProgress waits until task reports more than given number of
+	// progress states; returns true once task finished instead.
+	WaitProgress(string, int) ([]Progress, bool, error)
+	UpdateProgress(string, []Progress) error
+
 	FetchState(string) (State, error)
 	UpdateState(string, StateRequest) error
 }
@@ -0,0 +1,12 @@
+package tasks
+
+// Journal records changes applied by agent tasks along with commands
+// that undo them so that agent can undo changes left behind by tasks
+// that were running when it stopped. Changes are forgotten once task ends.
+type Journal interface {
+	Record(description string, undoCmd ...string)
+}
+
+type noopJournal struct{}
+
+func (noopJournal) Record(string, ...string) {}
@@ -25,12 +25,30 @@ type KillProcessOptions struct {
 	// If names are empty, randomly selected monitored process is killed
 }
 
-func (KillProcessOptions) _private() {}
+func init() {
+	Register(TaskType{
+		Name:        "KillProcess",
+		Description: "Kills processes matching a pattern or monitored processes",
+
+		NewOptions: func() Options { return KillProcessOptions{} },
+		NewTask: func(opts Options, deps AgentDeps) (AgentTask, error) {
+			monitClient, err := deps.MonitProvider.Get()
+			if err != nil {
+				return nil, bosherr.WrapError(err, "Failed to retrieve monit client")
+			}
+
+			return NewKillProcessTask(monitClient, deps.CmdRunner, opts.(KillProcessOptions), deps.Recorder, deps.Logger), nil
+		},
+	})
+}
+
+func (KillProcessOptions) Validate() error { return nil }
 
 type KillProcessTask struct {
 	monitClient monit.Client
 	cmdRunner   boshsys.CmdRunner
 	opts        KillProcessOptions
+	recorder    Recorder
 
 	logTag string
 	logger boshlog.Logger
@@ -40,31 +58,45 @@ func NewKillProcessTask(
 	monitClient monit.Client,
 	cmdRunner boshsys.CmdRunner,
 	opts KillProcessOptions,
+	recorder Recorder,
 	logger boshlog.Logger,
 ) KillProcessTask {
-	return KillProcessTask{monitClient, cmdRunner, opts, "tasks.KillProcessTask", logger}
+	return KillProcessTask{monitClient, cmdRunner, opts, recorder, "tasks.KillProcessTask", logger}
 }
 
 func (t KillProcessTask) Execute(stopCh chan struct{}) error {
+	var err error
+
 	if len(t.opts.ProcessName) > 0 {
-		return t.killProcesses(t.opts.ProcessName)
+		err = t.killProcesses(t.opts.ProcessName)
+	} else if len(t.opts.MonitoredProcessName) > 0 {
+		err = t.killMatchingServices(t.opts.MonitoredProcessName)
+	} else {
+		err = t.killRandomService()
 	}
 
-	if len(t.opts.MonitoredProcessName) > 0 {
-		return t.killMatchingServices(t.opts.MonitoredProcessName)
+	if err != nil {
+		return err
 	}
 
-	return t.killRandomService()
+	// Nothing to revert since killed processes are restarted by monit
+	t.recorder.Applied()
+
+	return nil
 }
 
 func (t KillProcessTask) killProcesses(name string) error {
 	t.logger.Debug(t.logTag, "Killing processes matching '%s'", name)
 
+	t.recorder.PIDs(matchingPIDs(t.cmdRunner, name)...)
+
 	_, _, _, err := t.cmdRunner.RunCommand("pkill", "-9", name)
 	if err != nil {
 		return bosherr.WrapError(err, "Killing processes")
 	}
 
+	t.recorder.Action("pkill -9 " + name)
+
 	return nil
 }
 
@@ -132,5 +164,8 @@ func (t KillProcessTask) killService(service monit.Service) error {
 		return bosherr.WrapError(err, "Killing process")
 	}
 
+	t.recorder.PIDs(service.PID)
+	t.recorder.Action("kill -9 " + strconv.Itoa(service.PID) + " (" + service.Name + ")")
+
 	return nil
 }
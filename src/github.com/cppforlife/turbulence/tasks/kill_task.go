@@ -5,4 +5,13 @@ type KillOptions struct {
 	Type string
 }
 
-func (KillOptions) _private() {}
+func init() {
+	Register(TaskType{
+		Name:        "Kill",
+		Description: "Deletes VM via the Director",
+
+		NewOptions: func() Options { return KillOptions{} },
+	})
+}
+
+func (KillOptions) Validate() error { return nil }
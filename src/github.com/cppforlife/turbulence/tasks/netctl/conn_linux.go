@@ -0,0 +1,154 @@
+package netctl
+
+import (
+	"syscall"
+)
+
+// message is a netlink message without header
+type message struct {
+	Type  uint16
+	Flags uint16
+	Data  []byte
+}
+
+// conn is a netlink socket used for a single request or batch
+type conn struct {
+	fd  int
+	seq uint32
+}
+
+func dial(protocol int) (*conn, error) {
+	fd, err := syscall.Socket(syscall.AF_NETLINK, syscall.SOCK_RAW|syscall.SOCK_CLOEXEC, protocol)
+	if err != nil {
+		return nil, err
+	}
+
+	err = syscall.Bind(fd, &syscall.SockaddrNetlink{Family: syscall.AF_NETLINK})
+	if err != nil {
+		syscall.Close(fd)
+		return nil, err
+	}
+
+	return &conn{fd: fd}, nil
+}
+
+func (c *conn) Close() error { return syscall.Close(c.fd) }
+
+// execute sends messages at once and waits until kernel acknowledges
+// each message that requested acknowledgement; first error is returned
+func (c *conn) execute(msgs []message) error {
+	pending, err := c.send(msgs)
+	if err != nil {
+		return err
+	}
+
+	for len(pending) > 0 {
+		received, err := c.receive()
+		if err != nil {
+			return err
+		}
+
+		for _, msg := range received {
+			if msg.Header.Type != syscall.NLMSG_ERROR {
+				continue
+			}
+
+			err := errnoOf(msg)
+			if err != nil {
+				return err
+			}
+
+			delete(pending, msg.Header.Seq)
+		}
+	}
+
+	return nil
+}
+
+// dump sends dump request and returns all received messages until NLMSG_DONE
+func (c *conn) dump(msgType uint16, data []byte) ([]syscall.NetlinkMessage, error) {
+	_, err := c.send([]message{{Type: msgType, Flags: flagRequest | flagDump, Data: data}})
+	if err != nil {
+		return nil, err
+	}
+
+	var result []syscall.NetlinkMessage
+
+	for {
+		received, err := c.receive()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, msg := range received {
+			switch msg.Header.Type {
+			case syscall.NLMSG_DONE:
+				return result, nil
+
+			case syscall.NLMSG_ERROR:
+				return result, errnoOf(msg)
+
+			default:
+				result = append(result, msg)
+			}
+		}
+	}
+}
+
+// send returns sequence numbers of messages that requested acknowledgement
+func (c *conn) send(msgs []message) (map[uint32]struct{}, error) {
+	var buf []byte
+
+	pending := map[uint32]struct{}{}
+
+	for _, msg := range msgs {
+		c.seq++
+
+		b := make([]byte, syscall.NLMSG_HDRLEN, syscall.NLMSG_HDRLEN+len(msg.Data))
+		nativeEndian.PutUint32(b[0:4], uint32(syscall.NLMSG_HDRLEN+len(msg.Data)))
+		nativeEndian.PutUint16(b[4:6], msg.Type)
+		nativeEndian.PutUint16(b[6:8], msg.Flags)
+		nativeEndian.PutUint32(b[8:12], c.seq)
+
+		buf = append(buf, append(b, msg.Data...)...)
+
+		// Messages are aligned to 4 bytes
+		buf = append(buf, make([]byte, alignAttr(len(buf))-len(buf))...)
+
+		if msg.Flags&flagAck != 0 {
+			pending[c.seq] = struct{}{}
+		}
+	}
+
+	err := syscall.Sendto(c.fd, buf, 0, &syscall.SockaddrNetlink{Family: syscall.AF_NETLINK})
+	if err != nil {
+		return nil, err
+	}
+
+	return pending, nil
+}
+
+func (c *conn) receive() ([]syscall.NetlinkMessage, error) {
+	// Errors include failed request hence buffer fits the largest request
+	buf := make([]byte, 64*1024)
+
+	n, _, err := syscall.Recvfrom(c.fd, buf, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	return syscall.ParseNetlinkMessage(buf[:n])
+}
+
+// errnoOf returns error of NLMSG_ERROR message; acknowledgements have no error
+func errnoOf(msg syscall.NetlinkMessage) error {
+	if len(msg.Data) < 4 {
+		return syscall.EBADMSG
+	}
+
+	if errno := int32(nativeEndian.Uint32(msg.Data[0:4])); errno != 0 {
+		return syscall.Errno(-errno)
+	}
+
+	return nil
+}
@@ -0,0 +1,104 @@
+package netctl
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// Encoding is tested internally since it cannot be observed
+// without changing network configuration of the VM
+var _ = Describe("netlink encoding", func() {
+	It("parses psched the same way as tc", func() {
+		clk, err := parsePsched("000003e8 00000040 000f4240 3b9aca00\n")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(clk).To(Equal(defaultClock))
+
+		_, err = parsePsched("000003e8 00000040")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("reads back u32 filters", func() {
+		dst, err := ParseIPNet("10.0.0.0/8")
+		Expect(err).ToNot(HaveOccurred())
+
+		for _, filter := range []Filter{
+			{Iface: "eth0", Kind: "u32", Parent: 0x10000, Prio: 1, ClassID: 0x10001, Dst: dst, DstPort: 80},
+			{Iface: "eth0", Kind: "u32", Parent: 0x10000, Prio: 1, ClassID: 0x10001, DstPort: 443},
+			{Iface: "eth0", Kind: "u32", Parent: 0x10000, Prio: 1, ClassID: 0x10001},
+		} {
+			a, err := filterAttrs(filter)
+			Expect(err).ToNot(HaveOccurred())
+
+			data := append(tcMsg(2, 0, filter.Parent, filterInfo(filter.Prio)), a...)
+			Expect(parseFilter("eth0", data)).To(Equal(filter))
+		}
+	})
+
+	It("reads back htb classes including rates that do not fit 32 bits", func() {
+		for _, rate := range []uint64{125000, 5000000000} {
+			class := Class{Iface: "eth0", Kind: "htb", Handle: 0x10001, Parent: 0x10000, Rate: rate}
+
+			a, err := classAttrs(class, defaultClock)
+			Expect(err).ToNot(HaveOccurred())
+
+			data := append(tcMsg(2, class.Handle, class.Parent, 0), a...)
+			Expect(parseClass("eth0", data)).To(Equal(class))
+		}
+	})
+
+	It("sets buffer of htb class to the time of sending a packet at given rate", func() {
+		a, err := classAttrs(Class{Kind: "htb", Rate: 125000}, defaultClock)
+		Expect(err).ToNot(HaveOccurred())
+
+		parms := parseAttrs(parseAttrs(a)[tcaOptions])[tcaHTBParms]
+		Expect(nativeEndian.Uint32(parms[24:28])).To(Equal(uint32(200000))) // 12.8ms in 64ns ticks
+	})
+
+	It("encodes netem options in scheduler ticks and probabilities", func() {
+		opts := netemOptions(Netem{Delay: 50 * time.Millisecond, Jitter: 10 * time.Millisecond, Loss: 50, LossCorrelation: 75}, defaultClock)
+
+		Expect(nativeEndian.Uint32(opts[0:4])).To(Equal(uint32(781250))) // 50ms in 64ns ticks
+		Expect(nativeEndian.Uint32(opts[4:8])).To(Equal(uint32(1000)))
+		Expect(nativeEndian.Uint32(opts[8:12])).To(Equal(uint32(0x80000000)))
+		Expect(nativeEndian.Uint32(opts[20:24])).To(Equal(uint32(156250)))
+
+		a := parseAttrs(opts[24:])
+		Expect(nativeEndian.Uint32(a[tcaNetemCorr][4:8])).To(Equal(uint32(3221225471)))
+		Expect(a[tcaNetemDelayDist]).To(HaveLen(4096 * 2))
+		Expect(a).ToNot(HaveKey(uint16(tcaNetemReorder)))
+	})
+
+	It("translates rules into nftables expressions ending with verdict", func() {
+		src, err := ParseIPNet("10.0.0.0/24")
+		Expect(err).ToNot(HaveOccurred())
+
+		rule := Rule{
+			Hook:     HookInput,
+			NotIface: "lo",
+			Protocol: "tcp",
+			Src:      src,
+			DstPorts: PortRange{From: 1000, To: 2000},
+			States:   []string{"new"},
+			Verdict:  VerdictAccept,
+		}
+
+		var names []string
+
+		for b := []byte(ruleExprs(rule)); len(b) > 0; {
+			attrLen := int(nativeEndian.Uint16(b[0:2]))
+			names = append(names, cString(parseAttrs(b[attrHdrLen:attrLen])[nftaExprName]))
+			b = b[alignAttr(attrLen):]
+		}
+
+		Expect(names).To(Equal([]string{
+			"meta", "cmp", // iifname
+			"meta", "cmp", // l4proto
+			"payload", "bitwise", "cmp", // saddr
+			"payload", "cmp", "cmp", // dport range
+			"ct", "bitwise", "cmp", // state
+			"immediate",
+		}))
+	})
+})
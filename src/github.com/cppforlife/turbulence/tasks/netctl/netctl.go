@@ -0,0 +1,119 @@
+// Package netctl reads and changes network configuration of the VM natively
+// via netlink (rtnetlink for qdiscs, classes and filters and nftables for
+// packet filtering) instead of building command lines for tc and iptables
+// so that tasks get structured errors and can verify applied changes.
+package netctl
+
+import (
+	"fmt"
+)
+
+const (
+	// HandleRoot is the parent of qdiscs attached directly to an interface
+	HandleRoot uint32 = 0xFFFFFFFF
+)
+
+type Controller interface {
+	// Qdiscs returns queueing disciplines attached to the interface
+	Qdiscs(ifaceName string) ([]Qdisc, error)
+
+	// Classes returns classes of classful qdiscs attached to the interface
+	Classes(ifaceName string) ([]Class, error)
+
+	// Filters returns filters attached to qdisc or class with given handle
+	Filters(ifaceName string, parent uint32) ([]Filter, error)
+
+	// AddQdisc fails if qdisc with the same handle or parent already exists
+	AddQdisc(qdisc Qdisc) error
+	AddClass(class Class) error
+	AddFilter(filter Filter) error
+
+	// DeleteRootQdisc removes root qdisc along with its classes and filters
+	DeleteRootQdisc(ifaceName string) error
+
+	// PacketFilterChains returns chains (one per hook) of packet filter
+	PacketFilterChains(name string) ([]Chain, error)
+
+	// AddPacketFilter adds all rules of packet filter at once or none of them
+	AddPacketFilter(filter PacketFilter) error
+
+	// DeletePacketFilter removes packet filter along with its rules
+	DeletePacketFilter(name string) error
+}
+
+type Qdisc struct {
+	Iface  string
+	Kind   string // e.g. prio, htb, netem, pfifo_fast
+	Handle uint32
+	Parent uint32
+
+	// Netem is required when adding netem qdisc; it is not read back
+	Netem *Netem
+}
+
+// IsRoot is true for qdisc attached directly to the interface
+func (q Qdisc) IsRoot() bool { return q.Parent == HandleRoot }
+
+// IsDefault is true for qdiscs that kernel attaches when nothing was configured;
+// such qdiscs do not have a handle assigned and can be replaced.
+func (q Qdisc) IsDefault() bool { return q.Handle == 0 }
+
+func (q Qdisc) HandleStr() string { return FormatHandle(q.Handle) }
+
+// FormatHandle formats handle the same way as tc (e.g. 1: or 30:1)
+func FormatHandle(handle uint32) string {
+	if handle == HandleRoot {
+		return "root"
+	}
+
+	major, minor := handle>>16, handle&0xFFFF
+
+	if minor == 0 {
+		return fmt.Sprintf("%x:", major)
+	}
+
+	return fmt.Sprintf("%x:%x", major, minor)
+}
+
+// Error describes failed netlink request; Err is syscall.Errno
+// when kernel rejected the request
+type Error struct {
+	Op    string
+	Iface string
+	Err   error
+}
+
+func (e Error) Error() string {
+	if len(e.Iface) == 0 {
+		return fmt.Sprintf("Netlink %s: %s", e.Op, e.Err.Error())
+	}
+
+	return fmt.Sprintf("Netlink %s on interface '%s': %s", e.Op, e.Iface, e.Err.Error())
+}
+
+// FindRootQdisc returns configured root qdisc of the interface if there is one
+func FindRootQdisc(c Controller, ifaceName string) (Qdisc, bool, error) {
+	qdiscs, err := c.Qdiscs(ifaceName)
+	if err != nil {
+		return Qdisc{}, false, err
+	}
+
+	for _, qdisc := range qdiscs {
+		if qdisc.IsRoot() && !qdisc.IsDefault() {
+			return qdisc, true, nil
+		}
+	}
+
+	return Qdisc{}, false, nil
+}
+
+// QdiscExistsError is returned when interface already has configured
+// root qdisc that would be replaced or removed by a task
+type QdiscExistsError struct {
+	Qdisc Qdisc
+}
+
+func (e QdiscExistsError) Error() string {
+	return fmt.Sprintf("Interface '%s' already has root qdisc '%s' with handle '%s'",
+		e.Qdisc.Iface, e.Qdisc.Kind, e.Qdisc.HandleStr())
+}
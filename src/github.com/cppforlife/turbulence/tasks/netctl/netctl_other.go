@@ -0,0 +1,55 @@
+//go:build !linux
+// +build !linux
+
+package netctl
+
+import (
+	"errors"
+)
+
+type unsupported struct{}
+
+var errUnsupported = errors.New("Netlink is not supported on this platform")
+
+// NewController returns controller that fails since netlink is only available on Linux
+func NewController() Controller { return unsupported{} }
+
+func (unsupported) Qdiscs(ifaceName string) ([]Qdisc, error) {
+	return nil, Error{Op: "list qdiscs", Iface: ifaceName, Err: errUnsupported}
+}
+
+func (unsupported) Classes(ifaceName string) ([]Class, error) {
+	return nil, Error{Op: "list classes", Iface: ifaceName, Err: errUnsupported}
+}
+
+func (unsupported) Filters(ifaceName string, _ uint32) ([]Filter, error) {
+	return nil, Error{Op: "list filters", Iface: ifaceName, Err: errUnsupported}
+}
+
+func (unsupported) AddQdisc(qdisc Qdisc) error {
+	return Error{Op: "add qdisc", Iface: qdisc.Iface, Err: errUnsupported}
+}
+
+func (unsupported) AddClass(class Class) error {
+	return Error{Op: "add class", Iface: class.Iface, Err: errUnsupported}
+}
+
+func (unsupported) AddFilter(filter Filter) error {
+	return Error{Op: "add filter", Iface: filter.Iface, Err: errUnsupported}
+}
+
+func (unsupported) DeleteRootQdisc(ifaceName string) error {
+	return Error{Op: "delete root qdisc", Iface: ifaceName, Err: errUnsupported}
+}
+
+func (unsupported) PacketFilterChains(name string) ([]Chain, error) {
+	return nil, Error{Op: "list chains of packet filter " + name, Err: errUnsupported}
+}
+
+func (unsupported) AddPacketFilter(filter PacketFilter) error {
+	return Error{Op: "add packet filter " + filter.Name, Err: errUnsupported}
+}
+
+func (unsupported) DeletePacketFilter(name string) error {
+	return Error{Op: "delete packet filter " + name, Err: errUnsupported}
+}
@@ -0,0 +1,51 @@
+package netctl_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cppforlife/turbulence/tasks/netctl"
+)
+
+// fakeController panics for methods that are not faked
+type fakeController struct {
+	Controller
+
+	qdiscs []Qdisc
+}
+
+func (c fakeController) Qdiscs(string) ([]Qdisc, error) { return c.qdiscs, nil }
+
+var _ = Describe("FormatHandle", func() {
+	It("formats handles the same way as tc", func() {
+		Expect(FormatHandle(0x10000)).To(Equal("1:"))
+		Expect(FormatHandle(0x300001)).To(Equal("30:1"))
+		Expect(FormatHandle(0)).To(Equal("0:"))
+		Expect(FormatHandle(HandleRoot)).To(Equal("root"))
+	})
+})
+
+var _ = Describe("FindRootQdisc", func() {
+	It("ignores default qdiscs attached by kernel", func() {
+		ctl := fakeController{qdiscs: []Qdisc{{Iface: "eth0", Kind: "pfifo_fast", Parent: HandleRoot}}}
+
+		_, found, err := FindRootQdisc(ctl, "eth0")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(found).To(BeFalse())
+	})
+
+	It("returns configured root qdisc", func() {
+		ctl := fakeController{qdiscs: []Qdisc{
+			{Iface: "eth0", Kind: "netem", Handle: 0x300000, Parent: 0x10001},
+			{Iface: "eth0", Kind: "prio", Handle: 0x10000, Parent: HandleRoot},
+		}}
+
+		qdisc, found, err := FindRootQdisc(ctl, "eth0")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(found).To(BeTrue())
+		Expect(qdisc.Kind).To(Equal("prio"))
+
+		Expect(QdiscExistsError{Qdisc: qdisc}.Error()).To(Equal(
+			"Interface 'eth0' already has root qdisc 'prio' with handle '1:'"))
+	})
+})
@@ -0,0 +1,109 @@
+package netctl
+
+import (
+	"encoding/binary"
+	"unsafe"
+)
+
+const (
+	attrHdrLen = 4
+	attrAlign  = 4
+
+	// attrNested marks attributes whose values are attributes themselves
+	attrNested uint16 = 1 << 15
+
+	// attrTypeMask clears nested and network byte order flags
+	attrTypeMask uint16 = 0x3FFF
+
+	ifNameSize = 16 // IFNAMSIZ
+
+	flagRequest = 0x1
+	flagAck     = 0x4
+	flagExcl    = 0x200
+	flagDump    = 0x300
+	flagCreate  = 0x400
+	flagAppend  = 0x800
+)
+
+// attrs builds netlink attributes in host byte order headers
+type attrs []byte
+
+func (a *attrs) add(attrType uint16, val []byte) {
+	attrLen := attrHdrLen + len(val)
+
+	b := make([]byte, alignAttr(attrLen))
+	nativeEndian.PutUint16(b[0:2], uint16(attrLen))
+	nativeEndian.PutUint16(b[2:4], attrType)
+	copy(b[attrHdrLen:], val)
+
+	*a = append(*a, b...)
+}
+
+func (a *attrs) addString(attrType uint16, val string) {
+	a.add(attrType, append([]byte(val), 0))
+}
+
+func (a *attrs) addUint32(attrType uint16, val uint32) {
+	b := make([]byte, 4)
+	nativeEndian.PutUint32(b, val)
+	a.add(attrType, b)
+}
+
+// addBE32 adds value in network byte order used by nftables
+func (a *attrs) addBE32(attrType uint16, val uint32) {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, val)
+	a.add(attrType, b)
+}
+
+func (a *attrs) addNested(attrType uint16, nested attrs) {
+	a.add(attrType|attrNested, nested)
+}
+
+// parseAttrs returns values of attributes by their type (without flags);
+// malformed trailing attributes are ignored
+func parseAttrs(b []byte) map[uint16][]byte {
+	parsed := map[uint16][]byte{}
+
+	for len(b) >= attrHdrLen {
+		attrLen := int(nativeEndian.Uint16(b[0:2]))
+		if attrLen < attrHdrLen || attrLen > len(b) {
+			break
+		}
+
+		parsed[nativeEndian.Uint16(b[2:4])&attrTypeMask] = b[attrHdrLen:attrLen]
+
+		alignedLen := alignAttr(attrLen)
+		if alignedLen > len(b) {
+			break
+		}
+
+		b = b[alignedLen:]
+	}
+
+	return parsed
+}
+
+// cString returns value of null terminated string attribute
+func cString(val []byte) string {
+	for i, c := range val {
+		if c == 0 {
+			return string(val[:i])
+		}
+	}
+
+	return string(val)
+}
+
+func alignAttr(attrLen int) int {
+	return (attrLen + attrAlign - 1) &^ (attrAlign - 1)
+}
+
+// Netlink messages use host byte order
+var nativeEndian binary.ByteOrder = func() binary.ByteOrder {
+	i := uint16(1)
+	if *(*byte)(unsafe.Pointer(&i)) == 1 {
+		return binary.LittleEndian
+	}
+	return binary.BigEndian
+}()
@@ -0,0 +1,303 @@
+package netctl
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+)
+
+const (
+	nfgenmsgLen = 4 // struct nfgenmsg
+
+	nfnlSubsysNftables = 10
+	nfnlMsgBatchBegin  = 0x10
+	nfnlMsgBatchEnd    = 0x11
+
+	nftMsgNewTable = 0
+	nftMsgDelTable = 2
+	nftMsgNewChain = 3
+	nftMsgGetChain = 4
+	nftMsgNewRule  = 6
+	nftMsgGetRule  = 7
+
+	nfprotoIPv4 = 2
+
+	nftaTableName = 1
+
+	nftaChainTable  = 1
+	nftaChainName   = 3
+	nftaChainHook   = 4
+	nftaChainPolicy = 5
+	nftaChainType   = 7
+
+	nftaHookHooknum  = 1
+	nftaHookPriority = 2
+
+	nftaRuleTable       = 1
+	nftaRuleChain       = 2
+	nftaRuleExpressions = 4
+
+	nftaListElem = 1
+	nftaExprName = 1
+	nftaExprData = 2
+
+	nftaCmpSreg = 1
+	nftaCmpOp   = 2
+	nftaCmpData = 3
+
+	nftaBitwiseSreg = 1
+	nftaBitwiseDreg = 2
+	nftaBitwiseLen  = 3
+	nftaBitwiseMask = 4
+	nftaBitwiseXor  = 5
+
+	nftaImmediateDreg = 1
+	nftaImmediateData = 2
+
+	nftaDataValue   = 1
+	nftaDataVerdict = 2
+	nftaVerdictCode = 1
+
+	nftRegVerdict = 0
+	nftReg1       = 1
+
+	nftMetaIifname = 6
+	nftMetaOifname = 7
+	nftMetaL4proto = 16
+
+	nftCtState = 0
+
+	nftPayloadNetworkHeader   = 1
+	nftPayloadTransportHeader = 2
+
+	nftCmpEq  = 0
+	nftCmpNeq = 1
+	nftCmpLte = 3
+	nftCmpGte = 5
+
+	nfDrop   = 0
+	nfAccept = 1
+
+	// Offsets within IPv4 and transport headers
+	ipSaddrOff = 12
+	ipDaddrOff = 16
+	thSportOff = 0
+	thDportOff = 2
+)
+
+var (
+	hookNums = map[string]uint32{HookInput: 1, HookForward: 2, HookOutput: 3}
+	verdicts = map[string]uint32{VerdictAccept: nfAccept, VerdictDrop: nfDrop}
+)
+
+// nftMsg is a netfilter message of nftables subsystem
+type nftMsg struct {
+	Type  uint16
+	Flags uint16
+	Data  []byte
+}
+
+func nftMsgType(msgType uint16) uint16 { return nfnlSubsysNftables<<8 | msgType }
+
+func nfgenmsg(family byte, resID uint16) []byte {
+	b := make([]byte, nfgenmsgLen)
+	b[0] = family
+	binary.BigEndian.PutUint16(b[2:4], resID)
+	return b
+}
+
+// packetFilterMsgs returns messages that create table, chains and rules
+// of packet filter; they have to be sent as a single batch
+func packetFilterMsgs(f PacketFilter) ([]nftMsg, error) {
+	var a attrs
+	a.addString(nftaTableName, f.Name)
+
+	msgs := []nftMsg{{Type: nftMsgType(nftMsgNewTable), Flags: flagCreate | flagExcl, Data: append(nfgenmsg(nfprotoIPv4, 0), a...)}}
+
+	for _, hook := range f.Hooks() {
+		var hookAttrs attrs
+		hookAttrs.addBE32(nftaHookHooknum, hookNums[hook])
+		hookAttrs.addBE32(nftaHookPriority, 0)
+
+		var a attrs
+		a.addString(nftaChainTable, f.Name)
+		a.addString(nftaChainName, hook)
+		a.addNested(nftaChainHook, hookAttrs)
+		a.addBE32(nftaChainPolicy, nfAccept)
+		a.addString(nftaChainType, "filter")
+
+		msgs = append(msgs, nftMsg{Type: nftMsgType(nftMsgNewChain), Flags: flagCreate, Data: append(nfgenmsg(nfprotoIPv4, 0), a...)})
+	}
+
+	for i, rule := range f.Rules {
+		err := rule.Validate()
+		if err != nil {
+			return nil, fmt.Errorf("Rule %d: %s", i, err.Error())
+		}
+
+		var a attrs
+		a.addString(nftaRuleTable, f.Name)
+		a.addString(nftaRuleChain, rule.Hook)
+		a.addNested(nftaRuleExpressions, ruleExprs(rule))
+
+		msgs = append(msgs, nftMsg{Type: nftMsgType(nftMsgNewRule), Flags: flagCreate | flagAppend, Data: append(nfgenmsg(nfprotoIPv4, 0), a...)})
+	}
+
+	return msgs, nil
+}
+
+func deletePacketFilterMsg(name string) nftMsg {
+	var a attrs
+	a.addString(nftaTableName, name)
+
+	return nftMsg{Type: nftMsgType(nftMsgDelTable), Data: append(nfgenmsg(nfprotoIPv4, 0), a...)}
+}
+
+// ruleExprs translates rule into nftables expressions; each condition loads
+// packet data into a register and compares it so that the rule stops matching
+func ruleExprs(r Rule) attrs {
+	var list attrs
+
+	elem := func(name string, data attrs) {
+		var e attrs
+		e.addString(nftaExprName, name)
+		e.addNested(nftaExprData, data)
+		list.addNested(nftaListElem, e)
+	}
+
+	// Attributes of meta, payload and ct expressions (destination register
+	// followed by what to load) are numbered in order of values
+	load := func(name string, vals ...uint32) {
+		var data attrs
+		for i, val := range vals {
+			data.addBE32(uint16(i+1), val)
+		}
+		elem(name, data)
+	}
+
+	cmp := func(op uint32, val []byte) {
+		var value, data attrs
+		value.add(nftaDataValue, val)
+		data.addBE32(nftaCmpSreg, nftReg1)
+		data.addBE32(nftaCmpOp, op)
+		data.addNested(nftaCmpData, value)
+		elem("cmp", data)
+	}
+
+	bitwise := func(mask []byte) {
+		var maskVal, xorVal, data attrs
+		maskVal.add(nftaDataValue, mask)
+		xorVal.add(nftaDataValue, make([]byte, len(mask)))
+		data.addBE32(nftaBitwiseSreg, nftReg1)
+		data.addBE32(nftaBitwiseDreg, nftReg1)
+		data.addBE32(nftaBitwiseLen, uint32(len(mask)))
+		data.addNested(nftaBitwiseMask, maskVal)
+		data.addNested(nftaBitwiseXor, xorVal)
+		elem("bitwise", data)
+	}
+
+	if len(r.NotIface) > 0 {
+		key := uint32(nftMetaIifname)
+		if r.Hook == HookOutput {
+			key = nftMetaOifname
+		}
+
+		name := make([]byte, ifNameSize)
+		copy(name, r.NotIface)
+
+		load("meta", nftReg1, key) // dreg, key
+		cmp(nftCmpNeq, name)
+	}
+
+	if len(r.Protocol) > 0 {
+		load("meta", nftReg1, nftMetaL4proto)
+		cmp(nftCmpEq, []byte{protocolNumbers[r.Protocol]})
+	}
+
+	for _, addr := range []struct {
+		ipNet *net.IPNet
+		off   uint32
+	}{{r.Src, ipSaddrOff}, {r.Dst, ipDaddrOff}} {
+		if addr.ipNet == nil {
+			continue
+		}
+
+		load("payload", nftReg1, nftPayloadNetworkHeader, addr.off, net.IPv4len) // dreg, base, offset, len
+
+		if ones, _ := addr.ipNet.Mask.Size(); ones < 32 {
+			bitwise(addr.ipNet.Mask)
+		}
+
+		cmp(nftCmpEq, addr.ipNet.IP.To4().Mask(addr.ipNet.Mask))
+	}
+
+	for _, ports := range []struct {
+		r   PortRange
+		off uint32
+	}{{r.SrcPorts, thSportOff}, {r.DstPorts, thDportOff}} {
+		if ports.r.IsZero() {
+			continue
+		}
+
+		load("payload", nftReg1, nftPayloadTransportHeader, ports.off, 2)
+
+		if ports.r.From == ports.r.To {
+			cmp(nftCmpEq, be16(ports.r.From))
+		} else {
+			cmp(nftCmpGte, be16(ports.r.From))
+			cmp(nftCmpLte, be16(ports.r.To))
+		}
+	}
+
+	if len(r.States) > 0 {
+		var mask uint32
+		for _, state := range r.States {
+			mask |= ctStates[state]
+		}
+
+		load("ct", nftReg1, nftCtState) // dreg, key
+
+		// Conntrack state is kept in host byte order
+		b := make([]byte, 4)
+		nativeEndian.PutUint32(b, mask)
+		bitwise(b)
+		cmp(nftCmpNeq, make([]byte, 4))
+	}
+
+	var verdict, verdictData, data attrs
+	verdict.addBE32(nftaVerdictCode, verdicts[r.Verdict])
+	verdictData.addNested(nftaDataVerdict, verdict)
+	data.addBE32(nftaImmediateDreg, nftRegVerdict)
+	data.addNested(nftaImmediateData, verdictData)
+	elem("immediate", data)
+
+	return list
+}
+
+// parseChain and parseRule decode dumped messages (nfgenmsg followed by attributes)
+func parseChain(data []byte) (string, Chain) {
+	a := parseAttrs(data[nfgenmsgLen:])
+
+	chain := Chain{Name: cString(a[nftaChainName])}
+
+	if hookNum := parseAttrs(a[nftaChainHook])[nftaHookHooknum]; len(hookNum) == 4 {
+		for hook, num := range hookNums {
+			if num == binary.BigEndian.Uint32(hookNum) {
+				chain.Hook = hook
+			}
+		}
+	}
+
+	return cString(a[nftaChainTable]), chain
+}
+
+func parseRule(data []byte) (string, string) {
+	a := parseAttrs(data[nfgenmsgLen:])
+	return cString(a[nftaRuleTable]), cString(a[nftaRuleChain])
+}
+
+func be16(val uint16) []byte {
+	b := make([]byte, 2)
+	binary.BigEndian.PutUint16(b, val)
+	return b
+}
@@ -0,0 +1,99 @@
+package netctl
+
+import (
+	"syscall"
+)
+
+func (netlinkCtl) PacketFilterChains(name string) ([]Chain, error) {
+	conn, err := dial(syscall.NETLINK_NETFILTER)
+	if err != nil {
+		return nil, Error{Op: "list chains of packet filter " + name, Err: err}
+	}
+
+	defer conn.Close()
+
+	chainMsgs, err := conn.dump(nftMsgType(nftMsgGetChain), nfgenmsg(nfprotoIPv4, 0))
+	if err != nil {
+		return nil, Error{Op: "list chains of packet filter " + name, Err: err}
+	}
+
+	ruleMsgs, err := conn.dump(nftMsgType(nftMsgGetRule), nfgenmsg(nfprotoIPv4, 0))
+	if err != nil {
+		return nil, Error{Op: "list rules of packet filter " + name, Err: err}
+	}
+
+	var chains []Chain
+
+	for _, msg := range chainMsgs {
+		if msg.Header.Type != nftMsgType(nftMsgNewChain) || len(msg.Data) < nfgenmsgLen {
+			continue
+		}
+
+		if table, chain := parseChain(msg.Data); table == name {
+			chains = append(chains, chain)
+		}
+	}
+
+	for _, msg := range ruleMsgs {
+		if msg.Header.Type != nftMsgType(nftMsgNewRule) || len(msg.Data) < nfgenmsgLen {
+			continue
+		}
+
+		table, chainName := parseRule(msg.Data)
+		if table != name {
+			continue
+		}
+
+		for i := range chains {
+			if chains[i].Name == chainName {
+				chains[i].Rules++
+			}
+		}
+	}
+
+	return chains, nil
+}
+
+func (netlinkCtl) AddPacketFilter(filter PacketFilter) error {
+	msgs, err := packetFilterMsgs(filter)
+	if err != nil {
+		return Error{Op: "add packet filter " + filter.Name, Err: err}
+	}
+
+	err = executeBatch(msgs)
+	if err != nil {
+		return Error{Op: "add packet filter " + filter.Name, Err: err}
+	}
+
+	return nil
+}
+
+func (netlinkCtl) DeletePacketFilter(name string) error {
+	err := executeBatch([]nftMsg{deletePacketFilterMsg(name)})
+	if err != nil {
+		return Error{Op: "delete packet filter " + name, Err: err}
+	}
+
+	return nil
+}
+
+// executeBatch sends messages as a single transaction;
+// kernel either applies all of them or none
+func executeBatch(msgs []nftMsg) error {
+	conn, err := dial(syscall.NETLINK_NETFILTER)
+	if err != nil {
+		return err
+	}
+
+	defer conn.Close()
+
+	batch := []message{{Type: nfnlMsgBatchBegin, Flags: flagRequest, Data: nfgenmsg(syscall.AF_UNSPEC, nfnlSubsysNftables)}}
+
+	for _, msg := range msgs {
+		batch = append(batch, message{Type: msg.Type, Flags: msg.Flags | flagRequest | flagAck, Data: msg.Data})
+	}
+
+	batch = append(batch, message{Type: nfnlMsgBatchEnd, Flags: flagRequest, Data: nfgenmsg(syscall.AF_UNSPEC, nfnlSubsysNftables)})
+
+	return conn.execute(batch)
+}
@@ -0,0 +1,271 @@
+package netctl
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	HookInput   = "input"
+	HookOutput  = "output"
+	HookForward = "forward"
+
+	VerdictAccept = "accept"
+	VerdictDrop   = "drop"
+)
+
+var (
+	hooks = []string{HookInput, HookOutput, HookForward}
+
+	protocolNumbers = map[string]byte{"icmp": 1, "tcp": 6, "udp": 17}
+
+	// Bits of conntrack state as seen by nftables
+	ctStates = map[string]uint32{"invalid": 1, "established": 2, "related": 4, "new": 8, "untracked": 64}
+
+	nonAlphanumericPattern = regexp.MustCompile(`[^a-zA-Z0-9]`)
+)
+
+// PacketFilter is kept in a dedicated nftables table (of ip family)
+// with a base chain for each used hook so that its rules can be removed
+// all at once without affecting rules added by others.
+type PacketFilter struct {
+	Name  string
+	Rules []Rule
+}
+
+// PacketFilterName returns name of the table holding task's rules;
+// only a prefix of task ID is used since older kernels limit names to 32 characters.
+func PacketFilterName(taskID string) string {
+	id := nonAlphanumericPattern.ReplaceAllString(taskID, "")
+
+	if len(id) > 18 {
+		id = id[:18]
+	}
+
+	return "turbulence-" + id
+}
+
+// Rule accepts or drops IPv4 packets that match all of its conditions
+type Rule struct {
+	Hook string // input, output or forward
+
+	// NotIface matches packets that were not received on (input hook)
+	// or are not sent out of (output hook) given interface
+	NotIface string
+
+	// Protocol is one of tcp, udp, icmp; empty matches all protocols
+	Protocol string
+
+	Src      *net.IPNet
+	Dst      *net.IPNet
+	SrcPorts PortRange
+	DstPorts PortRange
+
+	// States are conntrack states, e.g. new or established
+	States []string
+
+	Verdict string
+}
+
+// Chain is read back to verify that rules of packet filter are in place
+type Chain struct {
+	Name  string
+	Hook  string
+	Rules int
+}
+
+// PortRange of a single port has the same From and To
+type PortRange struct {
+	From uint16
+	To   uint16
+}
+
+func (r PortRange) IsZero() bool { return r.From == 0 && r.To == 0 }
+
+func (r PortRange) String() string {
+	if r.From == r.To {
+		return strconv.Itoa(int(r.From))
+	}
+
+	return fmt.Sprintf("%d-%d", r.From, r.To)
+}
+
+// ParsePortRange parses a single port (e.g. 80) or a range of ports (e.g. 4530:6740)
+func ParsePortRange(str string) (PortRange, error) {
+	pieces := strings.Split(str, ":")
+	if len(pieces) > 2 {
+		return PortRange{}, fmt.Errorf("Expected '%s' to be a port or a range of ports", str)
+	}
+
+	var ports []uint16
+
+	for _, piece := range pieces {
+		port, err := strconv.ParseUint(piece, 10, 16)
+		if err != nil || port == 0 {
+			return PortRange{}, fmt.Errorf("Expected '%s' to be a port or a range of ports", str)
+		}
+
+		ports = append(ports, uint16(port))
+	}
+
+	r := PortRange{From: ports[0], To: ports[len(ports)-1]}
+
+	if r.From > r.To {
+		return PortRange{}, fmt.Errorf("Expected range of ports '%s' to start with the lower port", str)
+	}
+
+	return r, nil
+}
+
+// ParseIPNet parses IPv4 address (e.g. 10.0.0.1) or network (e.g. 10.0.0.0/8)
+func ParseIPNet(str string) (*net.IPNet, error) {
+	if strings.Contains(str, "/") {
+		ip, ipNet, err := net.ParseCIDR(str)
+		if err != nil || ip.To4() == nil {
+			return nil, fmt.Errorf("Expected '%s' to be an IPv4 network", str)
+		}
+
+		return ipNet, nil
+	}
+
+	ip := net.ParseIP(str).To4()
+	if ip == nil {
+		return nil, fmt.Errorf("Expected '%s' to be an IPv4 address", str)
+	}
+
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(32, 32)}, nil
+}
+
+func (r Rule) Validate() error {
+	if !isHook(r.Hook) {
+		return fmt.Errorf("Unknown hook '%s'", r.Hook)
+	}
+
+	if r.Verdict != VerdictAccept && r.Verdict != VerdictDrop {
+		return fmt.Errorf("Unknown verdict '%s'", r.Verdict)
+	}
+
+	if len(r.NotIface) > 0 {
+		if r.Hook == HookForward {
+			return fmt.Errorf("Interface cannot be matched in forward hook")
+		}
+
+		if len(r.NotIface) >= ifNameSize {
+			return fmt.Errorf("Interface name '%s' is too long", r.NotIface)
+		}
+	}
+
+	if _, found := protocolNumbers[r.Protocol]; len(r.Protocol) > 0 && !found {
+		return fmt.Errorf("Unknown protocol '%s'", r.Protocol)
+	}
+
+	if (!r.SrcPorts.IsZero() || !r.DstPorts.IsZero()) && r.Protocol != "tcp" && r.Protocol != "udp" {
+		return fmt.Errorf("Ports can only be matched for tcp or udp protocol")
+	}
+
+	for _, ipNet := range []*net.IPNet{r.Src, r.Dst} {
+		if ipNet != nil && (ipNet.IP.To4() == nil || len(ipNet.Mask) != net.IPv4len) {
+			return fmt.Errorf("Expected '%s' to be an IPv4 network", ipNet.String())
+		}
+	}
+
+	for _, state := range r.States {
+		if _, found := ctStates[state]; !found {
+			return fmt.Errorf("Unknown conntrack state '%s'", state)
+		}
+	}
+
+	return nil
+}
+
+// String formats rule using nft syntax
+func (r Rule) String() string {
+	var conds []string
+
+	if len(r.NotIface) > 0 {
+		key := "iifname"
+		if r.Hook == HookOutput {
+			key = "oifname"
+		}
+		conds = append(conds, fmt.Sprintf("%s != \"%s\"", key, r.NotIface))
+	}
+
+	if len(r.Protocol) > 0 {
+		conds = append(conds, "meta l4proto "+r.Protocol)
+	}
+
+	if r.Src != nil {
+		conds = append(conds, "ip saddr "+formatIPNet(r.Src))
+	}
+
+	if r.Dst != nil {
+		conds = append(conds, "ip daddr "+formatIPNet(r.Dst))
+	}
+
+	if !r.SrcPorts.IsZero() {
+		conds = append(conds, "th sport "+r.SrcPorts.String())
+	}
+
+	if !r.DstPorts.IsZero() {
+		conds = append(conds, "th dport "+r.DstPorts.String())
+	}
+
+	if len(r.States) > 0 {
+		conds = append(conds, "ct state "+strings.Join(r.States, ","))
+	}
+
+	return strings.Join(append(conds, r.Verdict), " ")
+}
+
+// Commands describes packet filter as nft commands that would create it
+func (f PacketFilter) Commands() []string {
+	cmds := []string{"add table ip " + f.Name}
+
+	for _, hook := range f.Hooks() {
+		cmds = append(cmds, fmt.Sprintf(
+			"add chain ip %s %s { type filter hook %s priority 0; }", f.Name, hook, hook))
+	}
+
+	for _, rule := range f.Rules {
+		cmds = append(cmds, fmt.Sprintf("add rule ip %s %s %s", f.Name, rule.Hook, rule.String()))
+	}
+
+	return cmds
+}
+
+// Hooks returns hooks used by rules; chains are named after their hooks
+func (f PacketFilter) Hooks() []string {
+	var used []string
+
+	for _, hook := range hooks {
+		for _, rule := range f.Rules {
+			if rule.Hook == hook {
+				used = append(used, hook)
+				break
+			}
+		}
+	}
+
+	return used
+}
+
+func isHook(name string) bool {
+	for _, hook := range hooks {
+		if hook == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+func formatIPNet(ipNet *net.IPNet) string {
+	if ones, _ := ipNet.Mask.Size(); ones == 32 {
+		return ipNet.IP.String()
+	}
+
+	return ipNet.String()
+}
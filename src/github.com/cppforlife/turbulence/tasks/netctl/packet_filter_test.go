@@ -0,0 +1,106 @@
+package netctl_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cppforlife/turbulence/tasks/netctl"
+)
+
+var _ = Describe("PacketFilterName", func() {
+	It("fits task ID prefix into table name limit of older kernels", func() {
+		name := PacketFilterName("7d4a0b2c-5f3e-4a8d-9b61-0c2e4f6a8b1d")
+		Expect(name).To(Equal("turbulence-7d4a0b2c5f3e4a8d9b"))
+		Expect(len(name)).To(BeNumerically("<", 32))
+	})
+})
+
+var _ = Describe("ParsePortRange", func() {
+	It("parses single ports and ranges", func() {
+		Expect(ParsePortRange("53")).To(Equal(PortRange{From: 53, To: 53}))
+		Expect(ParsePortRange("4530:6740")).To(Equal(PortRange{From: 4530, To: 6740}))
+
+		for _, str := range []string{"", "0", "70000", "1:2:3", "http", "80:22"} {
+			_, err := ParsePortRange(str)
+			Expect(err).To(HaveOccurred(), str)
+		}
+	})
+})
+
+var _ = Describe("ParseIPNet", func() {
+	It("parses IPv4 addresses and networks", func() {
+		ipNet, err := ParseIPNet("10.0.0.1")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ipNet.String()).To(Equal("10.0.0.1/32"))
+
+		ipNet, err = ParseIPNet("192.168.1.7/24")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ipNet.String()).To(Equal("192.168.1.0/24"))
+
+		_, err = ParseIPNet("::1")
+		Expect(err).To(MatchError("Expected '::1' to be an IPv4 address"))
+
+		_, err = ParseIPNet("10.0.0.0/33")
+		Expect(err).To(MatchError("Expected '10.0.0.0/33' to be an IPv4 network"))
+	})
+})
+
+var _ = Describe("Rule", func() {
+	It("is formatted using nft syntax", func() {
+		src, err := ParseIPNet("10.0.0.1")
+		Expect(err).ToNot(HaveOccurred())
+
+		rule := Rule{
+			Hook:     HookInput,
+			NotIface: "lo",
+			Protocol: "tcp",
+			Src:      src,
+			SrcPorts: PortRange{From: 4222, To: 4222},
+			States:   []string{"new", "established"},
+			Verdict:  VerdictAccept,
+		}
+
+		Expect(rule.String()).To(Equal(`iifname != "lo" meta l4proto tcp ip saddr 10.0.0.1 th sport 4222 ct state new,established accept`))
+
+		rule = Rule{Hook: HookOutput, NotIface: "lo", Verdict: VerdictDrop}
+		Expect(rule.String()).To(Equal(`oifname != "lo" drop`))
+	})
+
+	It("requires tcp or udp protocol to match ports", func() {
+		rule := Rule{Hook: HookOutput, DstPorts: PortRange{From: 53, To: 53}, Verdict: VerdictDrop}
+		Expect(rule.Validate()).To(MatchError("Ports can only be matched for tcp or udp protocol"))
+
+		rule.Protocol = "icmp"
+		Expect(rule.Validate()).To(HaveOccurred())
+
+		rule.Protocol = "udp"
+		Expect(rule.Validate()).ToNot(HaveOccurred())
+	})
+
+	It("returns error for unknown hooks, verdicts and states", func() {
+		Expect(Rule{Hook: "prerouting", Verdict: VerdictDrop}.Validate()).To(MatchError("Unknown hook 'prerouting'"))
+		Expect(Rule{Hook: HookInput, Verdict: "reject"}.Validate()).To(MatchError("Unknown verdict 'reject'"))
+		Expect(Rule{Hook: HookInput, Verdict: VerdictDrop, States: []string{"open"}}.Validate()).To(
+			MatchError("Unknown conntrack state 'open'"))
+		Expect(Rule{Hook: HookForward, NotIface: "lo", Verdict: VerdictDrop}.Validate()).To(HaveOccurred())
+	})
+})
+
+var _ = Describe("PacketFilter", func() {
+	It("describes table, chains of used hooks and rules as nft commands", func() {
+		filter := PacketFilter{Name: "turbulence-task1", Rules: []Rule{
+			{Hook: HookOutput, Protocol: "udp", DstPorts: PortRange{From: 53, To: 53}, Verdict: VerdictDrop},
+			{Hook: HookInput, Protocol: "tcp", DstPorts: PortRange{From: 1000, To: 2000}, Verdict: VerdictDrop},
+		}}
+
+		Expect(filter.Hooks()).To(Equal([]string{HookInput, HookOutput}))
+
+		Expect(filter.Commands()).To(Equal([]string{
+			"add table ip turbulence-task1",
+			"add chain ip turbulence-task1 input { type filter hook input priority 0; }",
+			"add chain ip turbulence-task1 output { type filter hook output priority 0; }",
+			"add rule ip turbulence-task1 output meta l4proto udp th dport 53 drop",
+			"add rule ip turbulence-task1 input meta l4proto tcp th dport 1000-2000 drop",
+		}))
+	})
+})
@@ -0,0 +1,152 @@
+package netctl
+
+import (
+	"io/ioutil"
+	"net"
+	"sync"
+	"syscall"
+)
+
+type netlinkCtl struct{}
+
+func NewController() Controller { return netlinkCtl{} }
+
+func (netlinkCtl) Qdiscs(ifaceName string) ([]Qdisc, error) {
+	var qdiscs []Qdisc
+
+	err := dumpTC("list qdiscs", ifaceName, syscall.RTM_GETQDISC, 0, func(data []byte) {
+		qdiscs = append(qdiscs, parseQdisc(ifaceName, data))
+	})
+
+	return qdiscs, err
+}
+
+func (netlinkCtl) Classes(ifaceName string) ([]Class, error) {
+	var classes []Class
+
+	err := dumpTC("list classes", ifaceName, syscall.RTM_GETTCLASS, 0, func(data []byte) {
+		classes = append(classes, parseClass(ifaceName, data))
+	})
+
+	return classes, err
+}
+
+func (netlinkCtl) Filters(ifaceName string, parent uint32) ([]Filter, error) {
+	var filters []Filter
+
+	err := dumpTC("list filters", ifaceName, syscall.RTM_GETTFILTER, parent, func(data []byte) {
+		filters = append(filters, parseFilter(ifaceName, data))
+	})
+
+	return filters, err
+}
+
+func (netlinkCtl) AddQdisc(qdisc Qdisc) error {
+	a, err := qdiscAttrs(qdisc, schedClock())
+	if err != nil {
+		return Error{Op: "add qdisc", Iface: qdisc.Iface, Err: err}
+	}
+
+	return changeTC("add qdisc", qdisc.Iface, syscall.RTM_NEWQDISC, flagCreate|flagExcl, qdisc.Handle, qdisc.Parent, 0, a)
+}
+
+func (netlinkCtl) AddClass(class Class) error {
+	a, err := classAttrs(class, schedClock())
+	if err != nil {
+		return Error{Op: "add class", Iface: class.Iface, Err: err}
+	}
+
+	return changeTC("add class", class.Iface, syscall.RTM_NEWTCLASS, flagCreate|flagExcl, class.Handle, class.Parent, 0, a)
+}
+
+func (netlinkCtl) AddFilter(filter Filter) error {
+	a, err := filterAttrs(filter)
+	if err != nil {
+		return Error{Op: "add filter", Iface: filter.Iface, Err: err}
+	}
+
+	return changeTC("add filter", filter.Iface, syscall.RTM_NEWTFILTER, flagCreate|flagExcl, filter.Handle, filter.Parent, filterInfo(filter.Prio), a)
+}
+
+func (netlinkCtl) DeleteRootQdisc(ifaceName string) error {
+	return changeTC("delete root qdisc", ifaceName, syscall.RTM_DELQDISC, 0, 0, HandleRoot, 0, nil)
+}
+
+func dumpTC(op, ifaceName string, msgType uint16, parent uint32, parse func([]byte)) error {
+	iface, err := net.InterfaceByName(ifaceName)
+	if err != nil {
+		return Error{Op: op, Iface: ifaceName, Err: err}
+	}
+
+	conn, err := dial(syscall.NETLINK_ROUTE)
+	if err != nil {
+		return Error{Op: op, Iface: ifaceName, Err: err}
+	}
+
+	defer conn.Close()
+
+	msgs, err := conn.dump(msgType, tcMsg(iface.Index, 0, parent, 0))
+	if err != nil {
+		return Error{Op: op, Iface: ifaceName, Err: err}
+	}
+
+	for _, msg := range msgs {
+		// Dumps consist of RTM_NEW* messages that precede RTM_GET* types by 2
+		if msg.Header.Type != msgType-2 || len(msg.Data) < tcmsgLen {
+			continue
+		}
+
+		// Older kernels do not filter dumps by interface
+		if int(int32(nativeEndian.Uint32(msg.Data[4:8]))) != iface.Index {
+			continue
+		}
+
+		parse(msg.Data)
+	}
+
+	return nil
+}
+
+func changeTC(op, ifaceName string, msgType, flags uint16, handle, parent, info uint32, a attrs) error {
+	iface, err := net.InterfaceByName(ifaceName)
+	if err != nil {
+		return Error{Op: op, Iface: ifaceName, Err: err}
+	}
+
+	conn, err := dial(syscall.NETLINK_ROUTE)
+	if err != nil {
+		return Error{Op: op, Iface: ifaceName, Err: err}
+	}
+
+	defer conn.Close()
+
+	data := append(tcMsg(iface.Index, handle, parent, info), a...)
+
+	err = conn.execute([]message{{Type: msgType, Flags: flags | flagRequest | flagAck, Data: data}})
+	if err != nil {
+		return Error{Op: op, Iface: ifaceName, Err: err}
+	}
+
+	return nil
+}
+
+var psched struct {
+	sync.Once
+	clock clock
+}
+
+// schedClock falls back to the clock of recent kernels if psched cannot be read
+func schedClock() clock {
+	psched.Do(func() {
+		psched.clock = defaultClock
+
+		content, err := ioutil.ReadFile("/proc/net/psched")
+		if err == nil {
+			if clk, err := parsePsched(string(content)); err == nil {
+				psched.clock = clk
+			}
+		}
+	})
+
+	return psched.clock
+}
@@ -0,0 +1,255 @@
+package netctl
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Netem configures netem qdisc; percentages are between 0 and 100
+type Netem struct {
+	Delay time.Duration
+
+	// Jitter varies delay following normal distribution
+	Jitter time.Duration
+
+	Loss            float64
+	LossCorrelation float64
+
+	Duplicate float64
+	Corrupt   float64
+
+	// Reordering requires delay since reordered packets are sent right away
+	Reorder            float64
+	ReorderCorrelation float64
+}
+
+// Class is a class of classful qdisc; only htb classes can be added
+type Class struct {
+	Iface  string
+	Kind   string
+	Handle uint32 // class ID, e.g. 1:1
+	Parent uint32
+
+	Rate uint64 // bytes per second
+}
+
+// Filter classifies IPv4 packets into a class; only u32 filters can be added.
+// Filter without destination and port matches all IPv4 packets.
+type Filter struct {
+	Iface   string
+	Kind    string
+	Parent  uint32
+	Prio    uint16
+	Handle  uint32 // assigned by kernel
+	ClassID uint32 // flowid
+
+	Dst     *net.IPNet
+	DstPort uint16
+}
+
+func (q Qdisc) String() string {
+	str := fmt.Sprintf("qdisc %s %s dev %s %s", q.Kind, q.HandleStr(), q.Iface, formatParent(q.Parent))
+
+	if q.Netem != nil {
+		str += " " + q.Netem.String()
+	}
+
+	return str
+}
+
+func (n Netem) String() string {
+	var opts []string
+
+	if n.Delay > 0 {
+		opts = append(opts, "delay", n.Delay.String())
+
+		if n.Jitter > 0 {
+			opts = append(opts, n.Jitter.String(), "distribution", "normal")
+		}
+	}
+
+	opts = appendPercents(opts, "loss", n.Loss, n.LossCorrelation)
+	opts = appendPercents(opts, "duplicate", n.Duplicate, 0)
+	opts = appendPercents(opts, "corrupt", n.Corrupt, 0)
+	opts = appendPercents(opts, "reorder", n.Reorder, n.ReorderCorrelation)
+
+	return strings.Join(opts, " ")
+}
+
+func appendPercents(opts []string, name string, percent, correlation float64) []string {
+	if percent == 0 {
+		return opts
+	}
+
+	opts = append(opts, name, formatPercent(percent))
+
+	if correlation > 0 {
+		opts = append(opts, formatPercent(correlation))
+	}
+
+	return opts
+}
+
+func (c Class) String() string {
+	return fmt.Sprintf("class %s %s dev %s %s rate %s",
+		c.Kind, FormatHandle(c.Handle), c.Iface, formatParent(c.Parent), FormatRate(c.Rate))
+}
+
+func (f Filter) String() string {
+	str := fmt.Sprintf("filter dev %s %s protocol ip prio %d %s", f.Iface, formatParent(f.Parent), f.Prio, f.Kind)
+
+	if f.Dst != nil {
+		str += " match ip dst " + f.Dst.String()
+	}
+
+	if f.DstPort > 0 {
+		str += fmt.Sprintf(" match ip dport %d 0xffff", f.DstPort)
+	}
+
+	if f.Dst == nil && f.DstPort == 0 {
+		str += " match ip dst 0.0.0.0/0"
+	}
+
+	return str + " flowid " + FormatHandle(f.ClassID)
+}
+
+func formatParent(parent uint32) string {
+	if parent == HandleRoot {
+		return "root"
+	}
+
+	return "parent " + FormatHandle(parent)
+}
+
+func formatPercent(percent float64) string {
+	return strconv.FormatFloat(percent, 'f', -1, 64) + "%"
+}
+
+var (
+	tcValuePattern = regexp.MustCompile(`^([0-9]*\.?[0-9]+)([a-zA-Z%]*)$`)
+
+	// Units are matched case insensitively like tc does hence
+	// e.g. 1mbps means megabytes (and not megabits) per second
+	rateUnits = map[string]float64{
+		"":      1,
+		"bit":   1,
+		"kibit": 1024,
+		"kbit":  1000,
+		"mibit": 1024 * 1024,
+		"mbit":  1000000,
+		"gibit": 1024 * 1024 * 1024,
+		"gbit":  1000000000,
+		"tibit": 1024 * 1024 * 1024 * 1024,
+		"tbit":  1000000000000,
+		"bps":   8,
+		"kibps": 8 * 1024,
+		"kbps":  8000,
+		"mibps": 8 * 1024 * 1024,
+		"mbps":  8000000,
+		"gibps": 8 * 1024 * 1024 * 1024,
+		"gbps":  8000000000,
+		"tibps": 8 * 1024 * 1024 * 1024 * 1024,
+		"tbps":  8000000000000,
+	}
+
+	timeUnits = map[string]time.Duration{
+		"":      time.Microsecond,
+		"s":     time.Second,
+		"sec":   time.Second,
+		"secs":  time.Second,
+		"ms":    time.Millisecond,
+		"msec":  time.Millisecond,
+		"msecs": time.Millisecond,
+		"us":    time.Microsecond,
+		"usec":  time.Microsecond,
+		"usecs": time.Microsecond,
+	}
+)
+
+// ParseRate parses rate the same way as tc (e.g. 256kbit or 1mbps)
+// and returns it in bytes per second
+func ParseRate(str string) (uint64, error) {
+	num, unit, err := splitTCValue(str)
+	if err != nil {
+		return 0, err
+	}
+
+	multiplier, found := rateUnits[strings.ToLower(unit)]
+	if !found {
+		return 0, fmt.Errorf("Unknown rate unit '%s'", unit)
+	}
+
+	rate := uint64(num * multiplier / 8)
+	if rate == 0 {
+		return 0, fmt.Errorf("Rate '%s' must be at least 8bit", str)
+	}
+
+	return rate, nil
+}
+
+// FormatRate formats rate in bytes per second using the largest whole unit of bits
+func FormatRate(rate uint64) string {
+	bits := rate * 8
+
+	for _, unit := range []struct {
+		name string
+		size uint64
+	}{{"tbit", 1000000000000}, {"gbit", 1000000000}, {"mbit", 1000000}, {"kbit", 1000}} {
+		if bits >= unit.size && bits%unit.size == 0 {
+			return fmt.Sprintf("%d%s", bits/unit.size, unit.name)
+		}
+	}
+
+	return fmt.Sprintf("%dbit", bits)
+}
+
+// ParseTime parses time the same way as tc (e.g. 50ms); plain numbers are microseconds
+func ParseTime(str string) (time.Duration, error) {
+	num, unit, err := splitTCValue(str)
+	if err != nil {
+		return 0, err
+	}
+
+	multiplier, found := timeUnits[strings.ToLower(unit)]
+	if !found {
+		return 0, fmt.Errorf("Unknown time unit '%s'", unit)
+	}
+
+	return time.Duration(num * float64(multiplier)), nil
+}
+
+// ParsePercent parses percentage between 0 and 100 optionally suffixed with %
+func ParsePercent(str string) (float64, error) {
+	num, unit, err := splitTCValue(str)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(unit) > 0 && unit != "%" {
+		return 0, fmt.Errorf("Expected percentage '%s' to be suffixed with %%", str)
+	}
+
+	if num > 100 {
+		return 0, fmt.Errorf("Expected percentage '%s' to be at most 100%%", str)
+	}
+
+	return num, nil
+}
+
+func splitTCValue(str string) (float64, string, error) {
+	matches := tcValuePattern.FindStringSubmatch(str)
+	if matches == nil {
+		return 0, "", fmt.Errorf("Expected '%s' to be a number optionally followed by a unit", str)
+	}
+
+	num, err := strconv.ParseFloat(matches[1], 64)
+	if err != nil {
+		return 0, "", err
+	}
+
+	return num, matches[2], nil
+}
@@ -0,0 +1,422 @@
+package netctl
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	tcmsgLen = 20 // struct tcmsg
+
+	tcaKind    = 1 // TCA_KIND
+	tcaOptions = 2 // TCA_OPTIONS
+
+	tcaNetemCorr      = 1
+	tcaNetemDelayDist = 2
+	tcaNetemReorder   = 3
+	tcaNetemCorrupt   = 4
+
+	tcaHTBParms  = 1
+	tcaHTBInit   = 2
+	tcaHTBRate64 = 6
+	tcaHTBCeil64 = 7
+
+	tcaU32ClassID = 1
+	tcaU32Sel     = 5
+
+	u32Terminal = 1 // TC_U32_TERMINAL
+
+	// Same defaults as tc
+	netemLimit   = 1000
+	htbMTU       = 1600
+	htbVersion   = 3
+	htbRate2Quan = 10
+
+	linkLayerEthernet = 1
+
+	// Offsets within IPv4 header (without options) matched by u32 filters
+	u32DstOff  = 16
+	u32PortOff = 20
+)
+
+// prioMap is the default priority to band mapping used by tc
+var prioMap = []byte{1, 2, 2, 2, 1, 2, 0, 0, 1, 1, 1, 1, 1, 1, 1, 1}
+
+// clock converts times into packet scheduler ticks as described by /proc/net/psched
+type clock struct {
+	ticksPerUsec float64
+	hz           float64
+}
+
+// defaultClock matches kernels with 64ns ticks and high resolution timers
+var defaultClock = clock{ticksPerUsec: 15.625, hz: 1000000000}
+
+// parsePsched interprets /proc/net/psched the same way as tc
+func parsePsched(content string) (clock, error) {
+	fields := strings.Fields(content)
+	if len(fields) < 4 {
+		return clock{}, fmt.Errorf("Expected psched to have 4 fields but found %d", len(fields))
+	}
+
+	var vals []float64
+
+	for _, field := range fields[:4] {
+		val, err := strconv.ParseUint(field, 16, 32)
+		if err != nil {
+			return clock{}, err
+		}
+
+		vals = append(vals, float64(val))
+	}
+
+	t2us, us2t, clockRes, hz := vals[0], vals[1], vals[2], vals[3]
+
+	if us2t == 0 || hz == 0 {
+		return clock{}, fmt.Errorf("Unexpected psched '%s'", strings.TrimSpace(content))
+	}
+
+	// Kernel advertises tick multiplier of 1000 for nanosecond resolution
+	if clockRes == 1000000000 {
+		t2us = us2t
+	}
+
+	return clock{ticksPerUsec: t2us / us2t * clockRes / 1000000, hz: hz}, nil
+}
+
+func (c clock) ticks(d time.Duration) uint32 {
+	return uint32(float64(d) / float64(time.Microsecond) * c.ticksPerUsec)
+}
+
+func tcMsg(ifaceIndex int, handle, parent, info uint32) []byte {
+	b := make([]byte, tcmsgLen)
+	nativeEndian.PutUint32(b[4:8], uint32(int32(ifaceIndex)))
+	nativeEndian.PutUint32(b[8:12], handle)
+	nativeEndian.PutUint32(b[12:16], parent)
+	nativeEndian.PutUint32(b[16:20], info)
+	return b
+}
+
+// qdiscAttrs returns kind and options of qdisc; netem requires clock
+// since delays are specified in scheduler ticks
+func qdiscAttrs(q Qdisc, clk clock) (attrs, error) {
+	var a attrs
+
+	a.addString(tcaKind, q.Kind)
+
+	switch q.Kind {
+	case "prio":
+		opts := make([]byte, 4+len(prioMap))
+		nativeEndian.PutUint32(opts[0:4], 3) // bands
+		copy(opts[4:], prioMap)
+		a.add(tcaOptions, opts)
+
+	case "htb":
+		init := make([]byte, 20)
+		nativeEndian.PutUint32(init[0:4], htbVersion)
+		nativeEndian.PutUint32(init[4:8], htbRate2Quan)
+
+		var opts attrs
+		opts.add(tcaHTBInit, init)
+		a.add(tcaOptions, opts)
+
+	case "netem":
+		if q.Netem == nil {
+			return nil, fmt.Errorf("Expected netem options to be specified")
+		}
+		a.add(tcaOptions, netemOptions(*q.Netem, clk))
+
+	default:
+		return nil, fmt.Errorf("Adding qdisc '%s' is not supported", q.Kind)
+	}
+
+	return a, nil
+}
+
+func netemOptions(n Netem, clk clock) []byte {
+	var gap uint32
+
+	// Reordered packets are sent right away while the rest is delayed
+	if n.Reorder > 0 {
+		gap = 1
+	}
+
+	opts := make([]byte, 24) // struct tc_netem_qopt
+	nativeEndian.PutUint32(opts[0:4], clk.ticks(n.Delay))
+	nativeEndian.PutUint32(opts[4:8], netemLimit)
+	nativeEndian.PutUint32(opts[8:12], probability(n.Loss))
+	nativeEndian.PutUint32(opts[12:16], gap)
+	nativeEndian.PutUint32(opts[16:20], probability(n.Duplicate))
+	nativeEndian.PutUint32(opts[20:24], clk.ticks(n.Jitter))
+
+	var a attrs
+
+	if n.LossCorrelation > 0 {
+		a.add(tcaNetemCorr, uint32s(0, probability(n.LossCorrelation), 0))
+	}
+
+	if n.Reorder > 0 {
+		a.add(tcaNetemReorder, uint32s(probability(n.Reorder), probability(n.ReorderCorrelation)))
+	}
+
+	if n.Corrupt > 0 {
+		a.add(tcaNetemCorrupt, uint32s(probability(n.Corrupt), 0))
+	}
+
+	if n.Jitter > 0 {
+		a.add(tcaNetemDelayDist, normalDistTable())
+	}
+
+	return append(opts, a...)
+}
+
+func classAttrs(c Class, clk clock) (attrs, error) {
+	if c.Kind != "htb" {
+		return nil, fmt.Errorf("Adding class '%s' is not supported", c.Kind)
+	}
+
+	if c.Rate == 0 {
+		return nil, fmt.Errorf("Expected rate to be specified")
+	}
+
+	rate32 := uint32(math.MaxUint32)
+	if c.Rate < math.MaxUint32 {
+		rate32 = uint32(c.Rate)
+	}
+
+	// Allow bursts of a single packet in addition to what is sent per timer tick
+	burst := float64(c.Rate)/clk.hz + htbMTU
+	buffer := clk.ticks(time.Duration(burst / float64(c.Rate) * float64(time.Second)))
+
+	parms := make([]byte, 44) // struct tc_htb_opt
+	putRateSpec(parms[0:12], rate32)
+	putRateSpec(parms[12:24], rate32) // ceil
+	nativeEndian.PutUint32(parms[24:28], buffer)
+	nativeEndian.PutUint32(parms[28:32], buffer) // cbuffer
+
+	var opts attrs
+	opts.add(tcaHTBParms, parms)
+
+	if c.Rate >= math.MaxUint32 {
+		rate64 := make([]byte, 8)
+		nativeEndian.PutUint64(rate64, c.Rate)
+		opts.add(tcaHTBRate64, rate64)
+		opts.add(tcaHTBCeil64, rate64)
+	}
+
+	var a attrs
+	a.addString(tcaKind, c.Kind)
+	a.add(tcaOptions, opts)
+
+	return a, nil
+}
+
+// putRateSpec fills struct tc_ratespec; kernel does not need
+// rate tables when link layer is specified
+func putRateSpec(b []byte, rate uint32) {
+	b[1] = linkLayerEthernet
+	nativeEndian.PutUint32(b[8:12], rate)
+}
+
+func filterAttrs(f Filter) (attrs, error) {
+	if f.Kind != "u32" {
+		return nil, fmt.Errorf("Adding filter '%s' is not supported", f.Kind)
+	}
+
+	dst := f.Dst
+
+	if dst == nil && f.DstPort == 0 {
+		dst = &net.IPNet{IP: net.IPv4zero.To4(), Mask: net.CIDRMask(0, 32)}
+	}
+
+	var keys [][]byte
+
+	if dst != nil {
+		ip := dst.IP.To4()
+		if ip == nil || len(dst.Mask) != net.IPv4len {
+			return nil, fmt.Errorf("Expected '%s' to be an IPv4 network", dst.String())
+		}
+
+		mask := binary.BigEndian.Uint32(dst.Mask)
+		keys = append(keys, u32Key(mask, binary.BigEndian.Uint32(ip)&mask, u32DstOff))
+	}
+
+	if f.DstPort > 0 {
+		// Destination port is the lower half of the word following source port
+		keys = append(keys, u32Key(0xFFFF, uint32(f.DstPort), u32PortOff))
+	}
+
+	sel := make([]byte, 16) // struct tc_u32_sel
+	sel[0] = u32Terminal
+	sel[2] = byte(len(keys))
+
+	for _, key := range keys {
+		sel = append(sel, key...)
+	}
+
+	var opts attrs
+	opts.addUint32(tcaU32ClassID, f.ClassID)
+	opts.add(tcaU32Sel, sel)
+
+	var a attrs
+	a.addString(tcaKind, f.Kind)
+	a.add(tcaOptions, opts)
+
+	return a, nil
+}
+
+// u32Key builds struct tc_u32_key; mask and value are in network byte order
+func u32Key(mask, val uint32, off int32) []byte {
+	b := make([]byte, 16)
+	binary.BigEndian.PutUint32(b[0:4], mask)
+	binary.BigEndian.PutUint32(b[4:8], val)
+	nativeEndian.PutUint32(b[8:12], uint32(off))
+	return b
+}
+
+// filterInfo combines priority and protocol (IPv4 in network byte order)
+func filterInfo(prio uint16) uint32 {
+	return uint32(prio)<<16 | uint32(nativeEndian.Uint16([]byte{0x08, 0x00}))
+}
+
+// parseQdisc, parseClass and parseFilter decode dumped messages (tcmsg followed by attributes)
+func parseQdisc(ifaceName string, data []byte) Qdisc {
+	a := parseAttrs(data[tcmsgLen:])
+
+	return Qdisc{
+		Iface:  ifaceName,
+		Kind:   cString(a[tcaKind]),
+		Handle: nativeEndian.Uint32(data[8:12]),
+		Parent: nativeEndian.Uint32(data[12:16]),
+	}
+}
+
+func parseClass(ifaceName string, data []byte) Class {
+	a := parseAttrs(data[tcmsgLen:])
+
+	class := Class{
+		Iface:  ifaceName,
+		Kind:   cString(a[tcaKind]),
+		Handle: nativeEndian.Uint32(data[8:12]),
+		Parent: nativeEndian.Uint32(data[12:16]),
+	}
+
+	if class.Kind == "htb" {
+		opts := parseAttrs(a[tcaOptions])
+
+		if parms := opts[tcaHTBParms]; len(parms) >= 12 {
+			class.Rate = uint64(nativeEndian.Uint32(parms[8:12]))
+		}
+
+		if rate64 := opts[tcaHTBRate64]; len(rate64) == 8 {
+			class.Rate = nativeEndian.Uint64(rate64)
+		}
+	}
+
+	return class
+}
+
+func parseFilter(ifaceName string, data []byte) Filter {
+	a := parseAttrs(data[tcmsgLen:])
+
+	filter := Filter{
+		Iface:  ifaceName,
+		Kind:   cString(a[tcaKind]),
+		Handle: nativeEndian.Uint32(data[8:12]),
+		Parent: nativeEndian.Uint32(data[12:16]),
+		Prio:   uint16(nativeEndian.Uint32(data[16:20]) >> 16),
+	}
+
+	if filter.Kind != "u32" {
+		return filter
+	}
+
+	opts := parseAttrs(a[tcaOptions])
+
+	if classID := opts[tcaU32ClassID]; len(classID) == 4 {
+		filter.ClassID = nativeEndian.Uint32(classID)
+	}
+
+	sel := opts[tcaU32Sel]
+	if len(sel) < 16 {
+		return filter
+	}
+
+	for i, keys := 0, sel[16:]; i < int(sel[2]) && len(keys) >= 16; i, keys = i+1, keys[16:] {
+		mask, val := binary.BigEndian.Uint32(keys[0:4]), binary.BigEndian.Uint32(keys[4:8])
+
+		switch int32(nativeEndian.Uint32(keys[8:12])) {
+		case u32DstOff:
+			ip := make(net.IP, net.IPv4len)
+			binary.BigEndian.PutUint32(ip, val)
+			ipMask := make(net.IPMask, net.IPv4len)
+			binary.BigEndian.PutUint32(ipMask, mask)
+			filter.Dst = &net.IPNet{IP: ip, Mask: ipMask}
+
+		case u32PortOff:
+			if mask == 0xFFFF {
+				filter.DstPort = uint16(val)
+			}
+		}
+	}
+
+	// Filter matching all packets is added with an empty network
+	if filter.Dst != nil && filter.DstPort == 0 {
+		if ones, _ := filter.Dst.Mask.Size(); ones == 0 {
+			filter.Dst = nil
+		}
+	}
+
+	return filter
+}
+
+// probability scales percentage to the whole range of uint32 like tc
+func probability(percent float64) uint32 {
+	return uint32(math.Floor(percent/100*math.MaxUint32 + 0.5))
+}
+
+func uint32s(vals ...uint32) []byte {
+	b := make([]byte, 4*len(vals))
+	for i, val := range vals {
+		nativeEndian.PutUint32(b[i*4:], val)
+	}
+	return b
+}
+
+var normalDist struct {
+	sync.Once
+	table []byte
+}
+
+// normalDistTable is generated the same way as normal.dist shipped with tc
+// (inverse of normal distribution function scaled by NETEM_DIST_SCALE)
+func normalDistTable() []byte {
+	normalDist.Do(func() {
+		const tableSize, scale = 16384, 8192
+
+		table := make([]float64, tableSize+1)
+
+		for x := -10.0; x < 10.05; x += .00005 {
+			i := int(math.Floor(tableSize*(.5+.5*math.Erf(x/math.Sqrt2)) + .5))
+			table[i] = x
+		}
+
+		normalDist.table = make([]byte, 0, tableSize/2)
+
+		for i := 0; i < tableSize; i += 4 {
+			val := math.Floor(table[i]*scale + .5)
+			val = math.Max(math.MinInt16, math.Min(math.MaxInt16, val))
+
+			b := make([]byte, 2)
+			nativeEndian.PutUint16(b, uint16(int16(val)))
+			normalDist.table = append(normalDist.table, b...)
+		}
+	})
+
+	return normalDist.table
+}
@@ -0,0 +1,114 @@
+package netctl_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cppforlife/turbulence/tasks/netctl"
+)
+
+var _ = Describe("ParseRate", func() {
+	It("parses rates the same way as tc", func() {
+		for str, rate := range map[string]uint64{
+			"256kbit": 32000,
+			"1mbit":   125000,
+			"1Mbit":   125000,
+			"1mbps":   1000000,
+			"2kbps":   2000,
+			"1gbit":   125000000,
+			"8000":    1000,
+			"1.5mbit": 187500,
+		} {
+			parsed, err := ParseRate(str)
+			Expect(err).ToNot(HaveOccurred(), str)
+			Expect(parsed).To(Equal(rate), str)
+		}
+	})
+
+	It("returns error for unknown units and rates that are too low", func() {
+		_, err := ParseRate("1mb")
+		Expect(err).To(MatchError("Unknown rate unit 'mb'"))
+
+		_, err = ParseRate("4bit")
+		Expect(err).To(MatchError("Rate '4bit' must be at least 8bit"))
+
+		_, err = ParseRate("fast")
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+var _ = Describe("FormatRate", func() {
+	It("uses the largest whole unit of bits", func() {
+		Expect(FormatRate(125000)).To(Equal("1mbit"))
+		Expect(FormatRate(32000)).To(Equal("256kbit"))
+		Expect(FormatRate(187500)).To(Equal("1500kbit"))
+		Expect(FormatRate(1)).To(Equal("8bit"))
+	})
+})
+
+var _ = Describe("ParseTime", func() {
+	It("parses times the same way as tc", func() {
+		for str, d := range map[string]time.Duration{
+			"50ms":   50 * time.Millisecond,
+			"1.5s":   1500 * time.Millisecond,
+			"100us":  100 * time.Microsecond,
+			"100":    100 * time.Microsecond,
+			"2msecs": 2 * time.Millisecond,
+		} {
+			parsed, err := ParseTime(str)
+			Expect(err).ToNot(HaveOccurred(), str)
+			Expect(parsed).To(Equal(d), str)
+		}
+
+		_, err := ParseTime("1h")
+		Expect(err).To(MatchError("Unknown time unit 'h'"))
+	})
+})
+
+var _ = Describe("ParsePercent", func() {
+	It("parses percentages optionally suffixed with %", func() {
+		Expect(ParsePercent("20%")).To(Equal(20.0))
+		Expect(ParsePercent("0.1")).To(Equal(0.1))
+
+		_, err := ParsePercent("101%")
+		Expect(err).To(MatchError("Expected percentage '101%' to be at most 100%"))
+
+		_, err = ParsePercent("20ms")
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+var _ = Describe("Qdisc, Class and Filter", func() {
+	It("are formatted similarly to tc", func() {
+		qdisc := Qdisc{Iface: "eth0", Kind: "netem", Handle: 0x300000, Parent: 0x10001, Netem: &Netem{
+			Delay:           50 * time.Millisecond,
+			Jitter:          10 * time.Millisecond,
+			Loss:            20,
+			LossCorrelation: 75,
+			Corrupt:         0.1,
+			Reorder:         25,
+		}}
+
+		Expect(qdisc.String()).To(Equal("qdisc netem 30: dev eth0 parent 1:1 " +
+			"delay 50ms 10ms distribution normal loss 20% 75% corrupt 0.1% reorder 25%"))
+
+		qdisc = Qdisc{Iface: "eth0", Kind: "prio", Handle: 0x10000, Parent: HandleRoot}
+		Expect(qdisc.String()).To(Equal("qdisc prio 1: dev eth0 root"))
+
+		class := Class{Iface: "eth0", Kind: "htb", Handle: 0x10001, Parent: 0x10000, Rate: 125000}
+		Expect(class.String()).To(Equal("class htb 1:1 dev eth0 parent 1: rate 1mbit"))
+
+		dst, err := ParseIPNet("10.0.0.0/8")
+		Expect(err).ToNot(HaveOccurred())
+
+		filter := Filter{Iface: "eth0", Kind: "u32", Parent: 0x10000, Prio: 1, ClassID: 0x10001, Dst: dst, DstPort: 80}
+		Expect(filter.String()).To(Equal("filter dev eth0 parent 1: protocol ip prio 1 u32 " +
+			"match ip dst 10.0.0.0/8 match ip dport 80 0xffff flowid 1:1"))
+
+		filter = Filter{Iface: "eth0", Kind: "u32", Parent: 0x10000, Prio: 1, ClassID: 0x10001}
+		Expect(filter.String()).To(Equal("filter dev eth0 parent 1: protocol ip prio 1 u32 " +
+			"match ip dst 0.0.0.0/0 flowid 1:1"))
+	})
+})
@@ -0,0 +1,44 @@
+package netctl
+
+import (
+	"fmt"
+)
+
+// UndoCmdName starts undo commands recorded in agent's journal for changes
+// applied via netlink; such commands are run by RunUndoCmd instead of a shell
+const UndoCmdName = "netctl"
+
+const (
+	undoDeleteRootQdisc    = "delete-root-qdisc"
+	undoDeletePacketFilter = "delete-packet-filter"
+)
+
+func DeleteRootQdiscUndoCmd(ifaceName string) []string {
+	return []string{UndoCmdName, undoDeleteRootQdisc, ifaceName}
+}
+
+func DeletePacketFilterUndoCmd(name string) []string {
+	return []string{UndoCmdName, undoDeletePacketFilter, name}
+}
+
+func IsUndoCmd(cmd []string) bool {
+	return len(cmd) > 0 && cmd[0] == UndoCmdName
+}
+
+// RunUndoCmd undoes change described by command built by one of *UndoCmd functions
+func RunUndoCmd(c Controller, cmd []string) error {
+	if !IsUndoCmd(cmd) || len(cmd) != 3 {
+		return fmt.Errorf("Unknown undo command '%v'", cmd)
+	}
+
+	switch cmd[1] {
+	case undoDeleteRootQdisc:
+		return c.DeleteRootQdisc(cmd[2])
+
+	case undoDeletePacketFilter:
+		return c.DeletePacketFilter(cmd[2])
+
+	default:
+		return fmt.Errorf("Unknown undo command '%v'", cmd)
+	}
+}
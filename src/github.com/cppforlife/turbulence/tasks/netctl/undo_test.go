@@ -0,0 +1,43 @@
+package netctl_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cppforlife/turbulence/tasks/netctl"
+)
+
+type undoController struct {
+	Controller
+
+	deleted []string
+}
+
+func (c *undoController) DeleteRootQdisc(ifaceName string) error {
+	c.deleted = append(c.deleted, "qdisc "+ifaceName)
+	return nil
+}
+
+func (c *undoController) DeletePacketFilter(name string) error {
+	c.deleted = append(c.deleted, "packet filter "+name)
+	return nil
+}
+
+var _ = Describe("RunUndoCmd", func() {
+	It("runs undo commands recorded for changes applied via netlink", func() {
+		ctl := &undoController{}
+
+		Expect(IsUndoCmd(DeleteRootQdiscUndoCmd("eth0"))).To(BeTrue())
+		Expect(IsUndoCmd([]string{"iptables", "-X", "chain"})).To(BeFalse())
+
+		Expect(RunUndoCmd(ctl, DeleteRootQdiscUndoCmd("eth0"))).ToNot(HaveOccurred())
+		Expect(RunUndoCmd(ctl, DeletePacketFilterUndoCmd("turbulence-task1"))).ToNot(HaveOccurred())
+
+		Expect(ctl.deleted).To(Equal([]string{"qdisc eth0", "packet filter turbulence-task1"}))
+	})
+
+	It("returns error for unknown commands", func() {
+		Expect(RunUndoCmd(&undoController{}, []string{"netctl", "flush", "eth0"})).To(HaveOccurred())
+		Expect(RunUndoCmd(&undoController{}, []string{"tc", "qdisc", "del"})).To(HaveOccurred())
+	})
+})
@@ -5,7 +5,21 @@ type NoopOptions struct {
 	Stoppable bool
 }
 
-func (NoopOptions) _private() {}
+func init() {
+	Register(TaskType{
+		Name:        "Noop",
+		Description: "Does nothing; optionally waits until stopped",
+
+		NewOptions: func() Options { return NoopOptions{} },
+		NewTask: func(opts Options, deps AgentDeps) (AgentTask, error) {
+			return NewNoopTask(opts.(NoopOptions)), nil
+		},
+
+		RunsUntilStopped: func(opts Options) bool { return opts.(NoopOptions).Stoppable },
+	})
+}
+
+func (NoopOptions) Validate() error { return nil }
 
 type NoopTask struct {
 	opts NoopOptions
@@ -9,6 +9,10 @@ import (
 )
 
 func OptionsType(taskOpts Options) string {
+	if name, found := findTypeName(taskOpts); found {
+		return name
+	}
+
 	t := fmt.Sprintf("%T", taskOpts)
 	t = strings.TrimPrefix(t, "tasks.")
 	return strings.TrimSuffix(t, "Options")
@@ -31,52 +35,11 @@ func (s *OptionsSlice) UnmarshalJSON(data []byte) error {
 
 			var opts Options
 
-			switch {
-			case optType == OptionsType(NoopOptions{}):
-				var o NoopOptions
-				err, opts = json.Unmarshal(bytes, &o), o
-
-			case optType == OptionsType(KillOptions{}):
-				var o KillOptions
-				err, opts = json.Unmarshal(bytes, &o), o
-
-			case optType == OptionsType(KillProcessOptions{}):
-				var o KillProcessOptions
-				err, opts = json.Unmarshal(bytes, &o), o
-
-			case optType == OptionsType(PauseProcessOptions{}):
-				var o PauseProcessOptions
-				err, opts = json.Unmarshal(bytes, &o), o
-
-			case optType == OptionsType(StressOptions{}):
-				var o StressOptions
-				err, opts = json.Unmarshal(bytes, &o), o
-
-			case optType == OptionsType(ControlNetOptions{}):
-				var o ControlNetOptions
-				err, opts = json.Unmarshal(bytes, &o), o
-
-			case optType == OptionsType(FirewallOptions{}):
-				var o FirewallOptions
-				err, opts = json.Unmarshal(bytes, &o), o
-
-			case optType == OptionsType(TargetedBlockerOptions{}):
-				var o TargetedBlockerOptions
-				err, opts = json.Unmarshal(bytes, &o), o
-
-			case optType == OptionsType(BlockDNSOptions{}):
-				var o BlockDNSOptions
-				err, opts = json.Unmarshal(bytes, &o), o
-
-			case optType == OptionsType(FillDiskOptions{}):
-				var o FillDiskOptions
-				err, opts = json.Unmarshal(bytes, &o), o
-
-			case optType == OptionsType(ShutdownOptions{}):
-				var o ShutdownOptions
-				err, opts = json.Unmarshal(bytes, &o), o
+			name, _ := optType.(string)
 
-			default:
+			if taskType, found := FindType(name); found {
+				opts, err = taskType.unmarshalOptions(bytes)
+			} else {
 				err = bosherr.Errorf("Unknown task type '%s'", optType)
 			}
 
@@ -95,54 +58,13 @@ func (s *OptionsSlice) UnmarshalJSON(data []byte) error {
 
 func (s OptionsSlice) MarshalJSON() ([]byte, error) {
 	for i, o := range s {
-		switch typedO := o.(type) {
-		case NoopOptions:
-			typedO.Type = OptionsType(typedO)
-			s[i] = typedO
-
-		case KillOptions:
-			typedO.Type = OptionsType(typedO)
-			s[i] = typedO
-
-		case KillProcessOptions:
-			typedO.Type = OptionsType(typedO)
-			s[i] = typedO
-
-		case PauseProcessOptions:
-			typedO.Type = OptionsType(typedO)
-			s[i] = typedO
-
-		case StressOptions:
-			typedO.Type = OptionsType(typedO)
-			s[i] = typedO
-
-		case ControlNetOptions:
-			typedO.Type = OptionsType(typedO)
-			s[i] = typedO
-
-		case FirewallOptions:
-			typedO.Type = OptionsType(typedO)
-			s[i] = typedO
-
-		case TargetedBlockerOptions:
-			typedO.Type = OptionsType(typedO)
-			s[i] = typedO
-
-		case BlockDNSOptions:
-			typedO.Type = OptionsType(typedO)
-			s[i] = typedO
-
-		case FillDiskOptions:
-			typedO.Type = OptionsType(typedO)
-			s[i] = typedO
-
-		case ShutdownOptions:
-			typedO.Type = OptionsType(typedO)
-			s[i] = typedO
-
-		default:
+		name, found := findTypeName(o)
+		if !found {
 			return nil, bosherr.Errorf("Unknown task type '%T'", o)
 		}
+
+		taskType, _ := FindType(name)
+		s[i] = taskType.withTypeName(o)
 	}
 
 	return json.Marshal([]Options(s))
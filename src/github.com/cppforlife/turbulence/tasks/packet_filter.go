@@ -0,0 +1,80 @@
+package tasks
+
+import (
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	"github.com/cppforlife/turbulence/tasks/netctl"
+)
+
+// packetFilter keeps rules of a single task in a Turbulence-owned nftables table
+// so that they can be removed all at once without affecting rules added by others
+// even when rules are duplicated.
+type packetFilter struct {
+	netCtl   netctl.Controller
+	name     string
+	journal  Journal
+	recorder Recorder
+}
+
+func newPacketFilter(netCtl netctl.Controller, taskID string, journal Journal, recorder Recorder) packetFilter {
+	return packetFilter{netCtl: netCtl, name: netctl.PacketFilterName(taskID), journal: journal, recorder: recorder}
+}
+
+// Apply adds all rules at once and reads them back to verify that they are in place
+func (f packetFilter) Apply(rules []netctl.Rule) error {
+	filter := netctl.PacketFilter{Name: f.name, Rules: rules}
+
+	err := f.netCtl.AddPacketFilter(filter)
+	if err != nil {
+		return err
+	}
+
+	f.journal.Record("nftables table "+f.name, netctl.DeletePacketFilterUndoCmd(f.name)...)
+
+	for _, cmd := range filter.Commands() {
+		f.recorder.Action(cmd)
+	}
+
+	err = f.verify(filter)
+	if err != nil {
+		f.Remove()
+		return err
+	}
+
+	return nil
+}
+
+// Remove deletes task's table along with its chains and rules
+func (f packetFilter) Remove() error {
+	return f.netCtl.DeletePacketFilter(f.name)
+}
+
+func (f packetFilter) verify(filter netctl.PacketFilter) error {
+	chains, err := f.netCtl.PacketFilterChains(f.name)
+	if err != nil {
+		return err
+	}
+
+	for _, hook := range filter.Hooks() {
+		var expected, actual int
+
+		for _, rule := range filter.Rules {
+			if rule.Hook == hook {
+				expected++
+			}
+		}
+
+		for _, chain := range chains {
+			if chain.Hook == hook {
+				actual += chain.Rules
+			}
+		}
+
+		if actual != expected {
+			return bosherr.Errorf("Expected nftables table '%s' to have %d rule(s) in %s hook but found %d",
+				f.name, expected, hook, actual)
+		}
+	}
+
+	return nil
+}
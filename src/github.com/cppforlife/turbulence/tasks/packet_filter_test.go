@@ -0,0 +1,306 @@
+package tasks_test
+
+import (
+	"errors"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cppforlife/turbulence/tasks"
+	"github.com/cppforlife/turbulence/tasks/netctl"
+)
+
+type fakeJournal struct {
+	undoCmds [][]string
+}
+
+func (j *fakeJournal) Record(_ string, undoCmd ...string) {
+	j.undoCmds = append(j.undoCmds, undoCmd)
+}
+
+// fakeNetCtl keeps added configuration in memory so that tasks can read it back
+type fakeNetCtl struct {
+	packetFilters        map[string]netctl.PacketFilter
+	deletedPacketFilters []string
+
+	// Rules of packet filters that are not reported when reading back
+	missingRules int
+
+	qdiscs  []netctl.Qdisc
+	netems  []netctl.Netem
+	classes []netctl.Class
+	filters []netctl.Filter
+
+	deletedRootQdiscs []string
+
+	addPacketFilterErr    error
+	deletePacketFilterErr error
+	addFilterErr          error
+	deleteRootQdiscErr    error
+}
+
+func newFakeNetCtl() *fakeNetCtl {
+	return &fakeNetCtl{packetFilters: map[string]netctl.PacketFilter{}}
+}
+
+func (c *fakeNetCtl) Qdiscs(ifaceName string) ([]netctl.Qdisc, error) {
+	var qdiscs []netctl.Qdisc
+	for _, qdisc := range c.qdiscs {
+		if qdisc.Iface == ifaceName {
+			qdiscs = append(qdiscs, qdisc)
+		}
+	}
+	return qdiscs, nil
+}
+
+func (c *fakeNetCtl) Classes(ifaceName string) ([]netctl.Class, error) {
+	var classes []netctl.Class
+	for _, class := range c.classes {
+		if class.Iface == ifaceName {
+			classes = append(classes, class)
+		}
+	}
+	return classes, nil
+}
+
+func (c *fakeNetCtl) Filters(ifaceName string, parent uint32) ([]netctl.Filter, error) {
+	var filters []netctl.Filter
+	for _, filter := range c.filters {
+		if filter.Iface == ifaceName && filter.Parent == parent {
+			filters = append(filters, filter)
+		}
+	}
+	return filters, nil
+}
+
+func (c *fakeNetCtl) AddQdisc(qdisc netctl.Qdisc) error {
+	if qdisc.Netem != nil {
+		c.netems = append(c.netems, *qdisc.Netem)
+	}
+
+	qdisc.Netem = nil // not read back
+	c.qdiscs = append(c.qdiscs, qdisc)
+	return nil
+}
+
+func (c *fakeNetCtl) AddClass(class netctl.Class) error {
+	c.classes = append(c.classes, class)
+	return nil
+}
+
+func (c *fakeNetCtl) AddFilter(filter netctl.Filter) error {
+	if c.addFilterErr != nil {
+		return c.addFilterErr
+	}
+	c.filters = append(c.filters, filter)
+	return nil
+}
+
+func (c *fakeNetCtl) DeleteRootQdisc(ifaceName string) error {
+	c.deletedRootQdiscs = append(c.deletedRootQdiscs, ifaceName)
+	return c.deleteRootQdiscErr
+}
+
+func (c *fakeNetCtl) PacketFilterChains(name string) ([]netctl.Chain, error) {
+	filter := c.packetFilters[name]
+
+	var chains []netctl.Chain
+
+	for _, hook := range filter.Hooks() {
+		chain := netctl.Chain{Name: hook, Hook: hook}
+		for _, rule := range filter.Rules {
+			if rule.Hook == hook {
+				chain.Rules++
+			}
+		}
+		chains = append(chains, chain)
+	}
+
+	if len(chains) > 0 {
+		chains[0].Rules -= c.missingRules
+	}
+
+	return chains, nil
+}
+
+func (c *fakeNetCtl) AddPacketFilter(filter netctl.PacketFilter) error {
+	if c.addPacketFilterErr != nil {
+		return c.addPacketFilterErr
+	}
+	c.packetFilters[filter.Name] = filter
+	return nil
+}
+
+func (c *fakeNetCtl) DeletePacketFilter(name string) error {
+	c.deletedPacketFilters = append(c.deletedPacketFilters, name)
+	return c.deletePacketFilterErr
+}
+
+var _ = Describe("BlockDNSTask", func() {
+	var (
+		netCtl   *fakeNetCtl
+		journal  *fakeJournal
+		recorder *ResultRecorder
+		task     BlockDNSTask
+	)
+
+	BeforeEach(func() {
+		netCtl = newFakeNetCtl()
+		journal = &fakeJournal{}
+		recorder = NewResultRecorder(nil)
+		task = NewBlockDNSTask(netCtl, BlockDNSOptions{Timeout: "1m"}, "task-1", journal, recorder, boshlog.NewLogger(boshlog.LevelNone))
+	})
+
+	It("keeps rules in task's table and removes the table once stopped", func() {
+		stopCh := make(chan struct{})
+		close(stopCh)
+
+		Expect(task.Execute(stopCh)).ToNot(HaveOccurred())
+
+		Expect(netCtl.packetFilters).To(HaveKey("turbulence-task1"))
+		Expect(netCtl.packetFilters["turbulence-task1"].Rules).To(HaveLen(2))
+		Expect(netCtl.deletedPacketFilters).To(Equal([]string{"turbulence-task1"}))
+
+		Expect(journal.undoCmds).To(Equal([][]string{
+			{"netctl", "delete-packet-filter", "turbulence-task1"},
+		}))
+	})
+
+	It("records applied changes and times of applying and reverting", func() {
+		stopCh := make(chan struct{})
+		close(stopCh)
+
+		Expect(task.Execute(stopCh)).ToNot(HaveOccurred())
+
+		result := recorder.Result()
+		Expect(result.Actions).To(Equal([]string{
+			"add table ip turbulence-task1",
+			"add chain ip turbulence-task1 output { type filter hook output priority 0; }",
+			"add rule ip turbulence-task1 output meta l4proto tcp th dport 53 drop",
+			"add rule ip turbulence-task1 output meta l4proto udp th dport 53 drop",
+		}))
+		Expect(result.AppliedAt.IsZero()).To(BeFalse())
+		Expect(result.RevertedAt.IsZero()).To(BeFalse())
+		Expect(result.RevertSucceeded()).To(BeTrue())
+		Expect(result.Progress).To(HaveLen(4))
+	})
+
+	It("records revert error", func() {
+		netCtl.deletePacketFilterErr = errors.New("fake-err")
+
+		stopCh := make(chan struct{})
+		close(stopCh)
+
+		Expect(task.Execute(stopCh)).To(HaveOccurred())
+
+		result := recorder.Result()
+		Expect(result.RevertSucceeded()).To(BeFalse())
+		Expect(result.RevertError).To(ContainSubstring("fake-err"))
+	})
+
+	It("does not record undo command when adding rules fails", func() {
+		netCtl.addPacketFilterErr = errors.New("fake-err")
+
+		err := task.Execute(make(chan struct{}))
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("fake-err"))
+
+		Expect(recorder.Result().AppliedAt.IsZero()).To(BeTrue())
+		Expect(journal.undoCmds).To(BeEmpty())
+	})
+
+	It("removes the table when rules cannot be found after adding them", func() {
+		netCtl.missingRules = 1
+
+		err := task.Execute(make(chan struct{}))
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal("Expected nftables table 'turbulence-task1' to have 2 rule(s) in output hook but found 1"))
+
+		Expect(recorder.Result().AppliedAt.IsZero()).To(BeTrue())
+		Expect(netCtl.deletedPacketFilters).To(Equal([]string{"turbulence-task1"}))
+	})
+})
+
+var _ = Describe("FirewallTask", func() {
+	It("allows SSH and traffic to allowed destinations and drops the rest", func() {
+		netCtl := newFakeNetCtl()
+		recorder := NewResultRecorder(nil)
+
+		dests := []FirewallTaskDest{
+			{Host: "127.0.0.1", Port: 8080},
+			{Host: "127.0.0.2", Port: 6868, IsBOSHMbus: true},
+		}
+
+		task := NewFirewallTask(netCtl, FirewallOptions{Timeout: "1m", BlockBOSHAgent: true}, dests, "task-1", &fakeJournal{}, recorder, boshlog.NewLogger(boshlog.LevelNone))
+
+		stopCh := make(chan struct{})
+		close(stopCh)
+
+		Expect(task.Execute(stopCh)).ToNot(HaveOccurred())
+
+		Expect(recorder.Result().Actions[3:]).To(Equal([]string{
+			`add rule ip turbulence-task1 input iifname != "lo" meta l4proto tcp ip saddr 127.0.0.1 th sport 8080 ct state new,established accept`,
+			`add rule ip turbulence-task1 input iifname != "lo" meta l4proto tcp th dport 22 ct state new,established accept`,
+			`add rule ip turbulence-task1 input iifname != "lo" drop`,
+			`add rule ip turbulence-task1 output oifname != "lo" meta l4proto tcp ip daddr 127.0.0.1 th dport 8080 ct state new,established accept`,
+			`add rule ip turbulence-task1 output oifname != "lo" meta l4proto tcp th sport 22 ct state new,established accept`,
+			`add rule ip turbulence-task1 output oifname != "lo" drop`,
+		}))
+	})
+})
+
+var _ = Describe("TargetedBlockerTask", func() {
+	var (
+		netCtl   *fakeNetCtl
+		recorder *ResultRecorder
+	)
+
+	BeforeEach(func() {
+		netCtl = newFakeNetCtl()
+		recorder = NewResultRecorder(nil)
+	})
+
+	execute := func(targets ...Target) error {
+		cmdRunner := fakesys.NewFakeCmdRunner()
+		cmdRunner.AddCmdResult("dig +short example.com", fakesys.FakeCmdResult{Stdout: "10.0.0.5\n10.0.0.6\n"})
+
+		task := NewTargetedBlockerTask(cmdRunner, netCtl, TargetedBlockerOptions{Timeout: "1m", Targets: targets}, "task-1", &fakeJournal{}, recorder, boshlog.NewLogger(boshlog.LevelNone))
+
+		stopCh := make(chan struct{})
+		close(stopCh)
+
+		return task.Execute(stopCh)
+	}
+
+	It("adds rule for each combination of protocol, source and destination", func() {
+		err := execute(Target{Direction: "INPUT", SrcHost: "example.com", DstHost: "10.0.1.0/24", DstPorts: "1000:2000"})
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(recorder.Result().Actions[2:]).To(Equal([]string{
+			"add rule ip turbulence-task1 input meta l4proto tcp ip saddr 10.0.0.5 ip daddr 10.0.1.0/24 th dport 1000-2000 drop",
+			"add rule ip turbulence-task1 input meta l4proto tcp ip saddr 10.0.0.6 ip daddr 10.0.1.0/24 th dport 1000-2000 drop",
+			"add rule ip turbulence-task1 input meta l4proto udp ip saddr 10.0.0.5 ip daddr 10.0.1.0/24 th dport 1000-2000 drop",
+			"add rule ip turbulence-task1 input meta l4proto udp ip saddr 10.0.0.6 ip daddr 10.0.1.0/24 th dport 1000-2000 drop",
+		}))
+
+		Expect(recorder.Result().ResolvedIPs).To(Equal(map[string][]string{"example.com": {"10.0.0.5", "10.0.0.6"}}))
+	})
+
+	It("matches all protocols when ports are not specified", func() {
+		Expect(execute(Target{Direction: "OUTPUT", DstHost: "10.0.0.1"})).ToNot(HaveOccurred())
+
+		Expect(recorder.Result().Actions[2:]).To(Equal([]string{
+			"add rule ip turbulence-task1 output ip daddr 10.0.0.1 drop",
+		}))
+	})
+
+	It("does not add any rules when direction is invalid", func() {
+		err := execute(Target{Direction: "SIDEWAYS", DstHost: "10.0.0.1"})
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Invalid direction 'SIDEWAYS'"))
+
+		Expect(netCtl.packetFilters).To(BeEmpty())
+	})
+})
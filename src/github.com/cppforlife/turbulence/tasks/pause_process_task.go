@@ -1,6 +1,9 @@
 package tasks
 
 import(
+	"strconv"
+	"strings"
+
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
@@ -16,11 +19,37 @@ type PauseProcessOptions struct {
 	ProcessName string
 }
 
-func (PauseProcessOptions) _private() {}
+func init() {
+	Register(TaskType{
+		Name:        "PauseProcess",
+		Description: "Pauses processes matching a pattern until timeout or stop",
+
+		NewOptions: func() Options { return PauseProcessOptions{} },
+		NewTask: func(opts Options, deps AgentDeps) (AgentTask, error) {
+			return NewPauseProcessTask(deps.CmdRunner, opts.(PauseProcessOptions), deps.Journal, deps.Recorder, deps.Logger), nil
+		},
+
+		RunsUntilStopped: func(opts Options) bool { return len(opts.(PauseProcessOptions).Timeout) == 0 },
+	})
+}
+
+func (o PauseProcessOptions) Validate() error {
+	var errs fieldErrors
+
+	errs.validateTimeout(o.Timeout, false)
+
+	if len(o.ProcessName) == 0 {
+		errs.add("ProcessName", "must be specified")
+	}
+
+	return errs.err()
+}
 
 type PauseProcessTask struct {
 	cmdRunner boshsys.CmdRunner
 	opts PauseProcessOptions
+	journal Journal
+	recorder Recorder
 
 	logTag string
 	logger boshlog.Logger
@@ -29,9 +58,11 @@ type PauseProcessTask struct {
 func NewPauseProcessTask(
 	cmdRunner boshsys.CmdRunner,
 	opts PauseProcessOptions,
+	journal Journal,
+	recorder Recorder,
 	logger boshlog.Logger,
 ) PauseProcessTask {
-	return PauseProcessTask{cmdRunner, opts, "tasks.PauseProcessTask", logger}
+	return PauseProcessTask{cmdRunner, opts, journal, recorder, "tasks.PauseProcessTask", logger}
 }
 
 func (t PauseProcessTask) Execute(stopCh chan struct{}) error {
@@ -42,6 +73,11 @@ func (t PauseProcessTask) Execute(stopCh chan struct{}) error {
 
 	t.logger.Debug(t.logTag, "Pausing processes matching '%s'", t.opts.ProcessName)
 
+	// Record before pausing since some processes may be paused even if pkill fails
+	t.journal.Record("paused processes matching "+t.opts.ProcessName, "pkill", "-CONT", t.opts.ProcessName)
+
+	t.recorder.PIDs(matchingPIDs(t.cmdRunner, t.opts.ProcessName)...)
+
 	_, _, exitStatus, err := t.cmdRunner.RunCommand("pkill", "-STOP", t.opts.ProcessName)
 	if err != nil {
 		return bosherr.WrapError(err, "Pausing process")
@@ -49,12 +85,24 @@ func (t PauseProcessTask) Execute(stopCh chan struct{}) error {
 		return bosherr.Errorf("pkill exited with status %d", exitStatus)
 	}
 
+	t.recorder.Action("pkill -STOP " + t.opts.ProcessName)
+	t.recorder.Applied()
+
 	select {
 	case <-timeoutCh:
 	case <-stopCh:
 	}
 
-	_, _, exitStatus, err = t.cmdRunner.RunCommand("pkill", "-CONT", t.opts.ProcessName)
+	t.recorder.Reverting()
+
+	err = t.resume()
+	t.recorder.Reverted(err)
+
+	return err
+}
+
+func (t PauseProcessTask) resume() error {
+	_, _, exitStatus, err := t.cmdRunner.RunCommand("pkill", "-CONT", t.opts.ProcessName)
 	if err != nil {
 		return bosherr.WrapError(err, "Resuming process")
 	} else if exitStatus != 0 {
@@ -62,4 +110,24 @@ func (t PauseProcessTask) Execute(stopCh chan struct{}) error {
 	}
 
 	return nil
+}
+
+// matchingPIDs returns PIDs of processes matching pkill pattern;
+// it's best effort since PIDs are only reported in task result
+func matchingPIDs(cmdRunner boshsys.CmdRunner, pattern string) []int {
+	stdout, _, _, err := cmdRunner.RunCommand("pgrep", pattern)
+	if err != nil {
+		return nil
+	}
+
+	var pids []int
+
+	for _, field := range strings.Fields(stdout) {
+		pid, err := strconv.Atoi(field)
+		if err == nil {
+			pids = append(pids, pid)
+		}
+	}
+
+	return pids
 }
\ No newline at end of file
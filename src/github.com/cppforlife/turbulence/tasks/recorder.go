@@ -0,0 +1,153 @@
+package tasks
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Recorder collects details of what agent task did so that
+// they are included in the result reported to the API server
+type Recorder interface {
+	// Action records applied change, e.g. exact nftables rule or tc qdisc
+	Action(description string)
+	ResolvedIPs(host string, ips []string)
+	PIDs(pids ...int)
+
+	// Applied is called once task's changes took effect
+	Applied()
+
+	// Reverting is called before task starts undoing its changes
+	Reverting()
+
+	// Reverted is called once task attempted to undo its changes
+	Reverted(err error)
+}
+
+type noopRecorder struct{}
+
+func (noopRecorder) Action(string)                {}
+func (noopRecorder) ResolvedIPs(string, []string) {}
+func (noopRecorder) PIDs(...int)                  {}
+func (noopRecorder) Applied()                     {}
+func (noopRecorder) Reverting()                   {}
+func (noopRecorder) Reverted(error)               {}
+
+// ResultRecorder is used by the agent to build task result
+type ResultRecorder struct {
+	result     Result
+	resultLock sync.Mutex
+
+	// Optionally called after each progress change
+	progressFunc func()
+}
+
+// NewResultRecorder starts recording in applying state;
+// progressFunc may be nil
+func NewResultRecorder(progressFunc func()) *ResultRecorder {
+	now := time.Now().UTC()
+
+	result := Result{
+		StartedAt: now,
+		Progress:  []Progress{{State: ProgressApplying, At: now}},
+	}
+
+	return &ResultRecorder{result: result, progressFunc: progressFunc}
+}
+
+func (r *ResultRecorder) Action(description string) {
+	r.resultLock.Lock()
+	r.result.Actions = append(r.result.Actions, description)
+	r.resultLock.Unlock()
+}
+
+func (r *ResultRecorder) ResolvedIPs(host string, ips []string) {
+	r.resultLock.Lock()
+	defer r.resultLock.Unlock()
+
+	if r.result.ResolvedIPs == nil {
+		r.result.ResolvedIPs = map[string][]string{}
+	}
+
+	r.result.ResolvedIPs[host] = append([]string{}, ips...)
+}
+
+func (r *ResultRecorder) PIDs(pids ...int) {
+	r.resultLock.Lock()
+	defer r.resultLock.Unlock()
+
+	r.result.PIDs = append(r.result.PIDs, pids...)
+	sort.Ints(r.result.PIDs)
+}
+
+func (r *ResultRecorder) Applied() {
+	r.resultLock.Lock()
+	r.result.AppliedAt = r.progress(ProgressActive)
+	r.resultLock.Unlock()
+
+	r.notify()
+}
+
+func (r *ResultRecorder) Reverting() {
+	r.resultLock.Lock()
+	r.progress(ProgressReverting)
+	r.resultLock.Unlock()
+
+	r.notify()
+}
+
+func (r *ResultRecorder) Reverted(err error) {
+	r.resultLock.Lock()
+
+	r.result.RevertedAt = r.progress(ProgressReverted)
+
+	if err != nil {
+		r.result.RevertError = err.Error()
+	}
+
+	r.resultLock.Unlock()
+
+	r.notify()
+}
+
+// Progress returns states recorded so far
+func (r *ResultRecorder) Progress() []Progress {
+	r.resultLock.Lock()
+	defer r.resultLock.Unlock()
+
+	return append([]Progress(nil), r.result.Progress...)
+}
+
+// progress must be called with resultLock held
+func (r *ResultRecorder) progress(state string) time.Time {
+	now := time.Now().UTC()
+	r.result.Progress = append(r.result.Progress, Progress{State: state, At: now})
+	return now
+}
+
+func (r *ResultRecorder) notify() {
+	if r.progressFunc != nil {
+		r.progressFunc()
+	}
+}
+
+// Result returns details recorded so far
+func (r *ResultRecorder) Result() Result {
+	r.resultLock.Lock()
+	defer r.resultLock.Unlock()
+
+	result := r.result
+	result.Actions = append([]string(nil), r.result.Actions...)
+	result.PIDs = append([]int(nil), r.result.PIDs...)
+	result.Progress = append([]Progress(nil), r.result.Progress...)
+
+	if r.result.ResolvedIPs != nil {
+		result.ResolvedIPs = map[string][]string{}
+
+		for host, ips := range r.result.ResolvedIPs {
+			result.ResolvedIPs[host] = ips
+		}
+	}
+
+	return result
+}
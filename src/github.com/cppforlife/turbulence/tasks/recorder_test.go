@@ -0,0 +1,77 @@
+package tasks_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cppforlife/turbulence/tasks"
+)
+
+var _ = Describe("ResultRecorder", func() {
+	var (
+		recorder *ResultRecorder
+	)
+
+	BeforeEach(func() {
+		recorder = NewResultRecorder(nil)
+	})
+
+	It("starts with start time and nothing applied or reverted", func() {
+		result := recorder.Result()
+		Expect(result.StartedAt.IsZero()).To(BeFalse())
+		Expect(result.AppliedAt.IsZero()).To(BeTrue())
+		Expect(result.RevertedAt.IsZero()).To(BeTrue())
+		Expect(result.RevertSucceeded()).To(BeFalse())
+	})
+
+	It("collects actions, resolved IPs and sorted PIDs", func() {
+		recorder.Action("action-1")
+		recorder.Action("action-2")
+		recorder.ResolvedIPs("host", []string{"10.0.0.1", "10.0.0.2"})
+		recorder.PIDs(20, 10)
+		recorder.PIDs(15)
+
+		result := recorder.Result()
+		Expect(result.Actions).To(Equal([]string{"action-1", "action-2"}))
+		Expect(result.ResolvedIPs).To(Equal(map[string][]string{"host": {"10.0.0.1", "10.0.0.2"}}))
+		Expect(result.PIDs).To(Equal([]int{10, 15, 20}))
+	})
+
+	It("returns copies that are not affected by later changes", func() {
+		recorder.Action("action-1")
+		result := recorder.Result()
+
+		recorder.Action("action-2")
+		Expect(result.Actions).To(Equal([]string{"action-1"}))
+	})
+
+	It("records progress states and notifies about changes", func() {
+		var notified int
+		recorder = NewResultRecorder(func() { notified++ })
+
+		recorder.Applied()
+		recorder.Reverting()
+		recorder.Reverted(nil)
+
+		var states []string
+
+		for _, progress := range recorder.Result().Progress {
+			states = append(states, progress.State)
+		}
+
+		Expect(states).To(Equal([]string{ProgressApplying, ProgressActive, ProgressReverting, ProgressReverted}))
+		Expect(notified).To(Equal(3))
+	})
+
+	It("records whether revert succeeded", func() {
+		recorder.Applied()
+		recorder.Reverted(nil)
+		Expect(recorder.Result().RevertSucceeded()).To(BeTrue())
+
+		recorder.Reverted(errors.New("fake-err"))
+		Expect(recorder.Result().RevertSucceeded()).To(BeFalse())
+		Expect(recorder.Result().RevertError).To(Equal("fake-err"))
+	})
+})
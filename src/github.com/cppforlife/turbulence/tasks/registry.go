@@ -0,0 +1,208 @@
+package tasks
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+
+	"github.com/cppforlife/turbulence/tasks/monit"
+	"github.com/cppforlife/turbulence/tasks/netctl"
+)
+
+// TaskType describes a kind of task that can be included in an incident.
+// Packages adding new task types register them from init(); such package
+// has to be imported by both API server and agent binaries.
+type TaskType struct {
+	Name        string
+	Description string
+
+	// NewOptions returns options with default values;
+	// options must be a struct with a string Type field
+	NewOptions func() Options
+
+	// NewTask builds task executed by the agent;
+	// it's nil for tasks executed by the API server (e.g. Kill)
+	NewTask func(Options, AgentDeps) (AgentTask, error)
+
+	// RequiredCapabilities optionally returns names of capabilities
+	// that agent must have to execute task with given options
+	RequiredCapabilities func(Options) []string
+
+	// RunsUntilStopped optionally returns true if task with given
+	// options only finishes once it's stopped (e.g. without Timeout)
+	RunsUntilStopped func(Options) bool
+}
+
+type AgentTask interface {
+	Execute(stopCh chan struct{}) error
+}
+
+// AgentDeps are made available to tasks executed by the agent
+type AgentDeps struct {
+	CmdRunner     boshsys.CmdRunner
+	MonitProvider monit.ClientProvider
+
+	// Reads network configuration natively
+	NetCtl netctl.Controller
+
+	// Destinations that must stay reachable when traffic is blocked
+	AllowedOutputDests []FirewallTaskDest
+
+	// ID, journal and recorder of the task being built
+	TaskID   string
+	Journal  Journal
+	Recorder Recorder
+
+	Logger boshlog.Logger
+}
+
+var registry = struct {
+	sync.RWMutex
+
+	types map[string]TaskType
+	names map[reflect.Type]string
+}{
+	types: map[string]TaskType{},
+	names: map[reflect.Type]string{},
+}
+
+// Register makes task type available by its name; it panics
+// if task type is misconfigured or its name is already taken.
+func Register(taskType TaskType) {
+	registry.Lock()
+	defer registry.Unlock()
+
+	if len(taskType.Name) == 0 {
+		panic("tasks: Register task type without a name")
+	}
+
+	if _, found := registry.types[taskType.Name]; found {
+		panic(fmt.Sprintf("tasks: Register called twice for task type '%s'", taskType.Name))
+	}
+
+	if taskType.NewOptions == nil {
+		panic(fmt.Sprintf("tasks: Register task type '%s' without options", taskType.Name))
+	}
+
+	optsType := reflect.TypeOf(taskType.NewOptions())
+
+	if optsType == nil || optsType.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("tasks: Register task type '%s' with non-struct options", taskType.Name))
+	}
+
+	if field, found := optsType.FieldByName("Type"); !found || field.Type.Kind() != reflect.String {
+		panic(fmt.Sprintf("tasks: Register task type '%s' with options without Type field", taskType.Name))
+	}
+
+	if name, found := registry.names[optsType]; found {
+		panic(fmt.Sprintf("tasks: Register task type '%s' with options of task type '%s'", taskType.Name, name))
+	}
+
+	registry.types[taskType.Name] = taskType
+	registry.names[optsType] = taskType.Name
+}
+
+func FindType(name string) (TaskType, bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	taskType, found := registry.types[name]
+
+	return taskType, found
+}
+
+// Types returns all registered task types sorted by name
+func Types() []TaskType {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	var types []TaskType
+
+	for _, taskType := range registry.types {
+		types = append(types, taskType)
+	}
+
+	sort.Slice(types, func(i, j int) bool { return types[i].Name < types[j].Name })
+
+	return types
+}
+
+func findTypeName(taskOpts Options) (string, bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	name, found := registry.names[reflect.TypeOf(taskOpts)]
+
+	return name, found
+}
+
+// RunsUntilStopped returns true if task with given options
+// does not finish unless it's stopped (e.g. incident is aborted)
+func RunsUntilStopped(taskOpts Options) bool {
+	name, found := findTypeName(taskOpts)
+	if !found {
+		return false
+	}
+
+	taskType, _ := FindType(name)
+
+	if taskType.RunsUntilStopped == nil {
+		return false
+	}
+
+	return taskType.RunsUntilStopped(taskOpts)
+}
+
+func (t TaskType) unmarshalOptions(bytes []byte) (Options, error) {
+	optsVal := reflect.New(reflect.TypeOf(t.NewOptions()))
+	optsVal.Elem().Set(reflect.ValueOf(t.NewOptions()))
+
+	err := json.Unmarshal(bytes, optsVal.Interface())
+	if err != nil {
+		return nil, err
+	}
+
+	return optsVal.Elem().Interface().(Options), nil
+}
+
+func (t TaskType) withTypeName(taskOpts Options) Options {
+	optsVal := reflect.New(reflect.TypeOf(taskOpts)).Elem()
+	optsVal.Set(reflect.ValueOf(taskOpts))
+	optsVal.FieldByName("Type").SetString(t.Name)
+
+	return optsVal.Interface().(Options)
+}
+
+// BuildAgentTask builds agent task for given options based on their registered type
+func BuildAgentTask(taskOpts Options, deps AgentDeps) (AgentTask, error) {
+	name, found := findTypeName(taskOpts)
+	if !found {
+		return nil, bosherr.Errorf("Unknown task type '%T'", taskOpts)
+	}
+
+	taskType, _ := FindType(name)
+
+	if taskType.NewTask == nil {
+		return nil, bosherr.Errorf("Task type '%s' is not executed by agents", name)
+	}
+
+	if deps.Journal == nil {
+		deps.Journal = noopJournal{}
+	}
+
+	if deps.Recorder == nil {
+		deps.Recorder = noopRecorder{}
+	}
+
+	if deps.NetCtl == nil {
+		deps.NetCtl = netctl.NewController()
+	}
+
+	return taskType.NewTask(taskOpts, deps)
+}
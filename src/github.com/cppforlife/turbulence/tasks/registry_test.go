@@ -0,0 +1,129 @@
+package tasks_test
+
+import (
+	"encoding/json"
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cppforlife/turbulence/tasks"
+)
+
+type customOptions struct {
+	Type    string
+	Timeout string
+
+	Rate    int
+	Enabled bool `json:"on"`
+	Ports   []customPort
+
+	secret string
+}
+
+type customPort struct {
+	Port int
+}
+
+func (customOptions) Validate() error { return nil }
+
+type customTask struct {
+	opts customOptions
+}
+
+func (t customTask) Execute(_ chan struct{}) error {
+	return errors.New("executed " + t.opts.Timeout)
+}
+
+func init() {
+	Register(TaskType{
+		Name:        "Custom",
+		Description: "Custom fault",
+
+		NewOptions: func() Options { return customOptions{Timeout: "1m"} },
+
+		NewTask: func(opts Options, _ AgentDeps) (AgentTask, error) {
+			return customTask{opts.(customOptions)}, nil
+		},
+	})
+}
+
+var _ = Describe("Register", func() {
+	It("unmarshals registered task type with default options", func() {
+		var opts OptionsSlice
+
+		err := json.Unmarshal([]byte(`[{ "Type": "Custom", "Rate": 5 }, { "Type": "Stress", "NumCPUWorkers": 1 }]`), &opts)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(opts).To(Equal(OptionsSlice{
+			customOptions{Type: "Custom", Timeout: "1m", Rate: 5},
+			StressOptions{Type: "Stress", NumCPUWorkers: 1},
+		}))
+	})
+
+	It("marshals registered task type with its name", func() {
+		bytes, err := json.Marshal(OptionsSlice{customOptions{Rate: 2}, NoopOptions{}})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(bytes)).To(Equal(
+			`[{"Type":"Custom","Timeout":"","Rate":2,"on":false,"Ports":null},{"Type":"Noop","Stoppable":false}]`))
+
+		Expect(OptionsType(customOptions{})).To(Equal("Custom"))
+	})
+
+	It("returns error for unknown task type", func() {
+		var opts OptionsSlice
+
+		err := json.Unmarshal([]byte(`[{ "Type": "Unknown" }]`), &opts)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Unknown task type 'Unknown'"))
+	})
+
+	It("builds agent task for registered task type", func() {
+		task, err := BuildAgentTask(customOptions{Timeout: "2m"}, AgentDeps{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(task.Execute(nil)).To(MatchError("executed 2m"))
+	})
+
+	It("does not build agent task for tasks executed by the API server", func() {
+		_, err := BuildAgentTask(KillOptions{}, AgentDeps{})
+		Expect(err).To(MatchError("Task type 'Kill' is not executed by agents"))
+	})
+
+	It("panics when name is already registered", func() {
+		Expect(func() {
+			Register(TaskType{Name: "Custom", NewOptions: func() Options { return customOptions{} }})
+		}).To(Panic())
+	})
+
+	It("panics when options are already registered under a different name", func() {
+		Expect(func() {
+			Register(TaskType{Name: "Custom2", NewOptions: func() Options { return customOptions{} }})
+		}).To(Panic())
+	})
+})
+
+var _ = Describe("Types", func() {
+	It("lists registered task types sorted by name", func() {
+		var names []string
+
+		for _, taskType := range Types() {
+			names = append(names, taskType.Name)
+		}
+
+		Expect(names).To(Equal([]string{
+			"BlockDNS", "ControlNet", "Custom", "FillDisk", "Firewall", "Kill",
+			"KillProcess", "Noop", "PauseProcess", "Shutdown", "Stress", "TargetedBlocker",
+		}))
+	})
+
+	It("describes options of task type", func() {
+		taskType, found := FindType("Custom")
+		Expect(found).To(BeTrue())
+
+		Expect(taskType.OptionsSchema()).To(Equal([]OptionSchema{
+			{Name: "Timeout", Type: "string"},
+			{Name: "Rate", Type: "integer"},
+			{Name: "on", Type: "bool"},
+			{Name: "Ports", Type: "array", Options: []OptionSchema{{Name: "Port", Type: "integer"}}},
+		}))
+	})
+})
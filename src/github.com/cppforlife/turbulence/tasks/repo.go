@@ -12,9 +12,18 @@ type repo struct {
 	inboxes     map[string]agentInbox
 	inboxesLock sync.RWMutex
 
-	tasks     map[string]ResultRequest
-	tasksLock sync.RWMutex
-	taskChs   map[string]chan struct{}
+	// Wake up agent's poll when tasks are queued or should stop
+	agentChs     map[string]chan struct{}
+	agentChsLock sync.Mutex
+
+	tasks      map[string]ResultRequest
+	tasksLock  sync.RWMutex
+	taskChs    map[string]chan struct{}
+	taskAgents map[string]string
+
+	// Closed and replaced whenever task reports progress
+	taskProgress    map[string][]Progress
+	taskProgressChs map[string]chan struct{}
 
 	taskStates     map[string]State
 	taskStatesLock sync.RWMutex
@@ -30,10 +39,15 @@ type agentInbox struct {
 
 func NewRepo(logger boshlog.Logger) Repo {
 	return &repo{
-		inboxes: map[string]agentInbox{},
+		inboxes:  map[string]agentInbox{},
+		agentChs: map[string]chan struct{}{},
 
-		tasks:   map[string]ResultRequest{},
-		taskChs: map[string]chan struct{}{},
+		tasks:      map[string]ResultRequest{},
+		taskChs:    map[string]chan struct{}{},
+		taskAgents: map[string]string{},
+
+		taskProgress:    map[string][]Progress{},
+		taskProgressChs: map[string]chan struct{}{},
 
 		taskStates: map[string]State{},
 
@@ -42,12 +56,15 @@ func NewRepo(logger boshlog.Logger) Repo {
 	}
 }
 
-func (r *repo) QueueAndWait(agentID string, tasks []Task) error {
+// QueueAndWait waits until agent consumes queued tasks;
+// closing cancelCh removes tasks that were not yet consumed.
+func (r *repo) QueueAndWait(agentID string, tasks []Task, cancelCh <-chan struct{}) error {
 	// Set up wait channels for tasks
 	r.tasksLock.Lock()
 
 	for _, task := range tasks {
 		r.taskChs[task.ID] = make(chan struct{})
+		r.taskAgents[task.ID] = agentID
 	}
 
 	r.tasksLock.Unlock()
@@ -70,6 +87,8 @@ func (r *repo) QueueAndWait(agentID string, tasks []Task) error {
 	// Unlock before blocking
 	r.inboxesLock.Unlock()
 
+	r.notify(agentID)
+
 	select {
 	case <-consumed:
 		r.logger.Debug(r.logTag, "Finished waiting since agent '%s' consumed tasks", agentID)
@@ -90,14 +109,61 @@ func (r *repo) QueueAndWait(agentID string, tasks []Task) error {
 
 		for _, task := range tasks {
 			delete(r.taskChs, task.ID)
+			delete(r.taskAgents, task.ID)
 		}
 
 		r.tasksLock.Unlock()
 
 		return bosherr.Errorf("Timed out waiting for agent '%s' to consume tasks", agentID)
+
+	case <-cancelCh:
+		r.logger.Debug(r.logTag, "Cancelled waiting for agent '%s' to consume tasks", agentID)
+
+		r.dequeue(agentID, tasks)
+
+		return bosherr.Errorf("Cancelled waiting for agent '%s' to consume tasks", agentID)
 	}
 }
 
+// dequeue removes given tasks from agent inbox
+// leaving other queued tasks for the agent in place.
+func (r *repo) dequeue(agentID string, tasks []Task) {
+	r.inboxesLock.Lock()
+
+	if rec, found := r.inboxes[agentID]; found {
+		var remaining []Task
+
+		for _, queuedTask := range rec.tasks {
+			dequeued := false
+
+			for _, task := range tasks {
+				if task.ID == queuedTask.ID {
+					dequeued = true
+					break
+				}
+			}
+
+			if !dequeued {
+				remaining = append(remaining, queuedTask)
+			}
+		}
+
+		rec.tasks = remaining
+		r.inboxes[agentID] = rec
+	}
+
+	r.inboxesLock.Unlock()
+
+	r.tasksLock.Lock()
+
+	for _, task := range tasks {
+		delete(r.taskChs, task.ID)
+		delete(r.taskAgents, task.ID)
+	}
+
+	r.tasksLock.Unlock()
+}
+
 func (r *repo) Consume(agentID string) ([]Task, error) {
 	if len(agentID) == 0 {
 		return nil, bosherr.Error("Must provide non-empty agent ID")
@@ -123,6 +189,81 @@ func (r *repo) Consume(agentID string) ([]Task, error) {
 	return rec.tasks, nil
 }
 
+func (r *repo) Poll(agentID string, runningTaskIDs []string, timeout time.Duration, cancelCh <-chan struct{}) ([]Task, []string, error) {
+	if len(agentID) == 0 {
+		return nil, nil, bosherr.Error("Must provide non-empty agent ID")
+	}
+
+	agentCh := r.agentCh(agentID)
+	timeoutCh := time.After(timeout)
+
+	for {
+		select {
+		case <-cancelCh:
+			// Do not consume tasks that agent would never receive
+			return nil, nil, nil
+		default:
+		}
+
+		stopTaskIDs := r.stopTaskIDs(runningTaskIDs)
+
+		tasks, err := r.Consume(agentID)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if len(tasks) > 0 || len(stopTaskIDs) > 0 {
+			return tasks, stopTaskIDs, nil
+		}
+
+		select {
+		case <-agentCh:
+			// Check again since something changed for the agent
+		case <-timeoutCh:
+			return nil, nil, nil
+		case <-cancelCh:
+			return nil, nil, nil
+		}
+	}
+}
+
+func (r *repo) stopTaskIDs(taskIDs []string) []string {
+	r.taskStatesLock.RLock()
+	defer r.taskStatesLock.RUnlock()
+
+	var stopTaskIDs []string
+
+	for _, taskID := range taskIDs {
+		if r.taskStates[taskID].Stop {
+			stopTaskIDs = append(stopTaskIDs, taskID)
+		}
+	}
+
+	return stopTaskIDs
+}
+
+func (r *repo) agentCh(agentID string) chan struct{} {
+	r.agentChsLock.Lock()
+	defer r.agentChsLock.Unlock()
+
+	ch, found := r.agentChs[agentID]
+	if !found {
+		// Buffered so that change is noticed even when agent is not polling
+		ch = make(chan struct{}, 1)
+		r.agentChs[agentID] = ch
+	}
+
+	return ch
+}
+
+func (r *repo) notify(agentID string) {
+	select {
+	case r.agentCh(agentID) <- struct{}{}:
+	default:
+		// Agent will already be woken up
+	}
+}
+
 func (r *repo) Wait(taskID string) (ResultRequest, error) {
 	if len(taskID) == 0 {
 		return ResultRequest{}, bosherr.Error("Must provide non-empty task ID")
@@ -132,6 +273,7 @@ func (r *repo) Wait(taskID string) (ResultRequest, error) {
 
 	ch, found := r.taskChs[taskID]
 	if !found {
+		r.tasksLock.Unlock()
 		return ResultRequest{}, bosherr.Error("Waiting must happen after queueing")
 	}
 
@@ -157,6 +299,11 @@ func (r *repo) Update(taskID string, taskReq ResultRequest) error {
 	// Save task before closing channel
 	r.tasks[taskID] = taskReq
 
+	// Agent is done with the task
+	delete(r.taskAgents, taskID)
+	delete(r.taskProgress, taskID)
+	delete(r.taskProgressChs, taskID)
+
 	if ch, found := r.taskChs[taskID]; found {
 		// Unblock all waiting clients
 		close(ch)
@@ -172,6 +319,74 @@ func (r *repo) Update(taskID string, taskReq ResultRequest) error {
 	return nil
 }
 
+func (r *repo) WaitProgress(taskID string, known int) ([]Progress, bool, error) {
+	if len(taskID) == 0 {
+		return nil, false, bosherr.Error("Must provide non-empty task ID")
+	}
+
+	for {
+		r.tasksLock.Lock()
+
+		taskCh, found := r.taskChs[taskID]
+		if !found {
+			r.tasksLock.Unlock()
+			return nil, false, bosherr.Error("Waiting must happen after queueing")
+		}
+
+		progress := r.taskProgress[taskID]
+		progressCh := r.taskProgressCh(taskID)
+
+		r.tasksLock.Unlock()
+
+		if len(progress) > known {
+			return progress, false, nil
+		}
+
+		select {
+		case <-taskCh:
+			return nil, true, nil
+		case <-progressCh:
+		}
+	}
+}
+
+// UpdateProgress ignores progress of finished tasks and
+// progress that is older than already known (e.g. from concurrent polls)
+func (r *repo) UpdateProgress(taskID string, progress []Progress) error {
+	if len(taskID) == 0 {
+		return bosherr.Error("Must provide non-empty task ID")
+	}
+
+	r.tasksLock.Lock()
+	defer r.tasksLock.Unlock()
+
+	if _, running := r.taskAgents[taskID]; !running {
+		return nil
+	}
+
+	if len(progress) <= len(r.taskProgress[taskID]) {
+		return nil
+	}
+
+	r.taskProgress[taskID] = progress
+
+	close(r.taskProgressCh(taskID))
+	delete(r.taskProgressChs, taskID)
+
+	return nil
+}
+
+// taskProgressCh must be called with tasksLock held
+func (r *repo) taskProgressCh(taskID string) chan struct{} {
+	ch, found := r.taskProgressChs[taskID]
+	if !found {
+		ch = make(chan struct{})
+		r.taskProgressChs[taskID] = ch
+	}
+
+	return ch
+}
+
 func (r *repo) FetchState(taskID string) (State, error) {
 	if len(taskID) == 0 {
 		return State{}, bosherr.Error("Must provide non-empty task ID")
@@ -189,9 +404,18 @@ func (r *repo) UpdateState(taskID string, req StateRequest) error {
 	}
 
 	r.taskStatesLock.Lock()
-	defer r.taskStatesLock.Unlock()
-
 	r.taskStates[taskID] = State{Stop: req.Stop}
+	r.taskStatesLock.Unlock()
+
+	if req.Stop {
+		r.tasksLock.RLock()
+		agentID, found := r.taskAgents[taskID]
+		r.tasksLock.RUnlock()
+
+		if found {
+			r.notify(agentID)
+		}
+	}
 
 	return nil
 }
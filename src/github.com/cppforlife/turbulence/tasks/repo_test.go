@@ -0,0 +1,196 @@
+package tasks_test
+
+import (
+	"time"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cppforlife/turbulence/tasks"
+)
+
+var _ = Describe("Repo", func() {
+	var (
+		repo Repo
+	)
+
+	BeforeEach(func() {
+		repo = NewRepo(boshlog.NewLogger(boshlog.LevelNone))
+	})
+
+	queue := func(agentID string, taskIDs ...string) chan error {
+		var ts []Task
+
+		for _, id := range taskIDs {
+			ts = append(ts, Task{ID: id, Optionss: OptionsSlice{NoopOptions{}}})
+		}
+
+		errCh := make(chan error, 1)
+
+		go func() {
+			errCh <- repo.QueueAndWait(agentID, ts, nil)
+		}()
+
+		return errCh
+	}
+
+	Describe("Poll", func() {
+		It("returns tasks that were already queued for the agent", func() {
+			errCh := queue("agent1", "task1")
+
+			Eventually(func() []Task {
+				ts, _, err := repo.Poll("agent1", nil, 0, nil)
+				Expect(err).ToNot(HaveOccurred())
+				return ts
+			}).Should(HaveLen(1))
+
+			Eventually(errCh).Should(Receive(BeNil()))
+		})
+
+		It("returns as soon as tasks are queued for the agent", func() {
+			type pollResult struct {
+				Tasks []Task
+				Err   error
+			}
+
+			resultCh := make(chan pollResult, 1)
+
+			go func() {
+				ts, _, err := repo.Poll("agent1", nil, time.Minute, nil)
+				resultCh <- pollResult{ts, err}
+			}()
+
+			queue("agent2", "task2")
+			Consistently(resultCh, 100*time.Millisecond).ShouldNot(Receive())
+
+			queue("agent1", "task1")
+
+			var result pollResult
+			Eventually(resultCh).Should(Receive(&result))
+			Expect(result.Err).ToNot(HaveOccurred())
+			Expect(result.Tasks).To(HaveLen(1))
+			Expect(result.Tasks[0].ID).To(Equal("task1"))
+		})
+
+		It("returns running tasks that are asked to stop", func() {
+			queue("agent1", "task1", "task2")
+
+			Eventually(func() []Task {
+				ts, _, _ := repo.Poll("agent1", nil, 0, nil)
+				return ts
+			}).Should(HaveLen(2))
+
+			stopCh := make(chan []string, 1)
+
+			go func() {
+				_, stopTaskIDs, _ := repo.Poll("agent1", []string{"task1", "task2"}, time.Minute, nil)
+				stopCh <- stopTaskIDs
+			}()
+
+			Consistently(stopCh, 100*time.Millisecond).ShouldNot(Receive())
+
+			err := repo.UpdateState("task2", StateRequest{Stop: true})
+			Expect(err).ToNot(HaveOccurred())
+
+			Eventually(stopCh).Should(Receive(Equal([]string{"task2"})))
+
+			// Stop signal is repeated only for tasks that agent still reports
+			_, stopTaskIDs, err := repo.Poll("agent1", []string{"task1"}, 0, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(stopTaskIDs).To(BeEmpty())
+		})
+
+		It("returns nothing once timeout passes", func() {
+			ts, stopTaskIDs, err := repo.Poll("agent1", []string{"task1"}, 10*time.Millisecond, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ts).To(BeEmpty())
+			Expect(stopTaskIDs).To(BeEmpty())
+		})
+
+		It("does not consume tasks once cancelled", func() {
+			cancelCh := make(chan struct{})
+			close(cancelCh)
+
+			errCh := queue("agent1", "task1")
+
+			Consistently(func() []Task {
+				ts, _, _ := repo.Poll("agent1", nil, time.Minute, cancelCh)
+				return ts
+			}, 100*time.Millisecond).Should(BeEmpty())
+
+			Eventually(func() []Task {
+				ts, _, _ := repo.Poll("agent1", nil, 0, nil)
+				return ts
+			}).Should(HaveLen(1))
+
+			Eventually(errCh).Should(Receive(BeNil()))
+		})
+
+		It("returns error when agent ID is empty", func() {
+			_, _, err := repo.Poll("", nil, 0, nil)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("WaitProgress", func() {
+		type progressResult struct {
+			Progress []Progress
+			Finished bool
+		}
+
+		BeforeEach(func() {
+			queue("agent1", "task1")
+
+			Eventually(func() []Task {
+				ts, _, _ := repo.Poll("agent1", nil, 0, nil)
+				return ts
+			}).Should(HaveLen(1))
+		})
+
+		waitProgress := func(known int) chan progressResult {
+			resultCh := make(chan progressResult, 1)
+
+			go func() {
+				progress, finished, err := repo.WaitProgress("task1", known)
+				Expect(err).ToNot(HaveOccurred())
+				resultCh <- progressResult{progress, finished}
+			}()
+
+			return resultCh
+		}
+
+		It("returns once task reports more progress than known", func() {
+			applying := Progress{State: ProgressApplying}
+			active := Progress{State: ProgressActive}
+
+			Expect(repo.UpdateProgress("task1", []Progress{applying})).ToNot(HaveOccurred())
+
+			resultCh := waitProgress(1)
+			Consistently(resultCh, 100*time.Millisecond).ShouldNot(Receive())
+
+			Expect(repo.UpdateProgress("task1", []Progress{applying, active})).ToNot(HaveOccurred())
+
+			Eventually(resultCh).Should(Receive(Equal(progressResult{[]Progress{applying, active}, false})))
+		})
+
+		It("ignores progress older than already known", func() {
+			applying := Progress{State: ProgressApplying}
+			active := Progress{State: ProgressActive}
+
+			Expect(repo.UpdateProgress("task1", []Progress{applying, active})).ToNot(HaveOccurred())
+			Expect(repo.UpdateProgress("task1", []Progress{applying})).ToNot(HaveOccurred())
+
+			Eventually(waitProgress(0)).Should(Receive(Equal(progressResult{[]Progress{applying, active}, false})))
+		})
+
+		It("returns once task finishes", func() {
+			resultCh := waitProgress(0)
+			Consistently(resultCh, 100*time.Millisecond).ShouldNot(Receive())
+
+			Expect(repo.Update("task1", ResultRequest{})).ToNot(HaveOccurred())
+
+			Eventually(resultCh).Should(Receive(Equal(progressResult{nil, true})))
+		})
+	})
+})
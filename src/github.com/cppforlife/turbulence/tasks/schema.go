@@ -0,0 +1,91 @@
+package tasks
+
+import (
+	"reflect"
+	"strings"
+)
+
+// OptionSchema describes a single option as it appears in JSON
+type OptionSchema struct {
+	Name string
+
+	// One of string, bool, integer, number, array, object
+	Type string
+
+	// Options of nested objects or of objects in an array
+	Options []OptionSchema `json:",omitempty"`
+}
+
+// OptionsSchema describes options of the task type excluding Type itself
+func (t TaskType) OptionsSchema() []OptionSchema {
+	var schemas []OptionSchema
+
+	for _, schema := range structSchema(reflect.TypeOf(t.NewOptions())) {
+		if schema.Name != "Type" {
+			schemas = append(schemas, schema)
+		}
+	}
+
+	return schemas
+}
+
+func structSchema(structType reflect.Type) []OptionSchema {
+	schemas := []OptionSchema{}
+
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+
+		if len(field.PkgPath) > 0 {
+			continue // unexported
+		}
+
+		name := field.Name
+
+		if tag := field.Tag.Get("json"); len(tag) > 0 {
+			tagName := strings.Split(tag, ",")[0]
+			if tagName == "-" {
+				continue
+			}
+			if len(tagName) > 0 {
+				name = tagName
+			}
+		}
+
+		schema := OptionSchema{Name: name}
+		schema.Type, schema.Options = typeSchema(field.Type)
+
+		schemas = append(schemas, schema)
+	}
+
+	return schemas
+}
+
+func typeSchema(t reflect.Type) (string, []OptionSchema) {
+	switch t.Kind() {
+	case reflect.Ptr:
+		return typeSchema(t.Elem())
+
+	case reflect.String:
+		return "string", nil
+
+	case reflect.Bool:
+		return "bool", nil
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer", nil
+
+	case reflect.Float32, reflect.Float64:
+		return "number", nil
+
+	case reflect.Slice, reflect.Array:
+		_, elemOpts := typeSchema(t.Elem())
+		return "array", elemOpts
+
+	case reflect.Struct:
+		return "object", structSchema(t)
+
+	default:
+		return "object", nil
+	}
+}
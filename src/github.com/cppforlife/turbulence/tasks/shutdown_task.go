@@ -2,6 +2,7 @@ package tasks
 
 import (
 	"fmt"
+	"regexp"
 
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
@@ -19,7 +20,37 @@ type ShutdownOptions struct {
 	Sysrq string
 }
 
-func (ShutdownOptions) _private() {}
+var sysrqPattern = regexp.MustCompile(`^[0-9a-z]$`)
+
+func init() {
+	Register(TaskType{
+		Name:        "Shutdown",
+		Description: "Powers off, reboots or crashes VM",
+
+		NewOptions: func() Options { return ShutdownOptions{} },
+		NewTask: func(opts Options, deps AgentDeps) (AgentTask, error) {
+			return NewShutdownTask(deps.CmdRunner, opts.(ShutdownOptions), deps.Logger), nil
+		},
+
+		RequiredCapabilities: func(opts Options) []string {
+			if typedOpts := opts.(ShutdownOptions); typedOpts.Crash || len(typedOpts.Sysrq) > 0 {
+				return []string{CapabilitySysrq}
+			}
+			return nil
+		},
+	})
+}
+
+func (o ShutdownOptions) Validate() error {
+	var errs fieldErrors
+
+	// Only the first character written to /proc/sysrq-trigger is used
+	if len(o.Sysrq) > 0 && !sysrqPattern.MatchString(o.Sysrq) {
+		errs.add("Sysrq", "'%s' must be a single sysrq command character", o.Sysrq)
+	}
+
+	return errs.err()
+}
 
 type ShutdownTask struct {
 	cmdRunner boshsys.CmdRunner
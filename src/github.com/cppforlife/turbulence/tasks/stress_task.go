@@ -2,6 +2,7 @@ package tasks
 
 import (
 	"strconv"
+	"strings"
 	"time"
 
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
@@ -23,18 +24,63 @@ type StressOptions struct {
 	HDDWorkerBytes string // Sizes may be suffixed with B,K,M,G
 }
 
-func (StressOptions) _private() {}
+func init() {
+	Register(TaskType{
+		Name:        "Stress",
+		Description: "Stresses CPU, IO, memory and disk with stress utility",
+
+		NewOptions: func() Options { return StressOptions{} },
+		NewTask: func(opts Options, deps AgentDeps) (AgentTask, error) {
+			return NewStressTask(deps.CmdRunner, opts.(StressOptions), deps.Recorder, deps.Logger), nil
+		},
+
+		RequiredCapabilities: func(Options) []string { return []string{CapabilityStress} },
+		RunsUntilStopped:     func(opts Options) bool { return len(opts.(StressOptions).Timeout) == 0 },
+	})
+}
+
+func (o StressOptions) Validate() error {
+	var errs fieldErrors
+
+	workers := map[string]int{
+		"NumCPUWorkers":    o.NumCPUWorkers,
+		"NumIOWorkers":     o.NumIOWorkers,
+		"NumMemoryWorkers": o.NumMemoryWorkers,
+		"NumHDDWorkers":    o.NumHDDWorkers,
+	}
+
+	for _, field := range []string{"NumCPUWorkers", "NumIOWorkers", "NumMemoryWorkers", "NumHDDWorkers"} {
+		if workers[field] < 0 {
+			errs.add(field, "must not be negative")
+		}
+	}
+
+	if o.NumCPUWorkers+o.NumIOWorkers+o.NumMemoryWorkers+o.NumHDDWorkers <= 0 {
+		errs.add("NumCPUWorkers", "must specify at least 1 type of worker")
+	}
+
+	if o.NumMemoryWorkers > 0 && len(o.MemoryWorkerBytes) == 0 {
+		errs.add("MemoryWorkerBytes", "must be specified when NumMemoryWorkers is set")
+	}
+
+	if o.NumHDDWorkers > 0 && len(o.HDDWorkerBytes) == 0 {
+		errs.add("HDDWorkerBytes", "must be specified when NumHDDWorkers is set")
+	}
+
+	return errs.err()
+}
 
 type StressTask struct {
 	cmdRunner boshsys.CmdRunner
 	opts      StressOptions
+	recorder  Recorder
 
 	logTag string
 	logger boshlog.Logger
 }
 
-func NewStressTask(cmdRunner boshsys.CmdRunner, opts StressOptions, logger boshlog.Logger) StressTask {
-	return StressTask{cmdRunner, opts, "task.StressTask", logger}
+func NewStressTask(cmdRunner boshsys.CmdRunner, opts StressOptions, recorder Recorder, logger boshlog.Logger) StressTask {
+	return StressTask{cmdRunner, opts, recorder, "task.StressTask", logger}
 }
 
 func (t StressTask) Execute(stopCh chan struct{}) error {
@@ -97,7 +143,11 @@ func (t StressTask) runStress(args []string, stopCh chan struct{}) error {
 		return bosherr.WrapError(err, "Shelling out to stress")
 	}
 
+	t.recorder.Action("stress " + strings.Join(args, " "))
+	t.recorder.Applied()
+
 	var result boshsys.Result
+	var terminateErr error
 
 	isStopped := false
 
@@ -107,15 +157,23 @@ func (t StressTask) runStress(args []string, stopCh chan struct{}) error {
 		case result = <-procExitedCh:
 			procExitedCh = nil
 		case <-stopCh:
+			if !isStopped {
+				t.recorder.Reverting()
+			}
+
 			// Ignore possible TerminateNicely error since we cannot return it
 			err := process.TerminateNicely(10 * time.Second)
 			if err != nil {
 				t.logger.Error(t.logTag, "Failed to terminate %s", err.Error())
+				terminateErr = err
 			}
 			isStopped = true
 		}
 	}
 
+	// Load stops once stress exits
+	t.recorder.Reverted(terminateErr)
+
 	if isStopped {
 		return nil // todo successfully stopped?
 	}
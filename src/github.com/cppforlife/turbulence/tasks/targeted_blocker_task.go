@@ -1,14 +1,16 @@
-// See https://wiki.centos.org/HowTos/Network/IPTables for a good iptables tutorial
-
 package tasks
 
 import (
+	"fmt"
+	"net"
 	"regexp"
 	"strings"
 
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
+
+	"github.com/cppforlife/turbulence/tasks/netctl"
 )
 
 type TargetedBlockerOptions struct {
@@ -19,9 +21,8 @@ type TargetedBlockerOptions struct {
 }
 
 var ipPattern = regexp.MustCompile(`(\d{1,3}\.\d{1,3}\.\d{1,3}\.\d{1,3})(/\d{0,2})?`)
-var portPattern = regexp.MustCompile(`\d+(:\d+)?$`)
 
-// Target defines a rule for iptables. Each rule must contain one of {Host, DstPorts, SrcPorts}.
+// Target defines a packet filter rule. Each rule must contain one of {Host, DstPorts, SrcPorts}.
 // If DstPorts or SrcPorts ports are included without a DstHost or SrcHost, then those ports will be blocked for all hosts.
 // If Host is included without DstPorts or SrcPorts, then all traffic to/from those hosts will be blocked.
 type Target struct {
@@ -33,7 +34,7 @@ type Target struct {
 	// or a domain name such as "google.com" which will be resolved to an Ip.
 	SrcHost string
 
-	// Optional direction to block traffic, must be in the set {INPUT, OUTPUT, BOTH}. Defaults to "BOTH".
+	// Required direction to block traffic, must be in the set {INPUT, OUTPUT, FORWARD} (case insensitive).
 	Direction string
 
 	// Optional protocol to block, must be in the set {udp, tcp, icmp, all}. Defaults to "all".
@@ -46,20 +47,83 @@ type Target struct {
 	SrcPorts string
 }
 
-func (TargetedBlockerOptions) _private() {}
+func init() {
+	Register(TaskType{
+		Name:        "TargetedBlocker",
+		Description: "Drops traffic to or from specific hosts, ports and protocols",
+
+		NewOptions: func() Options { return TargetedBlockerOptions{} },
+		NewTask: func(opts Options, deps AgentDeps) (AgentTask, error) {
+			return NewTargetedBlockerTask(deps.CmdRunner, deps.NetCtl, opts.(TargetedBlockerOptions), deps.TaskID, deps.Journal, deps.Recorder, deps.Logger), nil
+		},
+
+		RequiredCapabilities: func(opts Options) []string {
+			required := []string{CapabilityNftables}
+
+			// Host names are resolved with dig
+			for _, target := range opts.(TargetedBlockerOptions).Targets {
+				for _, host := range []string{target.DstHost, target.SrcHost} {
+					if len(host) > 0 && !ipPattern.MatchString(host) {
+						return append(required, CapabilityDig)
+					}
+				}
+			}
+
+			return required
+		},
+	})
+}
+
+func (o TargetedBlockerOptions) Validate() error {
+	var errs fieldErrors
+
+	errs.validateTimeout(o.Timeout, true)
+
+	for i, target := range o.Targets {
+		errs.addNested(fmt.Sprintf("Targets[%d]", i), target.Validate())
+	}
+
+	return errs.err()
+}
+
+func (t Target) Validate() error {
+	var errs fieldErrors
+
+	if t.SrcHost == "" && t.DstHost == "" && t.DstPorts == "" && t.SrcPorts == "" {
+		errs.add("DstHost", "or one of SrcHost, DstPorts, SrcPorts must be specified")
+	}
+
+	errs.validateOneOf("Direction", strings.ToUpper(t.Direction), []string{"INPUT", "OUTPUT", "FORWARD"})
+
+	if len(t.Protocol) > 0 {
+		errs.validateOneOf("Protocol", strings.ToLower(t.Protocol), []string{"tcp", "udp", "icmp", "all"})
+	}
+
+	errs.validatePorts("DstPorts", t.DstPorts, t.Protocol)
+	errs.validatePorts("SrcPorts", t.SrcPorts, t.Protocol)
+
+	return errs.err()
+}
 
 type TargetedBlockerTask struct {
 	cmdRunner boshsys.CmdRunner
 	opts      TargetedBlockerOptions
+	filter    packetFilter
+	recorder  Recorder
 	logger    boshlog.Logger
 }
 
 func NewTargetedBlockerTask(
 	cmdRunner boshsys.CmdRunner,
+	netCtl netctl.Controller,
 	opts TargetedBlockerOptions,
+	taskID string,
+	journal Journal,
+	recorder Recorder,
 	logger boshlog.Logger,
 ) TargetedBlockerTask {
-	return TargetedBlockerTask{cmdRunner, opts, logger}
+	filter := newPacketFilter(netCtl, taskID, journal, recorder)
+	return TargetedBlockerTask{cmdRunner, opts, filter, recorder, logger}
 }
 
 func (t TargetedBlockerTask) Execute(stopCh chan struct{}) error {
@@ -73,140 +137,139 @@ func (t TargetedBlockerTask) Execute(stopCh chan struct{}) error {
 		return err
 	}
 
-	for _, rule := range rules {
-		r := []string{rule[0], "1"} // we want it inserted at the beginning of the rules or it may have no effect.
-		r = append(r, rule[1:]...)
-		err := t.iptables("-I", r)
-		if err != nil {
-			return err
-		}
+	err = t.filter.Apply(rules)
+	if err != nil {
+		return err
 	}
 
+	t.recorder.Applied()
+
 	select {
 	case <-timeoutCh:
 	case <-stopCh:
 	}
 
-	for _, r := range rules {
-		err := t.iptables("-D", r)
-		if err != nil {
-			return err
-		}
-	}
+	t.recorder.Reverting()
+
+	err = t.filter.Remove()
+	t.recorder.Reverted(err)
 
-	return nil
+	return err
 }
 
-func (t TargetedBlockerTask) getHost(host string) ([]string, error) {
+func (t TargetedBlockerTask) getHost(host string) ([]*net.IPNet, error) {
+	var hosts []string
+
 	if host == "" {
 		return nil, nil
 	} else if ipPattern.MatchString(host) {
-		return ipPattern.FindAllString(host, -1), nil
+		hosts = ipPattern.FindAllString(host, -1)
 	} else {
-		return t.dig(host)
+		resolved, err := t.dig(host)
+		if err != nil {
+			return nil, err
+		}
+		hosts = resolved
 	}
-}
 
-func appendHosts(cmd []string, flag string, hosts ...string) []string {
-	ips := ""
-	for i, ip := range hosts {
-		if i > 0 {
-			ips += ","
+	var ipNets []*net.IPNet
+
+	for _, h := range hosts {
+		ipNet, err := netctl.ParseIPNet(h)
+		if err != nil {
+			return nil, err
 		}
-		ips += ip
+		ipNets = append(ipNets, ipNet)
 	}
 
-	return append(cmd, "-d", ips)
+	return ipNets, nil
 }
 
-func (t TargetedBlockerTask) rules() ([][]string, error) {
-	rules := [][]string{}
+func (t TargetedBlockerTask) rules() ([]netctl.Rule, error) {
+	rules := []netctl.Rule{}
 
 	for _, target := range t.opts.Targets {
 		if target.SrcHost == "" && target.DstHost == "" && target.DstPorts == "" && target.SrcPorts == "" {
 			return nil, bosherr.Error("Must specify at least one of SrcHost, DstHost, DstPorts, and or SrcPorts.")
 		}
 
-		var dsthosts []string
-		var direction, protocol, dports, sports string
+		var hook string
+		var protocols []string
+		var dports, sports netctl.PortRange
 
 		srchosts, err := t.getHost(target.SrcHost)
 		if err != nil {
 			return nil, err
 		}
 
-		dsthosts, err = t.getHost(target.DstHost)
+		dsthosts, err := t.getHost(target.DstHost)
 		if err != nil {
 			return nil, err
 		}
 
 		switch strings.ToUpper(target.Direction) {
 		case "INPUT":
-			direction = "INPUT"
+			hook = netctl.HookInput
 		case "OUTPUT":
-			direction = "OUTPUT"
+			hook = netctl.HookOutput
 		case "FORWARD":
-			direction = "FORWARD"
+			hook = netctl.HookForward
 		default:
 			return nil, bosherr.Errorf("Invalid direction '%v', must be one of {INPUT, OUTPUT, FORWARD}.", target.Direction)
 		}
 
-		switch strings.ToLower(target.Protocol) {
-		case "":
-			protocol = ""
-		case "tcp":
-			protocol = "tcp"
-		case "udp":
-			protocol = "udp"
-		case "icmp":
-			protocol = "icmp"
-		case "all":
-			protocol = "all"
-		default:
-			return nil, bosherr.Errorf("Invalid protocol '%v', must be one of {tcp, udp, icmp, all} or blank.", target.Protocol)
+		if target.DstPorts != "" {
+			dports, err = netctl.ParsePortRange(target.DstPorts)
+			if err != nil {
+				return nil, bosherr.Errorf("Invalid destination port specified %v", target.DstPorts)
+			}
 		}
 
-		if target.DstPorts == "" {
-			dports = ""
-		} else if portPattern.MatchString(target.DstPorts) {
-			dports = target.DstPorts
-		} else {
-			return nil, bosherr.Errorf("Invalid destination port specified %v", target.DstPorts)
+		if target.SrcPorts != "" {
+			sports, err = netctl.ParsePortRange(target.SrcPorts)
+			if err != nil {
+				return nil, bosherr.Errorf("Invalid source port specified %v", target.SrcPorts)
+			}
 		}
 
-		if target.SrcPorts == "" {
-			sports = ""
-		} else if portPattern.MatchString(target.SrcPorts) {
-			sports = target.SrcPorts
-		} else {
-			return nil, bosherr.Errorf("Invalid destination port specified %v", target.SrcPorts)
-		}
-
-		cmd := []string{direction}
-
-		if dsthosts != nil {
-			cmd = appendHosts(cmd, "-d", dsthosts...)
-		}
-
-		if srchosts != nil {
-			cmd = appendHosts(cmd, "-s", srchosts...)
+		switch strings.ToLower(target.Protocol) {
+		case "", "all":
+			// Ports are only known for tcp and udp
+			if !dports.IsZero() || !sports.IsZero() {
+				protocols = []string{"tcp", "udp"}
+			} else {
+				protocols = []string{""}
+			}
+		case "tcp", "udp", "icmp":
+			protocols = []string{strings.ToLower(target.Protocol)}
+		default:
+			return nil, bosherr.Errorf("Invalid protocol '%v', must be one of {tcp, udp, icmp, all} or blank.", target.Protocol)
 		}
 
-		if protocol != "" {
-			cmd = append(cmd, "-p", protocol)
+		// Rules are added for each combination of hosts
+		if srchosts == nil {
+			srchosts = []*net.IPNet{nil}
 		}
 
-		if dports != "" {
-			cmd = append(cmd, "--dport", dports)
+		if dsthosts == nil {
+			dsthosts = []*net.IPNet{nil}
 		}
 
-		if sports != "" {
-			cmd = append(cmd, "--sport", sports)
+		for _, protocol := range protocols {
+			for _, src := range srchosts {
+				for _, dst := range dsthosts {
+					rules = append(rules, netctl.Rule{
+						Hook:     hook,
+						Protocol: protocol,
+						Src:      src,
+						Dst:      dst,
+						SrcPorts: sports,
+						DstPorts: dports,
+						Verdict:  netctl.VerdictDrop,
+					})
+				}
+			}
 		}
-
-		cmd = append(cmd, "-j", "DROP")
-
-		rules = append(rules, cmd)
 	}
 
 	return rules, nil
@@ -224,16 +287,7 @@ func (t TargetedBlockerTask) dig(hostname string) ([]string, error) {
 		return nil, bosherr.Errorf("No IPs found for host %v", hostname)
 	}
 
-	return ips, nil
-}
-
-func (t TargetedBlockerTask) iptables(action string, rule []string) error {
-	args := append([]string{action}, rule...)
-
-	_, _, _, err := t.cmdRunner.RunCommand("iptables", args...)
-	if err != nil {
-		return bosherr.WrapError(err, "Shelling out to iptables")
-	}
+	t.recorder.ResolvedIPs(hostname, ips)
 
-	return nil
+	return ips, nil
 }
@@ -0,0 +1,142 @@
+package tasks
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cppforlife/turbulence/tasks/netctl"
+)
+
+// FieldError describes why a single option is invalid;
+// nested fields are named by their path, e.g. Targets[0].Direction
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+// ValidationError is returned for options that agents would fail to execute
+type ValidationError struct {
+	Errors []FieldError
+}
+
+func (e ValidationError) Error() string {
+	var msgs []string
+
+	for _, fieldErr := range e.Errors {
+		msgs = append(msgs, fmt.Sprintf("%s %s", fieldErr.Field, fieldErr.Message))
+	}
+
+	return fmt.Sprintf("Invalid task options: %s", strings.Join(msgs, "; "))
+}
+
+// Fields maps field paths to their error messages
+func (e ValidationError) Fields() map[string]string {
+	fields := map[string]string{}
+
+	for _, fieldErr := range e.Errors {
+		if _, found := fields[fieldErr.Field]; found {
+			fields[fieldErr.Field] += "; " + fieldErr.Message
+		} else {
+			fields[fieldErr.Field] = fieldErr.Message
+		}
+	}
+
+	return fields
+}
+
+// Nested returns the same errors with field paths prefixed by given field
+func (e ValidationError) Nested(field string) ValidationError {
+	var nested []FieldError
+
+	for _, fieldErr := range e.Errors {
+		sep := "."
+		if strings.HasPrefix(fieldErr.Field, "[") {
+			sep = ""
+		}
+		nested = append(nested, FieldError{Field: field + sep + fieldErr.Field, Message: fieldErr.Message})
+	}
+
+	return ValidationError{nested}
+}
+
+func (s OptionsSlice) Validate() error {
+	var errs fieldErrors
+
+	for i, opts := range s {
+		errs.addNested(fmt.Sprintf("[%d]", i), opts.Validate())
+	}
+
+	return errs.err()
+}
+
+type fieldErrors []FieldError
+
+func (e *fieldErrors) add(field, msg string, args ...interface{}) {
+	*e = append(*e, FieldError{Field: field, Message: fmt.Sprintf(msg, args...)})
+}
+
+func (e *fieldErrors) addNested(field string, err error) {
+	if err == nil {
+		return
+	}
+
+	if valErr, ok := err.(ValidationError); ok {
+		*e = append(*e, valErr.Nested(field).Errors...)
+	} else {
+		e.add(field, "%s", err.Error())
+	}
+}
+
+func (e fieldErrors) err() error {
+	if len(e) == 0 {
+		return nil
+	}
+
+	return ValidationError{[]FieldError(e)}
+}
+
+// validateTimeout matches how NewOptionalTimeoutCh and NewMandatoryTimeoutCh parse timeouts
+func (e *fieldErrors) validateTimeout(timeoutStr string, required bool) {
+	if len(timeoutStr) == 0 {
+		if required {
+			e.add("Timeout", "must be specified")
+		}
+		return
+	}
+
+	timeout, err := time.ParseDuration(timeoutStr)
+	if err != nil {
+		e.add("Timeout", "'%s' must be a duration suffixed with ms,s,m,h", timeoutStr)
+	} else if timeout < 0 {
+		e.add("Timeout", "'%s' must not be negative", timeoutStr)
+	}
+}
+
+func (e *fieldErrors) validateOneOf(field, val string, allowed []string) {
+	for _, a := range allowed {
+		if val == a {
+			return
+		}
+	}
+
+	quoted := make([]string, len(allowed))
+	for i, a := range allowed {
+		quoted[i] = fmt.Sprintf("'%s'", a)
+	}
+
+	e.add(field, "'%s' must be one of %s", val, strings.Join(quoted, ", "))
+}
+
+// validatePorts matches how packet filter rules parse a port or a range of ports
+func (e *fieldErrors) validatePorts(field, ports, protocol string) {
+	if len(ports) == 0 {
+		return
+	}
+
+	if _, err := netctl.ParsePortRange(ports); err != nil {
+		e.add(field, "'%s' must be a port or a range of ports", ports)
+	} else if strings.ToLower(protocol) == "icmp" {
+		e.add(field, "cannot be specified for icmp protocol")
+	}
+}
@@ -0,0 +1,114 @@
+package tasks_test
+
+import (
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cppforlife/turbulence/tasks"
+)
+
+var _ = Describe("OptionsSlice", func() {
+	Describe("Validate", func() {
+		unmarshal := func(str string) OptionsSlice {
+			var opts OptionsSlice
+			Expect(json.Unmarshal([]byte(str), &opts)).ToNot(HaveOccurred())
+			return opts
+		}
+
+		fields := func(err error) map[string]string {
+			Expect(err).To(HaveOccurred())
+			Expect(err).To(BeAssignableToTypeOf(ValidationError{}))
+			return err.(ValidationError).Fields()
+		}
+
+		It("accepts valid options of every type", func() {
+			opts := unmarshal(`[
+				{ "Type": "Noop" },
+				{ "Type": "Kill" },
+				{ "Type": "KillProcess" },
+				{ "Type": "PauseProcess", "ProcessName": "nginx" },
+				{ "Type": "Stress", "NumMemoryWorkers": 1, "MemoryWorkerBytes": "128M" },
+				{ "Type": "ControlNet", "Timeout": "10m", "Delay": "50ms", "Targets": [{ "DstPort": "8080" }] },
+				{ "Type": "Firewall", "Timeout": "10m" },
+				{ "Type": "TargetedBlocker", "Timeout": "10m", "Targets": [{ "DstHost": "10.0.0.1", "Direction": "output", "Protocol": "TCP" }] },
+				{ "Type": "BlockDNS", "Timeout": "10m" },
+				{ "Type": "FillDisk", "Timeout": "10m" },
+				{ "Type": "Shutdown", "Sysrq": "b" }
+			]`)
+
+			Expect(opts.Validate()).ToNot(HaveOccurred())
+		})
+
+		It("requires timeout for tasks that revert their effects", func() {
+			opts := unmarshal(`[{ "Type": "Firewall" }, { "Type": "FillDisk", "Timeout": "10 minutes" }]`)
+
+			Expect(fields(opts.Validate())).To(Equal(map[string]string{
+				"[0].Timeout": "must be specified",
+				"[1].Timeout": "'10 minutes' must be a duration suffixed with ms,s,m,h",
+			}))
+		})
+
+		It("allows pause process timeout to be omitted", func() {
+			opts := unmarshal(`[{ "Type": "PauseProcess", "ProcessName": "nginx" }]`)
+			Expect(opts.Validate()).ToNot(HaveOccurred())
+		})
+
+		It("requires memory worker bytes for stress memory workers", func() {
+			opts := unmarshal(`[{ "Type": "Stress", "NumMemoryWorkers": 2 }]`)
+
+			Expect(fields(opts.Validate())).To(Equal(map[string]string{
+				"[0].MemoryWorkerBytes": "must be specified when NumMemoryWorkers is set",
+			}))
+		})
+
+		It("requires at least one stress worker", func() {
+			opts := unmarshal(`[{ "Type": "Stress" }]`)
+			Expect(fields(opts.Validate())).To(HaveKey("[0].NumCPUWorkers"))
+		})
+
+		It("rejects bandwidth limit combined with other network effects", func() {
+			opts := unmarshal(`[{ "Type": "ControlNet", "Timeout": "1m", "Bandwidth": "1mbps", "Delay": "10ms", "Loss": "5%" }]`)
+
+			Expect(fields(opts.Validate())).To(Equal(map[string]string{
+				"[0].Bandwidth": "cannot be limited at the same time as Delay, Loss",
+			}))
+		})
+
+		It("requires at least one network effect", func() {
+			opts := unmarshal(`[{ "Type": "ControlNet", "Timeout": "1m", "Targets": [{}] }]`)
+
+			Expect(fields(opts.Validate())).To(Equal(map[string]string{
+				"[0].Bandwidth":          "or one of Delay, Loss, Duplication, Corruption, Reorder must be specified",
+				"[0].Targets[0].DstHost": "or DstPort must be specified",
+			}))
+		})
+
+		It("rejects unknown targeted blocker directions and protocols", func() {
+			opts := unmarshal(`[{
+				"Type": "TargetedBlocker",
+				"Timeout": "1m",
+				"Targets": [
+					{ "DstHost": "10.0.0.1", "Direction": "INPUT" },
+					{ "DstHost": "10.0.0.1", "Direction": "BOTH", "Protocol": "sctp" },
+					{ "DstPorts": "http" }
+				]
+			}]`)
+
+			Expect(fields(opts.Validate())).To(Equal(map[string]string{
+				"[0].Targets[1].Direction": "'BOTH' must be one of 'INPUT', 'OUTPUT', 'FORWARD'",
+				"[0].Targets[1].Protocol":  "'sctp' must be one of 'tcp', 'udp', 'icmp', 'all'",
+				"[0].Targets[2].Direction": "'' must be one of 'INPUT', 'OUTPUT', 'FORWARD'",
+				"[0].Targets[2].DstPorts":  "'http' must be a port or a range of ports",
+			}))
+		})
+
+		It("includes all field errors in the error message", func() {
+			opts := unmarshal(`[{ "Type": "BlockDNS" }, { "Type": "PauseProcess" }]`)
+
+			Expect(opts.Validate().Error()).To(Equal(
+				"Invalid task options: [0].Timeout must be specified; [1].ProcessName must be specified"))
+		})
+	})
+})